@@ -0,0 +1,288 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Action the ACL action type
+type Action uint8
+
+// There are ACL actions
+const (
+	ActionFire Action = 1 << iota
+	ActionListen
+	// ActionAll fire and listen
+	ActionAll = ActionFire | ActionListen
+)
+
+// String get action name
+func (a Action) String() string {
+	switch a {
+	case ActionFire:
+		return "fire"
+	case ActionListen:
+		return "listen"
+	case ActionAll:
+		return "all"
+	}
+	return "unknown"
+}
+
+// AnyIdentity the identity for match all identities in ACL rule
+const AnyIdentity = "*"
+
+// ACLRule a rule of the ACL
+type ACLRule struct {
+	// Identity of the producer or consumer. AnyIdentity for all.
+	Identity string
+	Action   Action
+	// Pattern the event name pattern. see MatchName()
+	Pattern string
+	Allow   bool
+}
+
+// match check the rule is matched
+func (r *ACLRule) match(identity string, action Action, name string) bool {
+	if r.Identity != AnyIdentity && r.Identity != identity {
+		return false
+	}
+
+	if r.Action&action == 0 {
+		return false
+	}
+
+	// for listen, the name maybe is a pattern. eg: "*", "billing.*", "*ing.charged"
+	// the deny rule is matched if any event name can be matched by both of them,
+	// the allow rule is matched only if all names matched the pattern are allowed.
+	if action == ActionListen && !r.Allow {
+		return patternsOverlap(r.Pattern, name)
+	}
+	return MatchName(r.Pattern, name)
+}
+
+// patternsOverlap check there is an event name can be matched by both of the patterns.
+// same as MatchName, the char '*' will not match the separator '.'.
+func patternsOverlap(a, b string) bool {
+	if a == Wildcard || b == Wildcard {
+		return true
+	}
+
+	// the memo of the checked positions. 1: overlap, 2: not overlap
+	memo := make([]uint8, (len(a)+1)*(len(b)+1))
+
+	var check func(i, j int) bool
+	check = func(i, j int) bool {
+		idx := i*(len(b)+1) + j
+		if memo[idx] != 0 {
+			return memo[idx] == 1
+		}
+
+		var ok bool
+		switch {
+		case i == len(a) && j == len(b):
+			ok = true
+		case i < len(a) && a[i] == '*':
+			// the '*' matches empty, or matches the next char of b
+			ok = check(i+1, j) || (j < len(b) && b[j] != '.' && check(i, j+1))
+		case j < len(b) && b[j] == '*':
+			ok = check(i, j+1) || (i < len(a) && a[i] != '.' && check(i+1, j))
+		case i < len(a) && j < len(b):
+			ok = a[i] == b[j] && check(i+1, j+1)
+		}
+
+		if ok {
+			memo[idx] = 1
+		} else {
+			memo[idx] = 2
+		}
+		return ok
+	}
+	return check(0, 0)
+}
+
+// ACL access control list for fire and listen events.
+// the deny rules take precedence over the allow rules.
+//
+// Usage:
+// 	acl := NewACL(true).Deny("untrusted-plugin", ActionListen, "billing.*")
+// 	em.SetACL(acl)
+// 	// register listener by identity
+// 	err := em.As("untrusted-plugin").On("billing.charged", listener) // error
+type ACL struct {
+	mu    sync.RWMutex
+	rules []*ACLRule
+	// allow the access on no rule matched.
+	defaultAllow bool
+}
+
+// NewACL create new ACL. defaultAllow is the result on no rule matched.
+func NewACL(defaultAllow bool) *ACL {
+	return &ACL{defaultAllow: defaultAllow}
+}
+
+// Allow add allow rules for the identity
+func (a *ACL) Allow(identity string, action Action, patterns ...string) *ACL {
+	return a.addRules(identity, action, true, patterns)
+}
+
+// Deny add deny rules for the identity
+func (a *ACL) Deny(identity string, action Action, patterns ...string) *ACL {
+	return a.addRules(identity, action, false, patterns)
+}
+
+func (a *ACL) addRules(identity string, action Action, allow bool, patterns []string) *ACL {
+	a.mu.Lock()
+	for _, pattern := range patterns {
+		a.rules = append(a.rules, &ACLRule{
+			Identity: identity,
+			Action:   action,
+			Pattern:  pattern,
+			Allow:    allow,
+		})
+	}
+	a.mu.Unlock()
+	return a
+}
+
+// Rules get all rules
+func (a *ACL) Rules() []*ACLRule {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.rules
+}
+
+// Check the identity can do the action on the event name.
+// will return error on access denied.
+func (a *ACL) Check(identity string, action Action, name string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	allowed := a.defaultAllow
+	var matched bool
+	for _, r := range a.rules {
+		if !r.match(identity, action, name) {
+			continue
+		}
+
+		if !r.Allow {
+			allowed = false
+			break
+		}
+
+		// first matched allow rule
+		if !matched {
+			matched, allowed = true, true
+		}
+	}
+
+	if !allowed {
+		return &AccessError{Identity: identity, Action: action, Event: name}
+	}
+	return nil
+}
+
+// AccessError the error for access denied by ACL
+type AccessError struct {
+	Identity string
+	Action   Action
+	Event    string
+}
+
+// Error string
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("event: identity '%s' cannot %s the event '%s'", e.Identity, e.Action, e.Event)
+}
+
+// SetACL setting the ACL for the manager. it's only checked on use the Principal.
+// see Manager.As()
+func (em *Manager) SetACL(acl *ACL) {
+	em.mustNotSealed()
+	em.lock()
+	em.acl = acl
+	em.unlock()
+}
+
+// ACL get the ACL of the manager
+func (em *Manager) ACL() *ACL {
+	em.rLock()
+	defer em.rUnlock()
+	return em.acl
+}
+
+// checkAccess check the access by the manager ACL. the name is checked after normalized,
+// and the replacement of the rerouted deprecated name is checked too.
+func (em *Manager) checkAccess(identity string, action Action, name string) error {
+	acl := em.ACL()
+	if acl == nil {
+		return nil
+	}
+
+	name = em.normalize(strings.TrimSpace(name))
+	if err := acl.Check(identity, action, name); err != nil {
+		return err
+	}
+
+	em.rLock()
+	d, ok := em.deprecations[name]
+	em.rUnlock()
+	if ok && d.reroute && d.replacement != name {
+		return acl.Check(identity, action, d.replacement)
+	}
+	return nil
+}
+
+// Principal a manager accessor associated with an identity.
+// all operations will be checked by the manager ACL.
+type Principal struct {
+	em       *Manager
+	identity string
+}
+
+// As get a Principal for the identity
+func (em *Manager) As(identity string) *Principal {
+	return &Principal{em: em, identity: identity}
+}
+
+// Identity get identity name
+func (p *Principal) Identity() string {
+	return p.identity
+}
+
+// On register a listener, will return error on access denied or invalid.
+func (p *Principal) On(name string, listener Listener, priority ...int) error {
+	if err := p.em.checkAccess(p.identity, ActionListen, name); err != nil {
+		return err
+	}
+	return p.em.TryOn(name, listener, priority...)
+}
+
+// Listen register a listener with options, will return error on access denied or invalid.
+func (p *Principal) Listen(name string, listener Listener, opts ListenOpts) error {
+	if err := p.em.checkAccess(p.identity, ActionListen, name); err != nil {
+		return err
+	}
+
+	if opts.Label == "" {
+		opts.Label = p.identity
+	}
+
+	return p.em.tryAddListenerItem(name, newListenerItem(listener, opts))
+}
+
+// Fire event by name, will return error on access denied or invalid.
+func (p *Principal) Fire(name string, params M) (error, Event) {
+	if err := p.em.checkAccess(p.identity, ActionFire, name); err != nil {
+		return err, nil
+	}
+	return p.em.TryFire(name, params)
+}
+
+// FireEvent fire event by instance, will return error on access denied.
+func (p *Principal) FireEvent(e Event) error {
+	if err := p.em.checkAccess(p.identity, ActionFire, e.Name()); err != nil {
+		return err
+	}
+	return p.em.FireEvent(e)
+}
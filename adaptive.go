@@ -0,0 +1,149 @@
+package event
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// There are event names fired by the adaptive mode.
+const (
+	EventListenerDemoted   = "listener.demoted"
+	EventListenerSuspended = "listener.suspended"
+)
+
+// AdaptiveConfig config for the adaptive mode.
+//
+// On the adaptive mode, listeners that repeatedly return error will be
+// demoted in priority or temporarily suspended. and the manager will fire
+// the EventListenerDemoted or EventListenerSuspended event.
+type AdaptiveConfig struct {
+	// FailThreshold the consecutive failures count for trigger demote or suspend. default is 3
+	FailThreshold int
+	// DemoteStep the priority value to decrease on reach threshold. 0 is disabled
+	DemoteStep int
+	// MinPriority the demoted priority will not less than it. default is Min
+	MinPriority int
+	// SuspendFor suspend the listener duration on reach threshold. 0 is disabled
+	SuspendFor time.Duration
+}
+
+// WithAdaptive enable the adaptive mode for failing listeners.
+// Usage:
+// 	NewManager("app", WithAdaptive(AdaptiveConfig{FailThreshold: 5, SuspendFor: time.Minute}))
+func WithAdaptive(cfg AdaptiveConfig) Option {
+	if cfg.FailThreshold <= 0 {
+		cfg.FailThreshold = 3
+	}
+	if cfg.MinPriority == 0 {
+		cfg.MinPriority = Min
+	}
+
+	return func(o *Options) {
+		o.Adaptive = &cfg
+	}
+}
+
+// listenerState the runtime state of a listener item
+type listenerState struct {
+	// consecutive failures count
+	failures int32
+	// suspend until time. unix nano
+	suspendUntil int64
+	// mark the once listener has been called. 1: called
+	called int32
+	// the priority value decreased by the adaptive mode
+	demoted int32
+	// the priority value changed by the Manager.SetPriority(), relative to the Priority
+	shift int64
+}
+
+// IsSuspended check the listener is suspended by the adaptive mode
+func (li *ListenerItem) IsSuspended() bool {
+	until := atomic.LoadInt64(&li.state.suspendUntil)
+	return until > 0 && time.Now().UnixNano() < until
+}
+
+// CurrentPriority get the priority of the listener, it's changed by the Manager.SetPriority().
+// the Priority field keeps the registered value, the item is shared by the running dispatches.
+func (li *ListenerItem) CurrentPriority() int {
+	return li.Priority + int(atomic.LoadInt64(&li.state.shift))
+}
+
+// EffectivePriority get the priority of the listener after demoted by the adaptive mode.
+// the listeners are called by it.
+func (li *ListenerItem) EffectivePriority() int {
+	return li.CurrentPriority() - int(atomic.LoadInt32(&li.state.demoted))
+}
+
+// Failures get the consecutive failures count of the listener
+func (li *ListenerItem) Failures() int {
+	return int(atomic.LoadInt32(&li.state.failures))
+}
+
+// adapt update the listener state by the handle result.
+func (em *Manager) adapt(name string, li *ListenerItem, err error) {
+	cfg := em.opts.Adaptive
+	if err == nil {
+		atomic.StoreInt32(&li.state.failures, 0)
+		return
+	}
+
+	n := atomic.AddInt32(&li.state.failures, 1)
+	if int(n) < cfg.FailThreshold {
+		return
+	}
+	atomic.StoreInt32(&li.state.failures, 0)
+
+	data := M{"event": name, "label": li.Label, "failures": int(n), "error": err}
+	if cfg.DemoteStep > 0 && li.EffectivePriority() > cfg.MinPriority {
+		data["priority"] = em.demote(name, li, cfg)
+		_ = em.FireEvent(NewBasic(EventListenerDemoted, data))
+	}
+
+	if cfg.SuspendFor > 0 {
+		until := time.Now().Add(cfg.SuspendFor)
+		atomic.StoreInt64(&li.state.suspendUntil, until.UnixNano())
+
+		data["until"] = until
+		_ = em.FireEvent(NewBasic(EventListenerSuspended, data))
+	}
+}
+
+// demote decrease the effective priority of the listener, returns the new priority.
+// the ListenerItem is shared by the running dispatches, so the demoted value is
+// stored in the atomic state, and the queue is rebuilt for keep it sorted.
+func (em *Manager) demote(name string, li *ListenerItem, cfg *AdaptiveConfig) int {
+	var priority int
+	for {
+		old := atomic.LoadInt32(&li.state.demoted)
+		priority = li.CurrentPriority() - int(old) - cfg.DemoteStep
+		if priority < cfg.MinPriority {
+			priority = cfg.MinPriority
+		}
+
+		if atomic.CompareAndSwapInt32(&li.state.demoted, old, int32(li.CurrentPriority()-priority)) {
+			break
+		}
+	}
+
+	em.lock()
+	defer em.unlock()
+
+	lq, ok := em.listeners[name]
+	if !ok {
+		return priority
+	}
+
+	items := lq.Items()
+	for _, item := range items {
+		if item == li {
+			nq := em.newQueue()
+			for _, it := range items {
+				nq.Add(it)
+			}
+			em.listeners[name] = nq
+			break
+		}
+	}
+	return priority
+}
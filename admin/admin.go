@@ -0,0 +1,334 @@
+// Package admin provide the HTTP endpoints for manage the event manager at runtime.
+//
+// Endpoints:
+// 	GET  /events                  list the events and listened names
+// 	GET  /listeners?name=order.*  list the listeners, the name is optional
+// 	POST /pause?name=order.*      pause the event name or pattern
+// 	POST /resume?name=order.*     resume the event name or pattern
+// 	POST /priority                change the listener priority. body: {"event": "", "listener": "", "priority": 100}
+// 	POST /fire                    fire a test event. body: {"name": "", "data": {}}
+// 	GET  /stats                   the runtime stats
+// 	GET  /health?probe=ready      the health report, status is 503 if the probe("ready", "live") is failed
+// 	GET  /graph?format=dot        the event flow graph, format is "json" or "dot"
+// 	GET  /tail?events=order.*     stream the dispatched events by Server-Sent Events
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gookit/event"
+)
+
+// EventInfo the event info
+type EventInfo struct {
+	Name      string `json:"name"`
+	Declared  bool   `json:"declared"`
+	Listeners int    `json:"listeners"`
+	Paused    bool   `json:"paused"`
+}
+
+// ListenerInfo the listener info
+type ListenerInfo struct {
+	Event     string `json:"event"`
+	Name      string `json:"name"`
+	Priority  int    `json:"priority"`
+	Seq       uint64 `json:"seq"`
+	Once      bool   `json:"once,omitempty"`
+	Async     bool   `json:"async,omitempty"`
+	Suspended bool   `json:"suspended,omitempty"`
+	Failures  int    `json:"failures,omitempty"`
+	Caps      string `json:"caps"`
+}
+
+// PriorityRequest the body of change priority
+type PriorityRequest struct {
+	Event    string `json:"event"`
+	Listener string `json:"listener"`
+	Priority int    `json:"priority"`
+}
+
+// FireRequest the body of fire test event
+type FireRequest struct {
+	Name string  `json:"name"`
+	Data event.M `json:"data"`
+}
+
+// Handler the admin HTTP handler
+//
+// Usage:
+// 	h := admin.New(em)
+// 	h.Authorize = func(r *http.Request) bool {
+// 		return r.Header.Get("X-Admin-Token") == token
+// 	}
+// 	http.Handle("/admin/events/", http.StripPrefix("/admin/events", h))
+type Handler struct {
+	em  *event.Manager
+	mux *http.ServeMux
+	// Authorize the request. all requests are denied if not setting,
+	// use AllowAll for serve it on the trusted network.
+	Authorize func(r *http.Request) bool
+	// ReadOnly disable the POST endpoints
+	ReadOnly bool
+}
+
+// AllowAll the Handler.Authorize func allows all requests
+func AllowAll(r *http.Request) bool {
+	return true
+}
+
+// New create the admin handler
+func New(em *event.Manager) *Handler {
+	h := &Handler{em: em, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/events", h.get(h.events))
+	h.mux.HandleFunc("/listeners", h.get(h.listeners))
+	h.mux.HandleFunc("/stats", h.get(h.stats))
+	h.mux.HandleFunc("/health", h.get(h.health))
+	h.mux.HandleFunc("/graph", h.get(h.graph))
+	h.mux.HandleFunc("/tail", h.get(h.tail))
+	h.mux.HandleFunc("/pause", h.post(h.pause))
+	h.mux.HandleFunc("/resume", h.post(h.resume))
+	h.mux.HandleFunc("/priority", h.post(h.priority))
+	h.mux.HandleFunc("/fire", h.post(h.fire))
+	return h
+}
+
+// ServeHTTP implements the http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Authorize == nil || !h.Authorize(r) {
+		writeError(w, http.StatusForbidden, "forbidden")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) get(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (h *Handler) post(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		if h.ReadOnly {
+			writeError(w, http.StatusForbidden, "the admin is read only")
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
+	infos := map[string]*EventInfo{}
+	for _, name := range h.em.EventNames() {
+		infos[name] = &EventInfo{Name: name, Declared: true}
+	}
+
+	for name, n := range h.em.ListenedNames() {
+		if info, ok := infos[name]; ok {
+			info.Listeners = n
+		} else {
+			infos[name] = &EventInfo{Name: name, Listeners: n}
+		}
+	}
+
+	list := make([]*EventInfo, 0, len(infos))
+	for _, info := range infos {
+		info.Paused = h.em.IsPaused(info.Name)
+		list = append(list, info)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) listeners(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("name")
+
+	list := []*ListenerInfo{}
+	for name := range h.em.ListenedNames() {
+		lq := h.em.ListenersByName(name)
+		if lq == nil || filter != "" && name != filter {
+			continue
+		}
+
+		for _, li := range lq.Items() {
+			list = append(list, &ListenerInfo{
+				Event:     name,
+				Name:      li.Name(),
+				Priority:  li.CurrentPriority(),
+				Seq:       li.Seq,
+				Once:      li.Once,
+				Async:     li.Async,
+				Suspended: li.IsSuspended(),
+				Failures:  li.Failures(),
+				Caps:      li.Capabilities().String(),
+			})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Event != list[j].Event {
+			return list[i].Event < list[j].Event
+		}
+		return list[i].Priority > list[j].Priority
+	})
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.em.Stats())
+}
+
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	report := h.em.HealthReport()
+
+	status := http.StatusOK
+	switch r.URL.Query().Get("probe") {
+	case "ready":
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+	case "live":
+		if !report.Live {
+			status = http.StatusServiceUnavailable
+		}
+	default:
+		if !report.Ready || !report.Live {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, report)
+}
+
+func (h *Handler) graph(w http.ResponseWriter, r *http.Request) {
+	g := h.em.Graph()
+	if r.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		_, _ = w.Write([]byte(g.DOT()))
+		return
+	}
+	writeJSON(w, http.StatusOK, g)
+}
+
+func (h *Handler) tail(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	pattern := r.URL.Query().Get("events")
+	if pattern == "" {
+		pattern = event.Wildcard
+	}
+
+	ch, stop := h.em.Tail(pattern)
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			bs, err := event.DefaultCodec.Encode(e)
+			if err != nil {
+				continue
+			}
+
+			if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Name(), bs); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *Handler) pause(w http.ResponseWriter, r *http.Request) {
+	if name := h.name(w, r); name != "" {
+		h.em.Pause(name)
+		writeJSON(w, http.StatusOK, h.em.PausedNames())
+	}
+}
+
+func (h *Handler) resume(w http.ResponseWriter, r *http.Request) {
+	if name := h.name(w, r); name != "" {
+		h.em.Resume(name)
+		writeJSON(w, http.StatusOK, h.em.PausedNames())
+	}
+}
+
+func (h *Handler) name(w http.ResponseWriter, r *http.Request) string {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "the name is required")
+	}
+	return name
+}
+
+func (h *Handler) priority(w http.ResponseWriter, r *http.Request) {
+	req := &PriorityRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.em.SetPriority(req.Event, req.Listener, req.Priority); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+func (h *Handler) fire(w http.ResponseWriter, r *http.Request) {
+	req := &FireRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err, e := h.em.TryFire(req.Name, req.Data)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	res := map[string]interface{}{"name": req.Name, "handled": e != nil}
+	if e != nil {
+		res["data"] = h.em.RedactData(e.Name(), e.Data())
+		res["aborted"] = e.IsAborted()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,166 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func request(h http.Handler, method, url, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	em := event.NewManager("test")
+	em.AddEvent(event.NewBasic("app.start", nil))
+	em.Listen("order.*", event.ListenerFunc(func(e event.Event) error {
+		e.Set("result", "handled")
+		return nil
+	}), event.ListenOpts{Label: "l1"})
+	em.Listen("order.created", event.ListenerFunc(func(e event.Event) error {
+		return nil
+	}), event.ListenOpts{Label: "l2", Priority: event.High})
+
+	h := New(em)
+	// deny all if the Authorize is not setting
+	w := request(h, "GET", "/events", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	h.Authorize = AllowAll
+
+	// events
+	w = request(h, "GET", "/events", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var events []*EventInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	assert.Len(t, events, 3)
+	assert.Equal(t, EventInfo{Name: "app.start", Declared: true}, *events[0])
+	assert.Equal(t, EventInfo{Name: "order.*", Listeners: 1}, *events[1])
+
+	// listeners
+	w = request(h, "GET", "/listeners?name=order.created", "")
+	var listeners []*ListenerInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &listeners))
+	assert.Len(t, listeners, 1)
+	assert.Equal(t, "l2", listeners[0].Name)
+	assert.Equal(t, event.High, listeners[0].Priority)
+	assert.Equal(t, "observer|mutator|aborter", listeners[0].Caps)
+
+	// priority
+	w = request(h, "POST", "/priority", `{"event": "order.*", "listener": "l1", "priority": 300}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 300, em.ListenersByName("order.*").Items()[0].CurrentPriority())
+	w = request(h, "POST", "/priority", `{"event": "order.*", "listener": "l3", "priority": 300}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "'l3'")
+	w = request(h, "POST", "/priority", `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// fire
+	w = request(h, "POST", "/fire", `{"name": "order.created", "data": {"id": 1}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"result":"handled"`)
+	w = request(h, "POST", "/fire", `{"name": "not exist!"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// pause and resume
+	w = request(h, "POST", "/pause?name=order.*", "")
+	assert.Equal(t, "[\"order.*\"]\n", w.Body.String())
+	w = request(h, "POST", "/fire", `{"name": "order.created"}`)
+	assert.NotContains(t, w.Body.String(), `"result"`)
+	w = request(h, "POST", "/resume?name=order.*", "")
+	assert.Equal(t, "[]\n", w.Body.String())
+	w = request(h, "POST", "/pause", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// stats
+	w = request(h, "GET", "/stats", "")
+	stats := &event.Stats{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), stats))
+	assert.Equal(t, uint64(1), stats.Fired)
+	assert.Equal(t, uint64(1), stats.Paused)
+	assert.Equal(t, uint64(1), stats.Events["order.created"])
+
+	// health
+	w = request(h, "GET", "/health", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	em.ReportHealth("mailer", event.HealthStatus{Ready: false, Live: true})
+	w = request(h, "GET", "/health?probe=live", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = request(h, "GET", "/health?probe=ready", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), `"mailer"`)
+
+	// graph
+	w = request(h, "GET", "/graph?format=dot", "")
+	assert.Contains(t, w.Body.String(), `digraph "test"`)
+	w = request(h, "GET", "/graph", "")
+	assert.Contains(t, w.Body.String(), `"nodes"`)
+
+	// method and auth
+	w = request(h, "POST", "/stats", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	w = request(h, "GET", "/pause", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	h.ReadOnly = true
+	w = request(h, "POST", "/pause?name=order.*", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	h.Authorize = func(r *http.Request) bool {
+		return r.Header.Get("X-Token") == "abc"
+	}
+	w = request(h, "GET", "/stats", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandler_tail(t *testing.T) {
+	em := event.NewManager("test", event.WithConcurrencySafe())
+	em.On("order.*", event.ListenerFunc(func(e event.Event) error {
+		return nil
+	}))
+
+	h := New(em)
+	h.Authorize = AllowAll
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/tail?events=order.*")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	// wait the tail started
+	rd := bufio.NewReader(res.Body)
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond):
+				em.MustFire("order.created", event.M{"id": 1})
+			}
+		}
+	}()
+
+	line, err := rd.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "event: order.created\n", line)
+	line, err = rd.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "data: {\"name\":\"order.created\",\"data\":{\"id\":1}}\n", line)
+
+	close(stop)
+	<-done
+	assert.NoError(t, em.Close())
+}
@@ -2,8 +2,21 @@ package event
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -363,3 +376,4954 @@ func TestManager_AddSubscriber(t *testing.T) {
 
 	em.Clear()
 }
+
+func TestSaga_Run(t *testing.T) {
+	em := NewManager("test")
+	buf := new(bytes.Buffer)
+	mark := func(s string) Listener {
+		return ListenerFunc(func(e Event) error {
+			buf.WriteString(s + ";")
+			return nil
+		})
+	}
+
+	em.On("s1", mark("s1"))
+	em.On("s1.undo", mark("s1.undo"))
+	em.On("s2", mark("s2"))
+	em.On("s2.undo", mark("s2.undo"))
+	em.On("s3", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("s3 error")
+	}))
+
+	saga := NewSaga("order", em)
+	saga.Step("s1", "s1.undo").Step("s2", "s2.undo")
+	assert.Equal(t, "order", saga.Name())
+	assert.Len(t, saga.Steps(), 2)
+
+	assert.NoError(t, saga.Run(nil))
+	assert.Equal(t, "s1;s2;", buf.String())
+
+	buf.Reset()
+	saga.Step("s3", "")
+	err := saga.Run(M{"k": "v"})
+	assert.Error(t, err)
+	assert.Equal(t, "s1;s2;s2.undo;s1.undo;", buf.String())
+
+	se, ok := err.(*SagaError)
+	assert.True(t, ok)
+	assert.Equal(t, "s3", se.Step)
+	assert.Contains(t, err.Error(), "s3 error")
+
+	// compensate error
+	buf.Reset()
+	em.On("s1.undo", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("undo error")
+	}))
+	err = saga.Run(nil)
+	assert.Contains(t, err.Error(), "s1.undo: undo error")
+
+	// the compensate errors in the fired order
+	em.On("s2.undo", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("undo error")
+	}))
+	for i := 0; i < 10; i++ {
+		err = saga.Run(nil)
+		assert.Contains(t, err.Error(), "compensate errors: s2.undo: undo error, s1.undo: undo error")
+	}
+	se = &SagaError{Saga: "order", Step: "s3", Err: ErrAborted, Compensations: map[string]error{
+		"s2.undo": ErrAborted,
+		"s1.undo": ErrAborted,
+	}}
+	assert.Contains(t, se.Error(), "compensate errors: s1.undo: "+ErrAborted.Error()+", s2.undo")
+
+	// abort step
+	buf.Reset()
+	em.On("s4", ListenerFunc(func(e Event) error {
+		e.Abort(true)
+		return nil
+	}))
+	err = NewSaga("abort", em).Step("s2", "s2.undo").Step("s4", "").Run(nil)
+	assert.Error(t, err)
+	assert.Equal(t, "s2;s2.undo;", buf.String())
+}
+
+func TestStateMachine(t *testing.T) {
+	em := NewManager("test")
+	buf := new(bytes.Buffer)
+	stateListener := ListenerFunc(func(e Event) error {
+		buf.WriteString(e.Name() + ";")
+		return nil
+	})
+	em.On("state.exited.*", stateListener)
+	em.On("state.entered.*", stateListener)
+
+	sm := NewStateMachine(em, "draft")
+	sm.AddTransition(Transition{Event: "doc.publish", From: []string{"draft"}, To: "published"})
+	sm.AddTransition(Transition{Event: "doc.archive", To: "archived", Guard: func(e Event) bool {
+		return e.Get("force") == true
+	}})
+	assert.Panics(t, func() {
+		sm.AddTransition(Transition{Event: "doc.publish"})
+	})
+
+	assert.Equal(t, "draft", sm.Current())
+	assert.True(t, sm.Can("doc.publish", nil))
+	assert.False(t, sm.Can("doc.archive", nil))
+	assert.Error(t, sm.Trigger("doc.archive", nil))
+
+	// trigger by fire event
+	err, _ := em.Fire("doc.publish", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", sm.Current())
+	assert.Equal(t, "state.exited.draft;state.entered.published;", buf.String())
+
+	// not allowed, ignore
+	err, _ = em.Fire("doc.publish", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", sm.Current())
+
+	buf.Reset()
+	assert.NoError(t, sm.Trigger("doc.archive", M{"force": true}))
+	assert.Equal(t, "archived", sm.Current())
+	assert.Equal(t, "state.exited.published;state.entered.archived;", buf.String())
+
+	// the guard can read the state machine
+	sm.AddTransition(Transition{Event: "doc.restore", To: "draft", Guard: func(e Event) bool {
+		return sm.Current() == "archived" && sm.Can("doc.archive", M{"force": true})
+	}})
+	assert.True(t, sm.Can("doc.restore", nil))
+	assert.NoError(t, sm.Trigger("doc.restore", nil))
+	assert.Equal(t, "draft", sm.Current())
+}
+
+func TestOnMany(t *testing.T) {
+	em := NewManager("test")
+	em.OnMany([]string{"user.created", "user.updated"}, ListenerFunc(emptyListener), High)
+	assert.True(t, em.HasListeners("user.created"))
+	assert.True(t, em.HasListeners("user.updated"))
+	assert.Equal(t, High, em.ListenersByName("user.updated").Items()[0].Priority)
+
+	em.AddEvents(NewBasic("e1", nil), NewBasic("e2", nil))
+	assert.True(t, em.HasEvent("e1"))
+	assert.True(t, em.HasEvent("e2"))
+
+	assert.Panics(t, func() {
+		em.OnMany([]string{"e3", ""}, ListenerFunc(emptyListener))
+	})
+
+	// global
+	OnMany([]string{"n1", "n2"}, ListenerFunc(emptyListener))
+	assert.True(t, HasListeners("n1"))
+	assert.True(t, HasListeners("n2"))
+	AddEvents(NewBasic("evt1", nil))
+	assert.True(t, HasEvent("evt1"))
+	DefaultEM.Clear()
+}
+
+func TestManager_Listen(t *testing.T) {
+	em := NewManager("test")
+	buf := new(bytes.Buffer)
+	mark := func(s string) Listener {
+		return ListenerFunc(func(e Event) error {
+			buf.WriteString(s + ";")
+			return nil
+		})
+	}
+
+	em.Listen("e1", mark("once"), ListenOpts{Once: true, Priority: High, Label: "once"})
+	em.Listen("e1", mark("filter"), ListenOpts{Filter: func(e Event) bool {
+		return e.Get("ok") == true
+	}})
+	assert.Equal(t, "once", em.ListenersByName("e1").Items()[0].Label)
+
+	err, _ := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "once;", buf.String())
+	assert.Equal(t, 1, em.ListenersCount("e1"))
+
+	buf.Reset()
+	err, _ = em.Fire("e1", M{"ok": true})
+	assert.NoError(t, err)
+	assert.Equal(t, "filter;", buf.String())
+
+	// once on group listener
+	buf.Reset()
+	em.Listen("app.*", mark("group-once"), ListenOpts{Once: true})
+	em.On("app.run", mark("run"))
+	_, _ = em.Fire("app.run", nil)
+	_, _ = em.Fire("app.run", nil)
+	assert.Equal(t, "run;group-once;run;", buf.String())
+	assert.False(t, em.HasListeners("app.*"))
+
+	// async
+	var wg sync.WaitGroup
+	wg.Add(1)
+	Listen("e2", ListenerFunc(func(e Event) error {
+		defer wg.Done()
+		return fmt.Errorf("ignored error")
+	}), ListenOpts{Async: true})
+	err, _ = Fire("e2", nil)
+	assert.NoError(t, err)
+	wg.Wait()
+	DefaultEM.Clear()
+}
+
+func TestNewManager_options(t *testing.T) {
+	em := NewManager("test", WithErrorPolicy(PolicyCollect), WithMatchMode(ModeExact))
+	assert.Equal(t, "test", em.Name())
+	assert.Equal(t, PolicyCollect, em.Options().ErrorPolicy)
+
+	buf := new(bytes.Buffer)
+	em.On("app.e1", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("error1")
+	}), High)
+	em.On("app.e1", ListenerFunc(func(e Event) error {
+		buf.WriteString("called")
+		return fmt.Errorf("error2")
+	}))
+	em.On("app.*", ListenerFunc(func(e Event) error {
+		buf.WriteString(" group")
+		return nil
+	}))
+
+	err, _ := em.Fire("app.e1", nil)
+	assert.Error(t, err)
+	assert.Len(t, err, 2)
+	assert.Equal(t, "error1; error2", err.Error())
+	// exact mode, group listener not called
+	assert.Equal(t, "called", buf.String())
+
+	// ignore errors
+	em = NewManager("test", WithErrorPolicy(PolicyIgnore), WithPoolDisabled())
+	em.On("e1", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("error1")
+	}))
+	err, _ = em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, em.FireBatch("e1", "e1"))
+}
+
+func TestManager_ConcurrencySafe(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+	assert.True(t, em.Options().ConcurrencySafe)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("e%d", i%3)
+			em.On(name, ListenerFunc(emptyListener))
+			em.AddEvent(NewBasic(name, nil))
+			_ = em.FireBatch(name, "e1")
+			em.RemoveListeners(name)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestManager_ValidateName(t *testing.T) {
+	em := NewManager("test")
+	assert.NoError(t, em.ValidateName("app.user-created"))
+	assert.Error(t, em.ValidateName(" "))
+	assert.Error(t, em.ValidateName("orders eu created"))
+	assert.Panics(t, func() {
+		em.On("orders eu created", ListenerFunc(emptyListener))
+	})
+
+	// unicode and extended separators
+	assert.NoError(t, em.ValidateName("orders/eu:created"))
+	assert.NoError(t, em.ValidateName("用户.创建"))
+	assert.NoError(t, em.ValidateName("événement.créé2"))
+	assert.Error(t, em.ValidateName("2orders"))
+	assert.Error(t, em.ValidateName(":orders"))
+
+	em = NewManager("test", WithNamePattern(`^[a-z][\w-.*:/]*$`))
+	assert.NoError(t, em.ValidateName("orders/eu:created"))
+	assert.Error(t, em.ValidateName("Orders"))
+
+	em.On("orders/eu:created", ListenerFunc(func(e Event) error {
+		e.Set("ok", true)
+		return nil
+	}))
+	err, e := em.Fire("orders/eu:created", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, e.Get("ok"))
+
+	em = NewManager("test", WithLenientNames())
+	assert.NoError(t, em.ValidateName("++any name"))
+	assert.Error(t, em.ValidateName(""))
+}
+
+func TestManager_TryOn(t *testing.T) {
+	em := NewManager("test")
+	assert.Error(t, em.TryOn("", ListenerFunc(emptyListener)))
+	assert.Error(t, em.TryOn("++df", ListenerFunc(emptyListener)))
+	assert.Error(t, em.TryOn("name", nil))
+	assert.NoError(t, em.TryOn("name", ListenerFunc(emptyListener), High))
+	assert.True(t, em.HasListeners("name"))
+
+	err, e := em.TryFire("++df", nil)
+	assert.Error(t, err)
+	assert.Nil(t, e)
+
+	err, e = em.TryFire("name", M{"k": "v"})
+	assert.NoError(t, err)
+	assert.Equal(t, "v", e.Get("k"))
+
+	assert.Error(t, em.TryAddEvent(NewBasic(" ", nil)))
+	assert.NoError(t, em.TryAddEvent(NewBasic("e1", nil)))
+
+	// global
+	assert.Error(t, TryOn("", ListenerFunc(emptyListener)))
+	assert.NoError(t, TryOn("n1", ListenerFunc(emptyListener)))
+	err, _ = TryFire("", nil)
+	assert.Error(t, err)
+	DefaultEM.Clear()
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "user.created", NormalizeName(" User..Created. "))
+	assert.Equal(t, "user.created", NormalizeName("user.created"))
+	assert.Equal(t, "app.*", NormalizeName(".APP.*"))
+
+	em := NewManager("test", WithNormalizeNames())
+	em.On("User.Created", ListenerFunc(func(e Event) error {
+		e.Set("ok", true)
+		return nil
+	}))
+	assert.True(t, em.HasListeners("user.created"))
+	assert.Equal(t, 1, em.ListenersCount("USER.CREATED"))
+
+	err, e := em.Fire("user..created", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, e.Get("ok"))
+
+	// fire by event instance
+	e1 := NewBasic("user.Created", nil)
+	em.AddEvent(e1)
+	assert.True(t, em.HasEvent("user.created"))
+	assert.NoError(t, em.FireEvent(e1))
+	assert.Equal(t, true, e1.Get("ok"))
+
+	em.RemoveListeners("USER.created")
+	assert.False(t, em.HasListeners("user.created"))
+}
+
+type userCreated struct {
+	ID int
+}
+
+type userUpdated struct {
+	ID int
+}
+
+func (u *userUpdated) Name() string {
+	return "user.updated"
+}
+
+func TestManager_FireStruct(t *testing.T) {
+	assert.Equal(t, "event.userCreated", StructName(userCreated{}))
+	assert.Equal(t, "event.userCreated", StructName(&userCreated{}))
+	assert.Equal(t, "user.updated", StructName(&userUpdated{}))
+	assert.Panics(t, func() {
+		StructName(nil)
+	})
+
+	em := NewManager("test")
+	em.On("event.userCreated", ListenerFunc(func(e Event) error {
+		uc := Payload(e).(userCreated)
+		e.Set("id", uc.ID)
+		return nil
+	}))
+	em.On("user.updated", ListenerFunc(emptyListener))
+
+	err, e := em.FireStruct(userCreated{ID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, e.Get("id"))
+
+	err, e = em.FireStruct(&userUpdated{ID: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, Payload(e).(*userUpdated).ID)
+
+	err, _ = em.FireStruct(nil)
+	assert.Error(t, err)
+
+	// global
+	err, e = FireStruct(userCreated{ID: 7})
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+}
+
+func TestTypedListener(t *testing.T) {
+	assert.Panics(t, func() {
+		TypedListener("invalid")
+	})
+	assert.Panics(t, func() {
+		TypedListener(func(uc userCreated) {})
+	})
+	assert.Panics(t, func() {
+		TypedListener(func(s string, uc userCreated) error { return nil })
+	})
+	assert.Panics(t, func() {
+		TypedListener(func() error { return nil })
+	})
+
+	em := NewManager("test")
+	em.On("event.userCreated", TypedListener(func(e Event, uc userCreated) error {
+		e.Set("id", uc.ID)
+		return nil
+	}))
+	em.On("event.userCreated", TypedListener(func(uc *userCreated) error {
+		if uc.ID < 0 {
+			return fmt.Errorf("invalid id")
+		}
+		return nil
+	}))
+
+	err, e := em.FireStruct(userCreated{ID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, e.Get("id"))
+
+	err, e = em.FireStruct(&userCreated{ID: 6})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, e.Get("id"))
+
+	err, _ = em.FireStruct(userCreated{ID: -1})
+	assert.Error(t, err)
+
+	// decode from data
+	err, e = em.Fire("event.userCreated", M{"ID": 8})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, e.Get("id"))
+
+	// type mismatch
+	err, _ = em.Fire("event.userCreated", M{PayloadKey: "string"})
+	assert.Error(t, err)
+	err, _ = em.Fire("event.userCreated", M{"ID": "not int"})
+	assert.Error(t, err)
+}
+
+func TestBridge(t *testing.T) {
+	lib := NewManager("lib")
+	app := NewManager("app")
+	assert.Panics(t, func() {
+		NewBridge(lib, lib, "*")
+	})
+
+	buf := new(bytes.Buffer)
+	var data M
+	app.On("*", ListenerFunc(func(e Event) error {
+		buf.WriteString(e.Name() + ";")
+		data = e.Data()
+		return nil
+	}))
+
+	b := NewBridge(lib, app, "lib.*").Rename(func(name string) string {
+		return "app." + name
+	})
+	assert.Equal(t, "lib.*", b.Pattern())
+
+	lib.On("lib.run", ListenerFunc(emptyListener))
+	err, _ := lib.Fire("lib.run", M{"k": "v"})
+	assert.NoError(t, err)
+	assert.Equal(t, "app.lib.run;", buf.String())
+	// the bridge path is not stored in the event data
+	assert.Equal(t, M{"k": "v"}, data)
+
+	// loop: app -> lib -> app
+	buf.Reset()
+	NewBridge(app, lib, "app.*")
+	count := 0
+	lib.On("app.ping", ListenerFunc(func(e Event) error {
+		count++
+		return nil
+	}))
+	NewBridge(lib, app, "app.*")
+	err = app.FireEvent(NewBasic("app.ping", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// close
+	buf.Reset()
+	b.Close()
+	_, _ = lib.Fire("lib.run", nil)
+	assert.Equal(t, "", buf.String())
+}
+
+func TestRegisterManager(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterManager(NewManager(""))
+	})
+
+	em := NewManager("payments")
+	RegisterManager(em)
+	RegisterManager(NewManager("orders"))
+	assert.Contains(t, RegisteredManagers(), "payments")
+
+	got, ok := GetManager("payments")
+	assert.True(t, ok)
+	assert.Equal(t, em, got)
+	assert.Equal(t, em, MustGetManager("payments"))
+	assert.Panics(t, func() {
+		MustGetManager("not-exist")
+	})
+
+	UnregisterManager("orders")
+	_, ok = GetManager("orders")
+	assert.False(t, ok)
+
+	em.On("e1", ListenerFunc(emptyListener))
+	assert.NoError(t, CloseAll())
+	assert.True(t, em.IsClosed())
+	assert.Empty(t, RegisteredManagers())
+	assert.False(t, em.HasListeners("e1"))
+
+	// closed manager
+	assert.NoError(t, em.Close())
+	assert.Equal(t, ErrClosed, em.TryOn("e1", ListenerFunc(emptyListener)))
+	assert.Equal(t, ErrClosed, em.FireEvent(NewBasic("e1", nil)))
+}
+
+func TestManager_FireWith(t *testing.T) {
+	em := NewManager("test")
+	em.On("e1", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("error1")
+	}), High)
+	em.On("e1", ListenerFunc(func(e Event) error {
+		e.Set("called", true)
+		return fmt.Errorf("error2")
+	}))
+
+	// default policy
+	err, e := em.FireWith("e1", nil)
+	assert.Equal(t, "error1", err.Error())
+	assert.Nil(t, e.Get("called"))
+
+	err, e = em.FireWith("e1", nil, Policy(PolicyCollect))
+	assert.Equal(t, "error1; error2", err.Error())
+	assert.Equal(t, true, e.Get("called"))
+
+	err, _ = em.FireWith("e1", nil, Policy(PolicyIgnore))
+	assert.NoError(t, err)
+
+	// timeout
+	done := make(chan struct{})
+	em.On("slow", ListenerFunc(func(e Event) error {
+		<-done
+		return nil
+	}))
+	err, _ = em.FireWith("slow", nil, Timeout(10*time.Millisecond))
+	assert.Equal(t, ErrTimeout, err)
+	close(done)
+
+	err, _ = em.FireWith("e1", nil, Timeout(time.Second), Policy(PolicyIgnore))
+	assert.NoError(t, err)
+
+	// async
+	var wg sync.WaitGroup
+	wg.Add(1)
+	On("e2", ListenerFunc(func(e Event) error {
+		defer wg.Done()
+		return fmt.Errorf("ignored")
+	}))
+	err, _ = FireWith("e2", nil, Async())
+	assert.NoError(t, err)
+	wg.Wait()
+	DefaultEM.Clear()
+}
+
+func TestListenerQueue_stableOrder(t *testing.T) {
+	em := NewManager("test")
+	buf := new(bytes.Buffer)
+	for i := 0; i < 30; i++ {
+		pv := Normal
+		if i%3 == 0 {
+			pv = High
+		}
+
+		em.On("e1", &testListener{fmt.Sprint(i)}, pv)
+	}
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+
+	// high: 0 3 6 ... 27, then normal by registration order
+	for i := 0; i < 30; i += 3 {
+		_, _ = fmt.Fprintf(buf, " -> e1(%d)", i)
+	}
+	for i := 0; i < 30; i++ {
+		if i%3 != 0 {
+			_, _ = fmt.Fprintf(buf, " -> e1(%d)", i)
+		}
+	}
+	assert.Equal(t, "handled: "+buf.String()[4:], e.Get("result"))
+
+	items := em.ListenersByName("e1").Items()
+	assert.Equal(t, uint64(1), items[0].Seq)
+	assert.True(t, items[10].Seq < items[11].Seq)
+}
+
+func TestManager_SetEventParents(t *testing.T) {
+	em := NewManager("test")
+	buf := new(bytes.Buffer)
+	mark := func(s string) Listener {
+		return ListenerFunc(func(e Event) error {
+			_, _ = fmt.Fprintf(buf, "%s(%s);", s, e.Name())
+			return nil
+		})
+	}
+
+	em.SetEventParents("order.shipped", "order.changed")
+	em.SetEventParents("order.changed", "entity.changed", "order.shipped")
+	assert.Equal(t, []string{"order.changed"}, em.EventParents("order.shipped"))
+
+	em.On("order.changed", mark("changed"))
+	em.On("entity.changed", mark("entity"))
+	em.On("order.*", mark("group"))
+
+	// only parent has listeners
+	err, _ := em.Fire("order.shipped", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "changed(order.shipped);entity(order.shipped);group(order.shipped);", buf.String())
+
+	buf.Reset()
+	em.On("order.shipped", mark("shipped"))
+	_ = em.FireBatch("order.shipped")
+	assert.Equal(t, "shipped(order.shipped);changed(order.shipped);entity(order.shipped);group(order.shipped);", buf.String())
+
+	// remove parents
+	buf.Reset()
+	em.SetEventParents("order.shipped")
+	assert.Nil(t, em.EventParents("order.shipped"))
+	_, _ = em.Fire("order.shipped", nil)
+	assert.Equal(t, "shipped(order.shipped);group(order.shipped);", buf.String())
+}
+
+func TestManager_adaptive(t *testing.T) {
+	em := NewManager("test", WithAdaptive(AdaptiveConfig{
+		FailThreshold: 2,
+		DemoteStep:    100,
+		SuspendFor:    50 * time.Millisecond,
+	}))
+
+	var demoted, suspended int
+	em.On(EventListenerDemoted, ListenerFunc(func(e Event) error {
+		demoted++
+		assert.Equal(t, "bad", e.Get("label"))
+		return nil
+	}))
+	em.On(EventListenerSuspended, ListenerFunc(func(e Event) error {
+		suspended++
+		return nil
+	}))
+
+	calls := 0
+	em.Listen("e1", ListenerFunc(func(e Event) error {
+		calls++
+		return fmt.Errorf("an error")
+	}), ListenOpts{Label: "bad", Priority: Normal})
+	li := em.ListenersByName("e1").Items()[0]
+
+	err, _ := em.Fire("e1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, li.Failures())
+
+	err, _ = em.Fire("e1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, li.Failures())
+	assert.Equal(t, BelowNormal, li.EffectivePriority())
+	assert.Equal(t, Normal, li.Priority)
+	assert.True(t, li.IsSuspended())
+	assert.Equal(t, 1, demoted)
+	assert.Equal(t, 1, suspended)
+
+	// suspended, skip call
+	err, _ = em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, li.IsSuspended())
+	err, _ = em.Fire("e1", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	// the demoted listener is called after the listeners registered later
+	em.Listen("e1", &testListener{"good"}, ListenOpts{Priority: Normal})
+	assert.Equal(t, "good", em.ListenersByName("e1").Items()[0].Listener.(*testListener).userData)
+
+	// reset by SetPriority
+	assert.NoError(t, em.SetPriority("e1", "bad", High))
+	assert.Equal(t, High, li.EffectivePriority())
+	assert.Equal(t, li, em.ListenersByName("e1").Items()[0])
+}
+
+func TestManager_adaptive_concurrent(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe(), WithAdaptive(AdaptiveConfig{
+		FailThreshold: 1,
+		DemoteStep:    1,
+	}))
+	em.Listen("e1", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("an error")
+	}), ListenOpts{Priority: Normal})
+	em.On("e1", ListenerFunc(emptyListener), Normal)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, _ = em.Fire("e1", nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	items := em.ListenersByName("e1").Items()
+	assert.Equal(t, Normal, items[0].EffectivePriority())
+	assert.Equal(t, Normal-100, items[1].EffectivePriority())
+}
+
+func TestManager_ExportConfig(t *testing.T) {
+	RegisterListenerFactory("tl", func() Listener {
+		return &testListener{"factory"}
+	})
+	assert.Panics(t, func() {
+		RegisterListenerFactory("", nil)
+	})
+
+	em := NewManager("test")
+	em.AddEvent(NewBasic("e1", nil))
+	em.SetEventParents("e1", "e0")
+	assert.NoError(t, em.ListenFactory("e1", "tl", ListenOpts{Priority: High, Label: "l1"}))
+	assert.Error(t, em.ListenFactory("e1", "not-exist", ListenOpts{}))
+	em.On("e2", ListenerFunc(emptyListener))
+
+	cfg := em.ExportConfig()
+	assert.Equal(t, "test", cfg.Name)
+	assert.Equal(t, []string{"e1"}, cfg.Events)
+	assert.Equal(t, []string{"e0"}, cfg.Parents["e1"])
+	assert.Len(t, cfg.Listeners, 2)
+	assert.Equal(t, ListenerConfig{Event: "e1", Factory: "tl", Label: "l1", Priority: High}, *cfg.Listeners[0])
+	assert.Equal(t, "", cfg.Listeners[1].Factory)
+
+	// import
+	em2 := NewManager("test2")
+	assert.Error(t, em2.ImportConfig(cfg))
+
+	cfg.Listeners = cfg.Listeners[:1]
+	assert.NoError(t, em2.ImportConfig(cfg))
+	assert.True(t, em2.HasEvent("e1"))
+	assert.Equal(t, []string{"e0"}, em2.EventParents("e1"))
+
+	err, e := em2.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(factory)", e.Get("result"))
+
+	cfg2 := em2.ExportConfig()
+	cfg2.Name = cfg.Name
+	assert.Equal(t, cfg, cfg2)
+
+	cfg.Listeners[0].Factory = "not-exist"
+	assert.Error(t, em2.ImportConfig(cfg))
+}
+
+func TestManager_ReloadConfig(t *testing.T) {
+	RegisterListenerFactory("tl4", func() Listener {
+		return &testListener{"tl4"}
+	})
+
+	em := NewManager("test", WithMaxListeners(0, true))
+	cfg := &ManagerConfig{Listeners: []*ListenerConfig{{Event: "e1", Factory: "tl4"}}}
+	assert.NoError(t, em.ImportConfig(cfg))
+	em.On("e1", &testListener{"code"})
+
+	// the invalid names return error, not panic
+	bad := &ManagerConfig{
+		Events:    []string{"e2"},
+		Parents:   map[string][]string{"e2": {"e1", "+bad"}},
+		Listeners: []*ListenerConfig{{Event: "e2", Factory: "tl4"}},
+	}
+	assert.NotPanics(t, func() {
+		assert.True(t, errors.Is(em.ImportConfig(bad), ErrInvalidName))
+		assert.True(t, errors.Is(em.ReloadConfig(bad), ErrInvalidName))
+	})
+	assert.False(t, em.HasEvent("e2"))
+	assert.Equal(t, 2, em.ListenersCount("e1"))
+
+	// import failed after validation, will roll back and keep the old listeners
+	em.SetMaxListeners("e3", 1)
+	bad = &ManagerConfig{
+		Events:  []string{"e3"},
+		Parents: map[string][]string{"e3": {"e1"}},
+		Listeners: []*ListenerConfig{
+			{Event: "e2", Factory: "tl4"},
+			{Event: "e3", Factory: "tl4"},
+			{Event: "e3", Factory: "tl4"},
+		},
+	}
+	err := em.ReloadConfig(bad)
+	assert.True(t, errors.Is(err, ErrMaxListeners))
+	assert.False(t, em.HasEvent("e3"))
+	assert.Nil(t, em.EventParents("e3"))
+	assert.Equal(t, 0, em.ListenersCount("e2"))
+	assert.Equal(t, 0, em.ListenersCount("e3"))
+	assert.Equal(t, 2, em.ListenersCount("e1"))
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(tl4) -> e1(code)", e.Get("result"))
+
+	// import failed, the listeners imported before are kept
+	assert.Error(t, em.ImportConfig(bad))
+	assert.Equal(t, 2, em.ListenersCount("e1"))
+	assert.Equal(t, 0, em.ListenersCount("e3"))
+
+	// sealed
+	em.Seal()
+	assert.Equal(t, ErrSealed, em.ImportConfig(cfg))
+	assert.Equal(t, ErrSealed, em.ReloadConfig(cfg))
+}
+
+func TestManager_LoadConfigFile(t *testing.T) {
+	RegisterListenerFactory("tl2", func() Listener {
+		return &testListener{"tl2"}
+	})
+	RegisterListenerFactory("tl3", func() Listener {
+		return &testListener{"tl3"}
+	})
+
+	dir, err := ioutil.TempDir("", "event")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "wiring.json")
+	err = ioutil.WriteFile(file, []byte(`{
+	"name": "app",
+	"listeners": [
+		{"event": "e1", "factory": "tl2", "priority": 100},
+		{"event": "e1", "factory": "tl3", "label": "l3"}
+	]
+}`), 0644)
+	assert.NoError(t, err)
+
+	em := NewManager("test")
+	em.On("e1", &testListener{"code"}, Max)
+	assert.NoError(t, em.LoadConfigFile(file))
+	assert.Equal(t, 3, em.ListenersCount("e1"))
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(code) -> e1(tl2) -> e1(tl3)", e.Get("result"))
+
+	// reload, will remove the listeners imported before
+	err = ioutil.WriteFile(file, []byte(`{"listeners": [{"event": "e2", "factory": "tl3"}]}`), 0644)
+	assert.NoError(t, err)
+	assert.NoError(t, em.LoadConfigFile(file))
+	assert.Equal(t, 1, em.ListenersCount("e1"))
+	assert.Equal(t, 1, em.ListenersCount("e2"))
+
+	// custom decoder
+	RegisterConfigDecoder(".TXT", func(data []byte, v interface{}) error {
+		v.(*ManagerConfig).Listeners = []*ListenerConfig{{Event: "e3", Factory: "tl2"}}
+		return nil
+	})
+	txtFile := filepath.Join(dir, "wiring.txt")
+	assert.NoError(t, ioutil.WriteFile(txtFile, []byte("e3 -> tl2"), 0644))
+	assert.NoError(t, em.LoadConfigFile(txtFile))
+	assert.True(t, em.HasListeners("e3"))
+	assert.False(t, em.HasListeners("e2"))
+
+	assert.Error(t, em.LoadConfigFile(filepath.Join(dir, "wiring.ini")))
+	assert.Error(t, em.LoadConfigFile(filepath.Join(dir, "not-exist.json")))
+
+	_, err = ParseConfig([]byte("invalid"), nil)
+	assert.Error(t, err)
+}
+
+type testPlugin struct {
+	name string
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) SubscribedEvents() map[string]interface{} {
+	return map[string]interface{}{
+		"e1": &testListener{p.name},
+		"e2": ListenerItem{
+			Priority: High,
+			Listener: ListenerFunc(func(e Event) error {
+				panic("plugin panic")
+			}),
+		},
+	}
+}
+
+func TestManager_LoadPlugin(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterPlugin(&testPlugin{})
+	})
+
+	em := NewManager("test")
+	em.On("e1", &testListener{"app"})
+	assert.NoError(t, em.LoadPlugin(&testPlugin{"p1"}))
+	assert.Error(t, em.LoadPlugin(&testPlugin{"p1"}))
+	assert.Error(t, em.LoadPlugin(&testPlugin{""}))
+	assert.True(t, em.HasPlugin("p1"))
+	assert.Equal(t, "p1", em.ListenersByName("e2").Items()[0].Label)
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(app) -> e1(p1)", e.Get("result"))
+
+	// panic is recovered
+	err, _ = em.Fire("e2", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin 'p1' listener panic")
+
+	// registered plugins
+	RegisterPlugin(&testPlugin{"p2"})
+	assert.NotEmpty(t, RegisteredPlugins())
+	assert.NoError(t, em.LoadPlugins())
+	assert.True(t, em.HasPlugin("p2"))
+	assert.Equal(t, 3, em.ListenersCount("e1"))
+
+	em.UnloadPlugin("p1")
+	em.UnloadPlugin("p2")
+	assert.False(t, em.HasPlugin("p1"))
+	assert.Equal(t, 1, em.ListenersCount("e1"))
+	assert.False(t, em.HasListeners("e2"))
+
+	// concurrent load the same plugin
+	em = NewManager("test", WithConcurrencySafe())
+	var wg sync.WaitGroup
+	var loaded int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if em.LoadPlugin(&testPlugin{"p1"}) == nil {
+				atomic.AddInt32(&loaded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), loaded)
+	assert.Equal(t, 1, em.ListenersCount("e1"))
+}
+
+func TestManager_AddInterceptor(t *testing.T) {
+	em := NewManager("test")
+	called := 0
+	em.On("e1", ListenerFunc(func(e Event) error {
+		called++
+		return nil
+	}))
+	em.On("e2", ListenerFunc(emptyListener))
+
+	em.AddInterceptor(VetoNames("e2"), func(e Event) error {
+		if e.Get("user") != "admin" {
+			return ErrEventVetoed
+		}
+		return nil
+	})
+
+	err, _ := em.Fire("e1", nil)
+	assert.Equal(t, ErrEventVetoed, err)
+	assert.Equal(t, 0, called)
+
+	err, _ = em.Fire("e1", M{"user": "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, called)
+
+	err, _ = em.Fire("e2", M{"user": "admin"})
+	assert.Equal(t, ErrEventVetoed, err)
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*", "app.run", true},
+		{"app.run", "app.run", true},
+		{"app.*", "app.run", true},
+		{"app.*", "app.db.run", false},
+		{"app.*", "app", false},
+		{"*.created", "user.created", true},
+		{"*.created", "user.updated", false},
+		{"app.*.error", "app.db.error", true},
+		{"app.*.error", "app.db.warn", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"app.**", "app.x", true},
+		{"app*", "app.x", false},
+		{"*.*", "app.x", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchName(tt.pattern, tt.name), tt.pattern+" <> "+tt.name)
+	}
+}
+
+func TestACL(t *testing.T) {
+	acl := NewACL(true).
+		Deny("plugin", ActionListen, "billing.*").
+		Allow("billing-svc", ActionFire, "billing.*").
+		Deny(AnyIdentity, ActionFire, "billing.*")
+	assert.Len(t, acl.Rules(), 3)
+
+	assert.NoError(t, acl.Check("plugin", ActionListen, "user.created"))
+	assert.Error(t, acl.Check("plugin", ActionListen, "billing.charged"))
+	assert.Error(t, acl.Check("plugin", ActionListen, "*"))
+	assert.Error(t, acl.Check("other", ActionFire, "billing.charged"))
+	assert.NoError(t, acl.Check("other", ActionListen, "billing.charged"))
+	// deny take precedence
+	assert.Error(t, acl.Check("billing-svc", ActionFire, "billing.charged"))
+
+	acl = NewACL(false).Allow("svc", ActionAll, "user.*")
+	assert.NoError(t, acl.Check("svc", ActionFire, "user.created"))
+	assert.Error(t, acl.Check("svc", ActionFire, "order.created"))
+	err := acl.Check("other", ActionListen, "user.created")
+	assert.Equal(t, "event: identity 'other' cannot listen the event 'user.created'", err.Error())
+	assert.Equal(t, "unknown", Action(0).String())
+
+	em := NewManager("test")
+	em.SetACL(NewACL(true).Deny("plugin", ActionListen, "billing.*").Deny("plugin", ActionFire, "admin.*"))
+	assert.NotNil(t, em.ACL())
+
+	p := em.As("plugin")
+	assert.Equal(t, "plugin", p.Identity())
+	assert.Error(t, p.On("billing.charged", ListenerFunc(emptyListener)))
+	assert.Error(t, p.Listen("billing.*", ListenerFunc(emptyListener), ListenOpts{}))
+	assert.NoError(t, p.Listen("user.created", ListenerFunc(emptyListener), ListenOpts{Weight: 2, Observer: true}))
+	li := em.ListenersByName("user.created").Items()[0]
+	assert.Equal(t, "plugin", li.Label)
+	assert.Equal(t, 2, li.Weight)
+	assert.True(t, li.Observer)
+	assert.NoError(t, p.On("admin.login", ListenerFunc(emptyListener)))
+
+	err, _ = p.Fire("admin.login", nil)
+	assert.Error(t, err)
+	assert.Error(t, p.FireEvent(NewBasic("admin.login", nil)))
+	err, _ = p.Fire("user.created", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, p.FireEvent(NewBasic("user.created", nil)))
+
+	// the wildcard patterns overlap the denied pattern
+	for _, pattern := range []string{"*", "*.charged", "*ing.charged", "*.*"} {
+		assert.Error(t, p.On(pattern, ListenerFunc(emptyListener)), pattern)
+	}
+	// the '*' does not match the separator '.'
+	for _, pattern := range []string{"user.*", "bill*", "billing.*.x"} {
+		assert.NoError(t, p.On(pattern, ListenerFunc(emptyListener)), pattern)
+	}
+
+	// the normalized name
+	em2 := NewManager("test", WithNormalizeNames())
+	em2.SetACL(NewACL(true).Deny("plugin", ActionListen, "billing.*"))
+	assert.Error(t, em2.As("plugin").On(" Billing..Charged ", ListenerFunc(emptyListener)))
+
+	// the allow rule must cover all names of the pattern
+	acl = NewACL(false).Allow("svc", ActionListen, "user.*")
+	assert.NoError(t, acl.Check("svc", ActionListen, "user.*"))
+	assert.Error(t, acl.Check("svc", ActionListen, "user.*.created"))
+	assert.Error(t, acl.Check("svc", ActionListen, "*"))
+	assert.Error(t, acl.Check("svc", ActionListen, "*.created"))
+
+	// plugin
+	em.SetACL(NewACL(true).Deny("p1", ActionListen, "e2"))
+	assert.Error(t, em.LoadPlugin(&testPlugin{"p1"}))
+	assert.False(t, em.HasPlugin("p1"))
+	assert.False(t, em.HasListeners("e1"))
+}
+
+func TestAuditLog(t *testing.T) {
+	stream := new(bytes.Buffer)
+	audit := NewAuditLog(10).StreamTo(stream)
+	em := NewManager("test", WithAuditLog(audit))
+
+	em.Listen("user.deleted", ListenerFunc(emptyListener), ListenOpts{Label: "cleaner"})
+	em.On("user.deleted", &testListener{})
+	em.On("user.created", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("an error")
+	}))
+	_, _ = em.Fire("user.deleted", nil)
+	_, _ = em.Fire("user.created", nil)
+	em.RemoveListeners("user.created")
+
+	rs := audit.Filter(AuditHandle, "user.deleted")
+	assert.Len(t, rs, 2)
+	assert.Equal(t, "cleaner", rs[0].Label)
+	assert.Equal(t, "*event.testListener", rs[1].Label)
+
+	rs = audit.Filter(AuditListen, "*")
+	assert.Len(t, rs, 3)
+	assert.Contains(t, rs[0].Caller, "all_test.go:")
+
+	rs = audit.Filter("", "user.created")
+	assert.Len(t, rs, 4)
+	assert.Equal(t, "an error", rs[2].Error)
+	assert.Equal(t, AuditRemove, rs[3].Action)
+	assert.Contains(t, rs[1].Caller, "all_test.go:")
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, audit.WriteJSONLines(buf))
+	assert.Equal(t, stream.String(), buf.String())
+	assert.Contains(t, buf.String(), `"action":"fire","event":"user.deleted"`)
+
+	// max records
+	for i := 0; i < 10; i++ {
+		_, _ = em.Fire("user.deleted", nil)
+	}
+	assert.Len(t, audit.Records(), 10)
+	audit.Reset()
+	assert.Empty(t, audit.Records())
+}
+
+// auditWriter the audit stream, it reads the manager on write
+type auditWriter struct {
+	em     *Manager
+	counts []int
+}
+
+func (w *auditWriter) Write(p []byte) (int, error) {
+	w.counts = append(w.counts, w.em.ListenersCount("user.deleted"))
+	return len(p), nil
+}
+
+func TestAuditLog_notLocked(t *testing.T) {
+	w := &auditWriter{}
+	w.em = NewManager("test", WithConcurrencySafe(), WithAuditLog(NewAuditLog(0).StreamTo(w)))
+
+	l := &testListener{}
+	w.em.On("user.deleted", l)
+	w.em.Listen("user.deleted", ListenerFunc(emptyListener), ListenOpts{Label: "l2", Once: true})
+	w.em.MustFire("user.deleted", nil)
+	w.em.RemoveListener("user.deleted", l)
+	w.em.On("user.deleted", l)
+	w.em.RemoveListeners("user.deleted")
+	w.em.On("user.deleted", l)
+	w.em.ClearAllListeners()
+	w.em.On("user.deleted", l)
+	w.em.RemoveEventAndListeners("user.deleted")
+	assert.Len(t, w.counts, 13)
+}
+
+func TestManager_Seal(t *testing.T) {
+	em := NewManager("test")
+	em.Listen("e1", ListenerFunc(emptyListener), ListenOpts{Once: true})
+	em.On("e2", ListenerFunc(emptyListener))
+	em.Seal()
+	assert.True(t, em.IsSealed())
+
+	assert.Equal(t, ErrSealed, em.TryOn("e1", ListenerFunc(emptyListener)))
+	assert.Equal(t, ErrSealed, em.TryAddEvent(NewBasic("e1", nil)))
+	assert.Equal(t, ErrSealed, em.ListenFactory("e1", "tl", ListenOpts{}))
+	assert.Equal(t, ErrSealed, em.ReloadConfig(&ManagerConfig{}))
+	assert.Error(t, em.LoadPlugin(&testPlugin{"p1"}))
+
+	panics := []func(){
+		func() { em.On("e3", ListenerFunc(emptyListener)) },
+		func() { em.AddEvent(NewBasic("e3", nil)) },
+		func() { em.RemoveListener("e2", nil) },
+		func() { em.RemoveListeners("e2") },
+		func() { em.RemoveEvent("e2") },
+		func() { em.RemoveEvents() },
+		func() { em.SetEventParents("e2", "e1") },
+		func() { em.AddInterceptor(VetoNames("e1")) },
+		func() { em.SetACL(nil) },
+		func() { em.UnloadPlugin("p1") },
+		func() { em.Clear() },
+	}
+	for _, fn := range panics {
+		assert.Panics(t, fn)
+	}
+
+	// once listener still be removed
+	err, _ := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.False(t, em.HasListeners("e1"))
+	assert.True(t, em.HasListeners("e2"))
+
+	assert.NoError(t, em.Close())
+}
+
+type pooledEvent struct {
+	BasicEvent
+	resets int
+}
+
+func (e *pooledEvent) Reset() {
+	e.resets++
+}
+
+func TestManager_EventPool(t *testing.T) {
+	em := NewManager("test", WithPoolPrewarm(2))
+	assert.Equal(t, uint64(2), em.PoolStats().News)
+
+	em.On("e1", ListenerFunc(emptyListener))
+	em.FireBatch("e1", "e1")
+	st := em.PoolStats()
+	assert.Equal(t, uint64(2), st.Gets)
+	assert.Equal(t, uint64(2), st.Puts)
+	// the sync.Pool maybe drop the items, eg: on GC or with the race detector
+	assert.True(t, st.News >= 2)
+
+	// custom event pool
+	em.RegisterEventPool("e2", func() Event {
+		return &pooledEvent{BasicEvent: *NewBasic("e2", nil)}
+	})
+	assert.Equal(t, uint64(2), em.PoolStats("e2").News)
+	assert.Equal(t, PoolStats{}, em.PoolStats("not-exist"))
+
+	var typed bool
+	em.On("e2", ListenerFunc(func(e Event) error {
+		_, typed = e.(*pooledEvent)
+		return nil
+	}))
+	assert.Empty(t, em.FireBatch("e2"))
+	assert.True(t, typed)
+	assert.Equal(t, uint64(1), em.PoolStats("e2").Puts)
+
+	e := em.AcquireEvent("e2")
+	pe, ok := e.(*pooledEvent)
+	assert.True(t, ok)
+	resets := pe.resets
+	em.ReleaseEvent(e)
+	assert.Equal(t, resets+1, pe.resets)
+	assert.Equal(t, uint64(2), em.PoolStats("e2").Gets)
+	assert.Panics(t, func() {
+		em.RegisterEventPool("e3", nil)
+	})
+
+	// disabled pool
+	em = NewManager("test", WithPoolDisabled())
+	em.RegisterEventPool("e2", func() Event {
+		return &pooledEvent{BasicEvent: *NewBasic("e2", nil)}
+	})
+	_, ok = em.AcquireEvent("e2").(*pooledEvent)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), em.PoolStats("e2").Gets)
+}
+
+type orderEvent struct {
+	BasicEvent
+	OrderID int
+}
+
+func TestManager_AddEventFactory(t *testing.T) {
+	em := NewManager("test")
+	em.AddEventFactory("order.paid", func() Event {
+		return &orderEvent{BasicEvent: *NewBasic("order.paid", nil), OrderID: 23}
+	})
+	assert.True(t, em.HasEventFactory("order.paid"))
+	assert.False(t, em.HasEventFactory("order.created"))
+	assert.Panics(t, func() {
+		em.AddEventFactory("order.created", nil)
+	})
+
+	var id int
+	em.On("order.paid", ListenerFunc(func(e Event) error {
+		oe, ok := e.(*orderEvent)
+		if !ok {
+			return fmt.Errorf("not an orderEvent")
+		}
+		id = oe.OrderID
+		return nil
+	}))
+
+	err, e := em.Fire("order.paid", M{"amount": 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 23, id)
+	assert.Equal(t, 100, e.Get("amount"))
+
+	// create new instance on each fire
+	err, e2 := em.Fire("order.paid", nil)
+	assert.NoError(t, err)
+	assert.False(t, e == e2)
+	assert.Nil(t, e2.Get("amount"))
+
+	// fire batch
+	id = 0
+	assert.Empty(t, em.FireBatch("order.paid"))
+	assert.Equal(t, 23, id)
+
+	em.RemoveEvent("order.paid")
+	assert.False(t, em.HasEventFactory("order.paid"))
+}
+
+func TestManager_Fire_definedEventWithParams(t *testing.T) {
+	em := NewManager("test")
+	em.AddEvent(NewBasic("e1", M{"lang": "go", "ver": 1}))
+	em.AddEvent(&orderEvent{BasicEvent: *NewBasic("e2", M{"lang": "go"}), OrderID: 12})
+	em.On("e1", ListenerFunc(emptyListener))
+	em.On("e2", ListenerFunc(emptyListener))
+
+	err, e := em.Fire("e1", M{"ver": 2, "name": "event"})
+	assert.NoError(t, err)
+	assert.Equal(t, "go", e.Get("lang"))
+	assert.Equal(t, 2, e.Get("ver"))
+	assert.Equal(t, "event", e.Get("name"))
+
+	// the defined event is not changed
+	de, _ := em.GetEvent("e1")
+	assert.False(t, de == e)
+	assert.Equal(t, 1, de.Get("ver"))
+	assert.Nil(t, de.Get("name"))
+
+	// custom event type is kept
+	err, e = em.Fire("e2", M{"id": 3})
+	assert.NoError(t, err)
+	oe, ok := e.(*orderEvent)
+	assert.True(t, ok)
+	assert.Equal(t, 12, oe.OrderID)
+	assert.Equal(t, "go", oe.Get("lang"))
+	assert.Equal(t, 3, oe.Get("id"))
+	de, _ = em.GetEvent("e2")
+	assert.Nil(t, de.Get("id"))
+}
+
+func TestManager_CloneOnFire(t *testing.T) {
+	counter := ListenerFunc(func(e Event) error {
+		n, _ := e.Get("n").(int)
+		e.Set("n", n+1)
+		return nil
+	})
+
+	em := NewManager("test")
+	assert.True(t, em.Options().CloneOnFire)
+	em.AddEvent(NewBasic("e1", nil))
+	em.On("e1", counter)
+
+	_, e := em.Fire("e1", nil)
+	assert.Equal(t, 1, e.Get("n"))
+	_, e = em.Fire("e1", nil)
+	assert.Equal(t, 1, e.Get("n"))
+	assert.Empty(t, em.FireBatch("e1"))
+
+	de, _ := em.GetEvent("e1")
+	assert.Nil(t, de.Get("n"))
+
+	// disable clone, the defined event is shared
+	em = NewManager("test", WithCloneOnFire(false))
+	em.AddEvent(NewBasic("e1", nil))
+	em.On("e1", counter)
+
+	_, e = em.Fire("e1", nil)
+	assert.Equal(t, 1, e.Get("n"))
+	_, e = em.Fire("e1", M{"x": 1})
+	assert.Equal(t, 2, e.Get("n"))
+	de, _ = em.GetEvent("e1")
+	assert.True(t, de == e)
+}
+
+func TestManager_eventReuse(t *testing.T) {
+	em := NewManager("test")
+	var calls int
+	em.On("e1", ListenerFunc(func(e Event) error {
+		calls++
+		if e.Get("stop") == true {
+			e.Abort(true)
+		}
+		return nil
+	}), High)
+	em.On("e1", ListenerFunc(func(e Event) error {
+		calls++
+		return nil
+	}))
+
+	// reuse the aborted event instance
+	e := NewBasic("e1", M{"stop": true})
+	assert.NoError(t, em.FireEvent(e))
+	assert.True(t, e.IsAborted())
+	assert.Equal(t, 1, calls)
+
+	e.Set("stop", false)
+	assert.NoError(t, em.FireEvent(e))
+	assert.False(t, e.IsAborted())
+	assert.Equal(t, 3, calls)
+
+	// aborted pooled event will be reset on release
+	calls = 0
+	pe := em.AcquireEvent("e1")
+	pe.Set("stop", true)
+	assert.NoError(t, em.FireEvent(pe))
+	assert.True(t, pe.IsAborted())
+	em.ReleaseEvent(pe)
+	assert.False(t, pe.IsAborted())
+	assert.Nil(t, pe.Get("stop"))
+	assert.Empty(t, em.FireBatch("e1"))
+	assert.Equal(t, 3, calls)
+
+	// shared defined event
+	calls = 0
+	em = NewManager("test", WithCloneOnFire(false))
+	em.AddEvent(NewBasic("e2", nil))
+	em.On("e2", ListenerFunc(func(e Event) error {
+		calls++
+		e.Abort(true)
+		return nil
+	}))
+	em.On("e2", ListenerFunc(func(e Event) error {
+		calls++
+		return nil
+	}), Low)
+
+	_, e2 := em.Fire("e2", nil)
+	assert.True(t, e2.IsAborted())
+	_, e2 = em.Fire("e2", nil)
+	assert.True(t, e2.IsAborted())
+	assert.Equal(t, 2, calls)
+}
+
+// ownAbortEvent the custom event has own aborted flag
+type ownAbortEvent struct {
+	Event
+	aborted bool
+}
+
+func (e *ownAbortEvent) Abort(abort bool) { e.aborted = abort }
+
+func (e *ownAbortEvent) IsAborted() bool { return e.aborted }
+
+func TestManager_Dispatch(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe(), WithErrorPolicy(PolicyCollect))
+	em.Listen("e1", ContextListenerFunc(func(dc *DispatchContext) error {
+		if dc.Event().Get("stop") == true {
+			dc.Abort()
+		}
+		return nil
+	}), ListenOpts{Priority: High, Label: "stopper"})
+	em.Listen("e1", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("an error")
+	}), ListenOpts{Label: "failer"})
+
+	e := NewBasic("e1", M{"stop": true})
+	dc, err := em.Dispatch(e)
+	assert.NoError(t, err)
+	assert.True(t, dc.IsAborted())
+	assert.True(t, e.IsAborted())
+	assert.Len(t, dc.Visited(), 1)
+	assert.Equal(t, "stopper", dc.Visited()[0].Label)
+
+	e = NewBasic("e1", nil)
+	dc, err = em.Dispatch(e)
+	assert.Error(t, err)
+	assert.False(t, dc.IsAborted())
+	assert.Len(t, dc.Visited(), 2)
+	assert.Len(t, dc.Errors(), 1)
+
+	// concurrent fire the same event instance
+	e = NewBasic("e1", M{"stop": false})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dc, _ := em.Dispatch(e)
+			assert.Len(t, dc.Visited(), 2)
+		}()
+	}
+	wg.Wait()
+
+	// the Event.Abort() of the normal listener aborts the dispatch, include the custom event
+	em.Listen("e1", ListenerFunc(func(e Event) error {
+		e.Abort(true)
+		return nil
+	}), ListenOpts{Priority: Max})
+	for _, e := range []Event{NewBasic("e1", nil), &ownAbortEvent{Event: NewBasic("e1", nil)}} {
+		dc, err = em.Dispatch(e)
+		assert.NoError(t, err)
+		assert.True(t, dc.IsAborted())
+		assert.Len(t, dc.Visited(), 1)
+	}
+
+	// call as a normal listener
+	assert.NoError(t, ContextListenerFunc(func(dc *DispatchContext) error {
+		assert.Equal(t, "e2", dc.Event().Name())
+		return nil
+	}).Handle(NewBasic("e2", nil)))
+}
+
+func TestManager_HasListeners_matched(t *testing.T) {
+	em := NewManager("test")
+	assert.False(t, em.HasListeners("app.run"))
+
+	em.On("app.*", ListenerFunc(emptyListener))
+	assert.True(t, em.HasListeners("app.run"))
+	assert.False(t, em.HasListeners("db.run"))
+	assert.Equal(t, 0, em.ListenersCount("app.run"))
+
+	em.On("*", &testListener{"all"}, Low)
+	em.Listen("app.run", ListenerFunc(emptyListener), ListenOpts{Label: "low", Priority: Low})
+	em.Listen("app.run", ListenerFunc(emptyListener), ListenOpts{Label: "high", Priority: High})
+	em.On("app.stop", ListenerFunc(emptyListener))
+	em.SetEventParents("app.run", "app.stop")
+	assert.True(t, em.HasListeners("db.run"))
+
+	var labels []string
+	for _, li := range em.MatchListeners("app.run") {
+		labels = append(labels, li.Name())
+	}
+	assert.Len(t, labels, 5)
+	assert.Equal(t, "high", labels[0])
+	assert.Equal(t, "low", labels[1])
+	assert.Len(t, em.MatchListeners("db.run"), 1)
+
+	// fire with only group listeners
+	em = NewManager("test")
+	em.On("app.*", ListenerFunc(func(e Event) error {
+		e.Set("ok", true)
+		return nil
+	}))
+	err, e := em.Fire("app.run", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, e.Get("ok"))
+
+	em = NewManager("test", WithMatchMode(ModeExact))
+	em.On("app.*", ListenerFunc(emptyListener))
+	assert.False(t, em.HasListeners("app.run"))
+	assert.Len(t, em.MatchListeners("app.run"), 0)
+}
+
+func TestManager_ClearAllListeners(t *testing.T) {
+	em := NewManager("test")
+	em.AddEvent(NewBasic("app.run", nil))
+	em.On("app.run", ListenerFunc(emptyListener))
+	em.On("app.*", ListenerFunc(emptyListener))
+	em.On("*", ListenerFunc(emptyListener))
+
+	// only remove listeners on the name
+	em.RemoveListeners("app.run")
+	assert.Equal(t, 0, em.ListenersCount("app.run"))
+	assert.True(t, em.HasListeners("app.run"))
+	assert.Len(t, em.ListenedNames(), 2)
+
+	em.RemoveListeners("app.*")
+	assert.Len(t, em.MatchListeners("app.run"), 1)
+
+	em.ClearAllListeners()
+	assert.False(t, em.HasListeners("app.run"))
+	assert.Len(t, em.Listeners(), 0)
+	assert.Len(t, em.ListenedNames(), 0)
+	assert.True(t, em.HasEvent("app.run"))
+
+	// retire an event
+	em.On("app.run", ListenerFunc(emptyListener))
+	em.On("app.stop", ListenerFunc(emptyListener))
+	em.AddEventFactory("app.run", func() Event {
+		return NewBasic("app.run", nil)
+	})
+	em.SetEventParents("app.run", "app.stop")
+	em.RemoveEventAndListeners("app.run")
+	assert.False(t, em.HasEvent("app.run"))
+	assert.False(t, em.HasEventFactory("app.run"))
+	assert.Len(t, em.EventParents("app.run"), 0)
+	assert.False(t, em.HasListeners("app.run"))
+	assert.True(t, em.HasListeners("app.stop"))
+	assert.Len(t, em.ListenedNames(), 1)
+}
+
+func TestManager_ListenersCount(t *testing.T) {
+	em := NewManager("test")
+	l1 := &testListener{"l1"}
+	em.On("e1", l1)
+	em.On("e1", &testListener{"l2"})
+	em.Listen("e1", ListenerFunc(emptyListener), ListenOpts{Once: true})
+	em.On("e2", l1)
+	assert.Equal(t, 3, em.ListenersCount("e1"))
+	assert.Equal(t, 3, em.ListenedNames()["e1"])
+	assert.Equal(t, 1, em.ListenedNames()["e2"])
+
+	// once listener removed
+	em.MustFire("e1", nil)
+	assert.Equal(t, 2, em.ListenersCount("e1"))
+
+	em.RemoveListener("e1", l1)
+	assert.Equal(t, 1, em.ListenersCount("e1"))
+	assert.Equal(t, 1, em.ListenedNames()["e1"])
+
+	em.RemoveListener("", l1)
+	assert.Equal(t, 0, em.ListenersCount("e2"))
+	_, ok := em.ListenedNames()["e2"]
+	assert.False(t, ok)
+
+	em.RemoveListeners("e1")
+	assert.Equal(t, 0, em.ListenersCount("e1"))
+	assert.Len(t, em.ListenedNames(), 0)
+}
+
+func TestManager_patternListeners(t *testing.T) {
+	em := NewManager("test")
+	var calls []string
+	record := func(tag string) ListenerFunc {
+		return func(e Event) error {
+			calls = append(calls, tag)
+			return nil
+		}
+	}
+
+	em.On("app.*.error", record("app.*.error"))
+	em.On("*.created", record("*.created"))
+	em.On("app.*", record("app.*"))
+	em.On("app.db.*", record("app.db.*"))
+	em.On("user_*.created", record("user_*.created"))
+
+	assert.True(t, em.HasListeners("app.db.error"))
+	assert.True(t, em.HasListeners("order.created"))
+	assert.False(t, em.HasListeners("order.paid"))
+	assert.False(t, em.HasListeners("app.db.conn.error"))
+
+	em.MustFire("app.db.error", nil)
+	assert.Equal(t, []string{"app.*.error", "app.db.*"}, calls)
+
+	calls = nil
+	em.MustFire("app.created", nil)
+	assert.Equal(t, []string{"*.created", "app.*"}, calls)
+
+	calls = nil
+	em.MustFire("user_admin.created", nil)
+	assert.Equal(t, []string{"*.created", "user_*.created"}, calls)
+
+	// remove pattern listeners
+	em.RemoveListeners("*.created")
+	calls = nil
+	em.MustFire("order.created", nil)
+	assert.Len(t, calls, 0)
+
+	assert.True(t, matchSegments("app.*", "app.run"))
+	assert.False(t, matchSegments("app.*", "app.db.run"))
+	assert.False(t, matchSegments("app.*", "app"))
+}
+
+func TestManager_WithMatcher(t *testing.T) {
+	matchers := map[string]func() Matcher{
+		"wildcard": func() Matcher { return NewWildcardMatcher() },
+		"trie":     func() Matcher { return NewTrieMatcher() },
+	}
+
+	for typ, newFn := range matchers {
+		em := NewManager("test", WithMatcher(newFn()))
+		em.On("app.*.error", ListenerFunc(emptyListener))
+		em.On("app.*", ListenerFunc(emptyListener))
+		em.On("*.created", ListenerFunc(emptyListener))
+		em.On("user_*.created", ListenerFunc(emptyListener))
+		em.On("*", ListenerFunc(emptyListener))
+
+		assert.Len(t, em.MatchListeners("app.db.error"), 2, typ)
+		assert.Len(t, em.MatchListeners("app.created"), 3, typ)
+		assert.Len(t, em.MatchListeners("user_admin.created"), 3, typ)
+		assert.Len(t, em.MatchListeners("app.db.conn"), 1, typ)
+
+		em.RemoveListeners("*.created")
+		em.RemoveListeners("*")
+		assert.Len(t, em.MatchListeners("app.created"), 1, typ)
+		assert.Len(t, em.MatchListeners("user_admin.created"), 1, typ)
+		assert.False(t, em.HasListeners("db.created"), typ)
+	}
+
+	// exact
+	em := NewManager("test", WithMatcher(&ExactMatcher{}))
+	em.On("app.*", ListenerFunc(emptyListener))
+	em.On("*", ListenerFunc(emptyListener))
+	assert.False(t, em.HasListeners("app.run"))
+	assert.Len(t, em.MatchListeners("app.*"), 1)
+
+	// regex
+	em = NewManager("test", WithMatcher(NewRegexMatcher()), WithLenientNames())
+	em.On(`app\.(db|cache)\.error`, ListenerFunc(emptyListener))
+	em.On("app.*", ListenerFunc(emptyListener))
+	em.On("app.(", ListenerFunc(emptyListener))
+	assert.Len(t, em.MatchListeners("app.db.error"), 2)
+	assert.Len(t, em.MatchListeners("app.cache.error"), 2)
+	assert.Len(t, em.MatchListeners("app.db.run"), 1)
+	assert.Len(t, em.MatchListeners("app.("), 2)
+	assert.False(t, em.HasListeners("db.run"))
+}
+
+// rotateQueue a round-robin queue for test the custom Queue
+type rotateQueue struct {
+	ListenerQueue
+	n int
+}
+
+func (q *rotateQueue) Items() []*ListenerItem {
+	items := q.ListenerQueue.Items()
+	if len(items) < 2 {
+		return items
+	}
+
+	q.n = (q.n + 1) % len(items)
+	return append(append([]*ListenerItem{}, items[q.n:]...), items[:q.n]...)
+}
+
+func TestListenerQueue_Push(t *testing.T) {
+	lq := NewListenerQueue()
+	lq.Push(&ListenerItem{Priority: Normal, Seq: 1})
+	lq.Push(&ListenerItem{Priority: High, Seq: 2})
+	lq.Push(&ListenerItem{Priority: Normal, Seq: 3})
+	lq.Push(&ListenerItem{Priority: Low, Seq: 4})
+	lq.Push(&ListenerItem{Priority: High, Seq: 5})
+
+	// the snapshot is not changed on push
+	snap := lq.Items()
+	lq.Push(&ListenerItem{Priority: Max, Seq: 6})
+
+	var seqs []uint64
+	for _, li := range lq.Items() {
+		seqs = append(seqs, li.Seq)
+	}
+	assert.Equal(t, []uint64{6, 2, 5, 1, 3, 4}, seqs)
+	assert.Equal(t, uint64(2), snap[0].Seq)
+	assert.Len(t, snap, 5)
+
+	// re-sort after changed priority
+	lq.Items()[5].Priority = Max + 1
+	assert.Equal(t, uint64(4), lq.Sort().Items()[0].Seq)
+	lq.Clear()
+	assert.True(t, lq.IsEmpty())
+}
+
+func TestListenerQueue_Remove(t *testing.T) {
+	l1, l2 := &testListener{"a"}, &testListener{"a"}
+	fn := ListenerFunc(func(e Event) error { return nil })
+
+	lq := NewListenerQueue()
+	lq.Push(&ListenerItem{Listener: l1})
+	lq.Push(&ListenerItem{Listener: l2})
+	lq.Push(&ListenerItem{Listener: fn})
+
+	// the same content pointers are different listeners
+	lq.Remove(l1)
+	assert.Len(t, lq.Items(), 2)
+	assert.Equal(t, l2, lq.Items()[0].Listener)
+
+	lq.Remove(nil)
+	lq.Remove(fn)
+	assert.Len(t, lq.Items(), 1)
+
+	assert.True(t, sameListener(nil, nil))
+	assert.False(t, sameListener(l1, nil))
+	assert.False(t, sameListener(l1, fn))
+}
+
+func TestManager_WithQueueFactory(t *testing.T) {
+	em := NewManager("test", WithQueueFactory(func() Queue {
+		return &rotateQueue{}
+	}))
+	em.On("e1", &testListener{"a"})
+	em.On("e1", &testListener{"b"})
+
+	_, e := em.Fire("e1", nil)
+	assert.Equal(t, "handled: e1(b) -> e1(a)", e.Get("result"))
+	_, e = em.Fire("e1", nil)
+	assert.Equal(t, "handled: e1(a) -> e1(b)", e.Get("result"))
+}
+
+func BenchmarkListenerQueue_Push(b *testing.B) {
+	items := make([]*ListenerItem, 100)
+	for i := range items {
+		items[i] = &ListenerItem{Priority: i % 5 * 100, Seq: uint64(i)}
+	}
+
+	lq := NewListenerQueue()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if lq.Len() == len(items) {
+			lq.Clear()
+		}
+		lq.Push(items[lq.Len()])
+	}
+}
+
+func BenchmarkMatcher_Match(b *testing.B) {
+	matchers := []struct {
+		name string
+		m    Matcher
+	}{
+		{"wildcard", NewWildcardMatcher()},
+		{"trie", NewTrieMatcher()},
+	}
+
+	for _, bm := range matchers {
+		m := bm.m
+		for i := 0; i < 100; i++ {
+			m.Add(fmt.Sprintf("app%d.*", i))
+			m.Add(fmt.Sprintf("*.db%d.error", i))
+		}
+		m.Add(Wildcard)
+
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.Match("app50.db50.error")
+			}
+		})
+	}
+}
+
+func BenchmarkManager_Fire(b *testing.B) {
+	em := NewManager("bench")
+	for i := 0; i < 10; i++ {
+		em.On("app.run", ListenerFunc(emptyListener), i%3)
+	}
+	em.On("app.*", ListenerFunc(emptyListener))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = em.Fire("app.run", nil)
+	}
+}
+
+func TestManager_SetDelivery(t *testing.T) {
+	em := NewManager("test")
+	counts := map[string]int{}
+	worker := func(name string) ListenerFunc {
+		return func(e Event) error {
+			counts[name]++
+			return nil
+		}
+	}
+
+	em.SetDelivery("job.created", RoundRobin)
+	assert.Equal(t, RoundRobin, em.DeliveryOf("job.created"))
+	assert.Equal(t, Broadcast, em.DeliveryOf("job.deleted"))
+	assert.Equal(t, "round-robin", RoundRobin.String())
+
+	var filters int
+	em.On("job.created", worker("w1"))
+	em.Listen("job.created", worker("w2"), ListenOpts{Filter: func(e Event) bool {
+		filters++
+		return true
+	}})
+	em.On("job.*", worker("w3"))
+	em.On("*", worker("w4"))
+	em.Listen("job.created", worker("skip"), ListenOpts{Filter: func(e Event) bool {
+		return false
+	}})
+
+	for i := 0; i < 8; i++ {
+		em.MustFire("job.created", nil)
+	}
+	assert.Equal(t, 4, counts["w1"])
+	assert.Equal(t, 4, counts["w2"])
+	assert.Equal(t, 0, counts["skip"])
+	// the Filter is checked once on each fire
+	assert.Equal(t, 8, filters)
+	// the pattern and wildcard listeners are observers
+	assert.Equal(t, 8, counts["w3"])
+	assert.Equal(t, 8, counts["w4"])
+
+	// only the observers
+	em.SetDelivery("job.deleted", RoundRobin)
+	em.MustFire("job.deleted", nil)
+	assert.Equal(t, 9, counts["w3"])
+	assert.Equal(t, 9, counts["w4"])
+
+	// weighted
+	counts = map[string]int{}
+	em = NewManager("test")
+	em.SetDelivery("job.created", Weighted)
+	em.Listen("job.created", worker("w1"), ListenOpts{Weight: 9})
+	em.Listen("job.created", worker("w2"), ListenOpts{Weight: 1})
+	for i := 0; i < 1000; i++ {
+		em.MustFire("job.created", nil)
+	}
+	assert.Equal(t, 1000, counts["w1"]+counts["w2"])
+	assert.True(t, counts["w1"] > counts["w2"])
+
+	// back to broadcast
+	counts = map[string]int{}
+	em.SetDelivery("job.created", Broadcast)
+	em.MustFire("job.created", nil)
+	assert.Equal(t, 1, counts["w1"])
+	assert.Equal(t, 1, counts["w2"])
+}
+
+func TestManager_SetDelivery_unicast(t *testing.T) {
+	em := NewManager("test")
+	em.SetDelivery("cmd.send", Unicast)
+	assert.Equal(t, "unicast", em.DeliveryOf("cmd.send").String())
+
+	err, e := em.Fire("cmd.send", nil)
+	assert.Equal(t, ErrNoListener, err)
+	assert.Nil(t, e)
+	assert.Equal(t, []error{ErrNoListener}, em.FireBatch("cmd.send"))
+
+	em.On("cmd.send", ListenerFunc(func(e Event) error {
+		e.Set("sent", true)
+		return nil
+	}))
+	err, e = em.Fire("cmd.send", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, true, e.Get("sent"))
+
+	// the pattern and wildcard listeners are observers, not counted
+	var logged []string
+	em.On("cmd.*", ListenerFunc(func(e Event) error {
+		logged = append(logged, "group:"+e.Name())
+		return nil
+	}))
+	em.On("*", ListenerFunc(func(e Event) error {
+		logged = append(logged, "all:"+e.Name())
+		return nil
+	}))
+	err, _ = em.Fire("cmd.send", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"group:cmd.send", "all:cmd.send"}, logged)
+
+	// only the wildcard listener
+	em.SetDelivery("cmd.stop", Unicast)
+	err, _ = em.Fire("cmd.stop", nil)
+	assert.Equal(t, ErrNoListener, err)
+
+	// multi listeners on the name
+	em.On("cmd.send", ListenerFunc(emptyListener))
+	err, _ = em.Fire("cmd.send", nil)
+	assert.Equal(t, ErrAmbiguousListener, err)
+	assert.Equal(t, ErrAmbiguousListener, em.FireEvent(NewBasic("cmd.send", nil)))
+}
+
+type createUserCmd struct {
+	Name string
+}
+
+func TestCommandBus(t *testing.T) {
+	bus := NewCommandBus(nil)
+	bus.RegisterHandler("user.create", func(cmd Event) (interface{}, error) {
+		name, _ := cmd.Get("name").(string)
+		if name == "" {
+			return nil, fmt.Errorf("name is required")
+		}
+		return &userCreated{ID: len(name)}, nil
+	})
+	assert.True(t, bus.HasHandler("user.create"))
+	assert.False(t, bus.HasHandler("user.delete"))
+	assert.Panics(t, func() {
+		bus.RegisterHandler("user.create", func(cmd Event) (interface{}, error) {
+			return nil, nil
+		})
+	})
+
+	params := M{"name": "inhere"}
+	ret, err := bus.Dispatch("user.create", params)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, ret.(*userCreated).ID)
+	// the params is not changed
+	assert.Equal(t, M{"name": "inhere"}, params)
+
+	_, err = bus.Dispatch("user.create", nil)
+	assert.Error(t, err)
+
+	_, err = bus.Dispatch("user.delete", nil)
+	assert.Equal(t, ErrNoListener, err)
+
+	// the wildcard logger is not a handler
+	var logged int
+	bus.Manager().On("*", ListenerFunc(func(e Event) error {
+		logged++
+		return nil
+	}))
+	ret, err = bus.Dispatch("user.create", M{"name": "inhere"})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, ret.(*userCreated).ID)
+	_, err = bus.Dispatch("user.delete", nil)
+	assert.Equal(t, ErrNoListener, err)
+	assert.Equal(t, 1, logged)
+
+	// bind result
+	var uc *userCreated
+	assert.NoError(t, bus.DispatchTo("user.create", M{"name": "tom"}, &uc))
+	assert.Equal(t, 3, uc.ID)
+	var s string
+	assert.Error(t, bus.DispatchTo("user.create", M{"name": "tom"}, &s))
+	assert.Error(t, bus.DispatchTo("user.create", M{"name": "tom"}, s))
+
+	// struct command
+	bus.RegisterHandler(StructName(createUserCmd{}), func(cmd Event) (interface{}, error) {
+		return Payload(cmd).(createUserCmd).Name, nil
+	})
+	ret, err = bus.Send(createUserCmd{Name: "john"})
+	assert.NoError(t, err)
+	assert.Equal(t, "john", ret)
+	_, err = bus.Send(nil)
+	assert.Error(t, err)
+}
+
+type getUserQuery struct {
+	ID int
+}
+
+func TestQueryBus(t *testing.T) {
+	bus := NewQueryBus(nil)
+	var calls int
+	bus.RegisterHandler("user.get", func(q Event) (interface{}, error) {
+		calls++
+		id, _ := q.Get("id").(int)
+		if id == 0 {
+			return nil, fmt.Errorf("id is required")
+		}
+		return &userCreated{ID: id}, nil
+	}, &QueryCache{TTL: time.Minute})
+	assert.True(t, bus.HasHandler("user.get"))
+	assert.Equal(t, "query-bus", bus.Manager().Name())
+
+	ret, err := bus.Query("user.get", M{"id": 23})
+	assert.NoError(t, err)
+	assert.Equal(t, 23, ret.(*userCreated).ID)
+
+	// cached
+	var uc *userCreated
+	assert.NoError(t, bus.QueryTo("user.get", M{"id": 23}, &uc))
+	assert.Equal(t, 23, uc.ID)
+	assert.Equal(t, 1, calls)
+
+	_, err = bus.Query("user.get", M{"id": 24})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// error is not cached
+	_, err = bus.Query("user.get", nil)
+	assert.Error(t, err)
+	_, err = bus.Query("user.get", nil)
+	assert.Error(t, err)
+	assert.Equal(t, 4, calls)
+
+	bus.Invalidate("user.get")
+	_, err = bus.Query("user.get", M{"id": 23})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, calls)
+
+	_, err = bus.Query("user.list", nil)
+	assert.Equal(t, ErrNoListener, err)
+
+	// reuse the params map
+	params := M{"id": 25}
+	_, _ = bus.Query("user.get", params)
+	_, _ = bus.Query("user.get", params)
+	assert.Equal(t, 6, calls)
+	assert.Equal(t, M{"id": 25}, params)
+
+	// the expired entries are swept
+	bus.RegisterHandler("user.find", func(q Event) (interface{}, error) {
+		return q.Get("id"), nil
+	}, &QueryCache{TTL: time.Millisecond})
+	for i := 1; i < minSweepSize; i++ {
+		_, _ = bus.Query("user.find", M{"id": i})
+	}
+	time.Sleep(2 * time.Millisecond)
+	_, _ = bus.Query("user.find", M{"id": -1})
+	assert.Len(t, bus.caches["user.find"].entries, 1)
+
+	// struct query, custom key, no cache
+	bus.RegisterHandler(StructName(getUserQuery{}), func(q Event) (interface{}, error) {
+		calls++
+		return Payload(q).(getUserQuery).ID, nil
+	}, &QueryCache{Key: func(params M) string {
+		return fmt.Sprint(params[PayloadKey].(getUserQuery).ID)
+	}})
+	calls = 0
+	ret, err = bus.Ask(getUserQuery{ID: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, ret)
+	_, _ = bus.Ask(getUserQuery{ID: 5})
+	assert.Equal(t, 1, calls)
+	bus.Invalidate()
+	_, _ = bus.Ask(getUserQuery{ID: 5})
+	assert.Equal(t, 2, calls)
+}
+
+func TestManager_WithEventStore(t *testing.T) {
+	store := NewMemoryStore()
+	em := NewManager("test", WithEventStore(store, "order.*"))
+	em.On("*", ListenerFunc(emptyListener))
+
+	em.MustFire("order.created", M{StreamKey: "order-23", "amount": 100})
+	em.MustFire("order.paid", M{StreamKey: "order-23"})
+	em.MustFire("order.created", M{"amount": 20})
+	em.MustFire("user.created", nil)
+
+	ses, err := store.Load("order-23")
+	assert.NoError(t, err)
+	assert.Len(t, ses, 2)
+	assert.Equal(t, "order.created", ses[0].Name)
+	assert.Equal(t, uint64(1), ses[0].Version)
+	assert.Equal(t, float64(100), ses[0].Data["amount"])
+	assert.Nil(t, ses[0].Data[StreamKey])
+	assert.Equal(t, uint64(2), ses[1].Version)
+	assert.False(t, ses[1].Time.IsZero())
+
+	ses, err = store.LoadFrom("order-23", 2)
+	assert.NoError(t, err)
+	assert.Len(t, ses, 1)
+	assert.Equal(t, "order.paid", ses[0].ToEvent().Name())
+
+	ses, _ = store.LoadFrom("order-23", 3)
+	assert.Len(t, ses, 0)
+	ses, _ = store.Load("order.created")
+	assert.Len(t, ses, 1)
+	assert.Len(t, store.Streams(), 2)
+}
+
+func TestManager_WithEventStore_noListener(t *testing.T) {
+	store := NewMemoryStore()
+	audit := NewAuditLog(10)
+	em := NewManager("test", WithEventStore(store, "order.*"), WithAuditLog(audit))
+	rec := em.Record("*")
+
+	err, _ := em.Fire("order.created", M{StreamKey: "order-1"})
+	assert.NoError(t, err)
+	assert.Empty(t, em.FireBatch("order.paid"))
+
+	ses, _ := store.Load("order-1")
+	assert.Len(t, ses, 1)
+	ses, _ = store.Load("order.paid")
+	assert.Len(t, ses, 1)
+	assert.Len(t, rec.Events(), 2)
+	assert.Len(t, audit.Filter(AuditFire, "order.*"), 2)
+	assert.Equal(t, uint64(1), em.Stats().Unheard["order.paid"])
+
+	// unicast event still requires a listener
+	em.SetDelivery("order.cancel", Unicast)
+	err, _ = em.Fire("order.cancel", nil)
+	assert.Equal(t, ErrNoListener, err)
+	ses, _ = store.Load("order.cancel")
+	assert.Len(t, ses, 1)
+}
+
+var errDBDown = errors.New("db is down")
+
+type failStore struct {
+	MemoryStore
+}
+
+func (s *failStore) Append(string, ...*StoredEvent) error {
+	return errDBDown
+}
+
+func TestManager_WithEventStore_error(t *testing.T) {
+	em := NewManager("test", WithEventStore(&failStore{}))
+	var called bool
+	em.On("e1", ListenerFunc(func(e Event) error {
+		called = true
+		return nil
+	}))
+
+	err, _ := em.Fire("e1", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "db is down")
+	assert.True(t, errors.Is(err, errDBDown))
+	assert.False(t, called)
+}
+
+type orderAggregate struct {
+	Amount  float64
+	Applied int `json:"-"`
+}
+
+func (a *orderAggregate) Apply(se *StoredEvent) error {
+	a.Applied++
+	if v, ok := se.Data["amount"].(float64); ok {
+		a.Amount += v
+	}
+	return nil
+}
+
+func TestLoadAggregate(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.Append("order-1", &StoredEvent{Name: "order.paid", Data: M{"amount": 10}}))
+	}
+
+	agg := &orderAggregate{}
+	ver, err := LoadAggregate(store, "order-1", agg, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), ver)
+	assert.Equal(t, float64(50), agg.Amount)
+	assert.Equal(t, 5, agg.Applied)
+
+	snap, err := store.LoadSnapshot("order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), snap.Version)
+
+	// replay from the snapshot
+	assert.NoError(t, store.Append("order-1", &StoredEvent{Name: "order.paid", Data: M{"amount": 5}}))
+	agg = &orderAggregate{}
+	ver, err = LoadAggregate(store, "order-1", agg, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), ver)
+	assert.Equal(t, float64(55), agg.Amount)
+	assert.Equal(t, 1, agg.Applied)
+
+	snap, _ = store.LoadSnapshot("order-2")
+	assert.Nil(t, snap)
+
+	assert.Error(t, TakeSnapshot(struct{ EventStore }{store}, "order-1", 1, agg))
+}
+
+func TestProjection(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, store.Append(fmt.Sprint("order-", i), &StoredEvent{Name: "order.paid", Data: M{"amount": i}}))
+	}
+	assert.NoError(t, store.Append("user-1", &StoredEvent{Name: "user.created"}))
+
+	var total float64
+	var fail bool
+	var progress []ProjectionProgress
+	p := NewProjection("order-total", store)
+	p.BatchSize = 2
+	p.On("order.*", ListenerFunc(func(e Event) error {
+		if fail && e.Get("amount") == float64(4) {
+			return fmt.Errorf("db error")
+		}
+		total += e.Get("amount").(float64)
+		return nil
+	})).OnProgress(func(pp ProjectionProgress) {
+		progress = append(progress, pp)
+	})
+	assert.Equal(t, "order-total", p.Name())
+
+	// stop on error
+	fail = true
+	err := p.Run()
+	assert.Error(t, err)
+	assert.Equal(t, float64(6), total)
+	pos, _ := p.Position()
+	assert.Equal(t, uint64(3), pos)
+
+	// resume
+	fail = false
+	assert.NoError(t, p.Run())
+	assert.Equal(t, float64(15), total)
+	pos, _ = p.Position()
+	assert.Equal(t, uint64(6), pos)
+	last := progress[len(progress)-1]
+	assert.Equal(t, uint64(6), last.Position)
+	assert.Equal(t, 3, last.Processed)
+
+	// catch up new events
+	assert.NoError(t, store.Append("order-6", &StoredEvent{Name: "order.paid", Data: M{"amount": 6}}))
+	assert.NoError(t, p.Run())
+	assert.Equal(t, float64(21), total)
+
+	// rebuild
+	total = 0
+	assert.NoError(t, p.WithCheckpoints(NewMemoryCheckpoints()).Rebuild())
+	assert.Equal(t, float64(21), total)
+}
+
+func TestTemplateRenderer(t *testing.T) {
+	e := NewBasic("order.created", M{
+		"id":    23,
+		"user":  M{"name": "inhere"},
+		"items": []interface{}{map[string]interface{}{"sku": "A1"}},
+	})
+
+	r := MustTemplateRenderer(`{{.Name}}: order {{.Get "id"}} by {{path .Data "user.name"}} {{json .Data.user}}`)
+	text, err := r.Render(e)
+	assert.NoError(t, err)
+	assert.Equal(t, `order.created: order 23 by inhere {"name":"inhere"}`, text)
+
+	_, err = NewTemplateRenderer("{{.Name")
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		MustTemplateRenderer("{{.Name")
+	})
+
+	var fn Renderer = RenderFunc(func(e Event) (string, error) {
+		return e.Name(), nil
+	})
+	text, _ = fn.Render(e)
+	assert.Equal(t, "order.created", text)
+}
+
+func TestPathRenderer(t *testing.T) {
+	type user struct {
+		Name string
+		Tags []string
+	}
+
+	e := NewBasic("order.created", M{
+		"id":    23,
+		"user":  &user{Name: "inhere", Tags: []string{"vip"}},
+		"items": []interface{}{map[string]interface{}{"sku": "A1"}},
+		"meta":  map[string]int{"n": 2},
+	})
+
+	r := NewPathRenderer("{name}: order {data.id} by {data.user.Name}({data.user.Tags.0}) {data.items.0.sku} {data.meta.n} {data.not.exist}.")
+	text, err := r.Render(e)
+	assert.NoError(t, err)
+	assert.Equal(t, "order.created: order 23 by inhere(vip) A1 2 .", text)
+
+	text, err = NewPathRenderer("{data}").Render(NewBasic("app.run", M{"k": "v"}))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"k":"v"}`, text)
+
+	_, ok := GetPath(e.Data(), "items.1")
+	assert.False(t, ok)
+	_, ok = GetPath(e.Data(), "id.x")
+	assert.False(t, ok)
+	_, ok = GetPath(e.Data(), "user.age")
+	assert.False(t, ok)
+}
+
+func TestCompileExpr(t *testing.T) {
+	e := NewBasic("order.created", M{
+		"amount": 150,
+		"region": "eu",
+		"vip":    true,
+		"user":   M{"name": "inhere"},
+	})
+
+	tests := map[string]bool{
+		`data.amount > 100 && data.region == "eu"`:     true,
+		`data.amount > 100 && data.region == 'us'`:     false,
+		`data.amount >= 150 || data.region == "us"`:    true,
+		`data.amount < 100 || !(data.region != "eu")`:  true,
+		`data.amount <= -1`:                            false,
+		`data.amount == 150.0`:                         true,
+		`data.region in ["us", "eu"]`:                  true,
+		`data.region in ["us"]`:                        false,
+		`name == "order.created" && data.vip`:          true,
+		`data.user.name == "inhere"`:                   true,
+		`data.not.exist == nil && !data.not`:           true,
+		`data.region > "a" && data.amount != "150"`:    true,
+		`data.amount > "100"`:                          false,
+		`data.vip == true && data.user != nil && data`: true,
+	}
+
+	for src, want := range tests {
+		x, err := CompileExpr(src)
+		assert.NoError(t, err, src)
+		assert.Equal(t, want, x.Match(e), src)
+	}
+
+	x := MustCompileExpr(`data.amount`)
+	assert.Equal(t, "data.amount", x.String())
+	assert.Equal(t, 150, x.Eval(e))
+
+	for _, src := range []string{
+		"", "data.amount >", `data.region == "eu`, "amount > 1", "(data.vip", "data.vip in", "data.vip in [1",
+		"data.vip in [1 2]", "data.amount # 1", "data.vip )", "1.2.3 > 1",
+	} {
+		_, err := CompileExpr(src)
+		assert.Error(t, err, src)
+	}
+
+	assert.Panics(t, func() {
+		MustCompileExpr("data.amount >")
+	})
+}
+
+func TestExpr_routing(t *testing.T) {
+	RegisterListenerFactory("tl-where", func() Listener {
+		return &testListener{"where"}
+	})
+
+	em := NewManager("test")
+	err := em.ImportConfig(&ManagerConfig{Listeners: []*ListenerConfig{
+		{Event: "order.*", Factory: "tl-where", Where: `data.amount > 100`},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, `data.amount > 100`, em.ExportConfig().Listeners[0].Where)
+
+	err, e := em.Fire("order.created", M{"amount": 50})
+	assert.NoError(t, err)
+	assert.Nil(t, e.Get("result"))
+
+	err, e = em.Fire("order.created", M{"amount": 150})
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: order.created(where)", e.Get("result"))
+
+	err = em.ImportConfig(&ManagerConfig{Listeners: []*ListenerConfig{
+		{Event: "order.*", Factory: "tl-where", Where: `data.amount >`},
+	}})
+	assert.Error(t, err)
+
+	// bridge filter
+	em2 := NewManager("test2")
+	var got []string
+	em2.On("order.*", ListenerFunc(func(e Event) error {
+		got = append(got, e.Name())
+		return nil
+	}))
+
+	NewBridge(em, em2, "order.*").Filter(MustCompileExpr(`data.region == "eu"`).Match)
+	em.MustFire("order.created", M{"region": "us"})
+	em.MustFire("order.paid", M{"region": "eu"})
+	assert.Equal(t, []string{"order.paid"}, got)
+}
+
+type orderCreated struct {
+	ID     int      `json:"id"`
+	Amount float64  `json:"amount"`
+	Region string   `json:"region,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Note   string   `json:"-"`
+	secret string
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	reg := NewSchemaRegistry()
+	reg.Register("order.created", MustStructSchema(&orderCreated{}))
+	reg.Register("user.created", MustJSONSchema(`{
+	"type": "object",
+	"required": ["id"],
+	"properties": {
+		"id": {"type": "integer"},
+		"role": {"enum": ["admin", "user"]},
+		"emails": {"type": "array", "items": {"type": "string"}}
+	}
+}`))
+	assert.Equal(t, []string{"order.created", "user.created"}, reg.Names())
+	assert.Panics(t, func() {
+		reg.Register("", nil)
+	})
+
+	tests := []struct {
+		name  string
+		data  M
+		field string
+	}{
+		{"order.created", M{"id": 1, "amount": 2.5, "tags": []string{"a"}}, ""},
+		{"order.created", M{PayloadKey: orderCreated{ID: 1}}, ""},
+		{"order.created", M{"id": 1}, "amount"},
+		{"order.created", M{"id": "1", "amount": 1}, "id"},
+		{"order.created", M{"id": 1.5, "amount": 1}, "id"},
+		{"order.created", M{"id": 1, "amount": 1, "tags": []interface{}{1}}, "tags.0"},
+		{"user.created", M{"id": 1, "role": "admin", "emails": []interface{}{"a@b.c"}}, ""},
+		{"user.created", M{"role": "admin"}, "id"},
+		{"user.created", M{"id": 1, "role": "guest"}, "role"},
+		{"user.created", M{"id": 1, "emails": []interface{}{1}}, "emails.0"},
+		{"not.registered", M{"any": 1}, ""},
+	}
+
+	for _, tt := range tests {
+		err := reg.Validate(NewBasic(tt.name, tt.data))
+		if tt.field == "" {
+			assert.NoError(t, err, tt.name)
+			continue
+		}
+
+		assert.Error(t, err, tt.name)
+		assert.Equal(t, tt.field, err.(*SchemaError).Field, tt.name)
+		assert.Equal(t, tt.name, err.(*SchemaError).Event)
+	}
+
+	// validate on fire
+	em := NewManager("test")
+	em.AddInterceptor(reg.Interceptor())
+	var calls int
+	em.On("order.created", reg.Consumer(ListenerFunc(func(e Event) error {
+		calls++
+		return nil
+	})))
+
+	err, _ := em.Fire("order.created", M{"id": 1})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the data field 'amount' of 'order.created' is invalid: is required")
+	assert.Equal(t, 0, calls)
+
+	// validate on consume
+	assert.Error(t, reg.Consumer(ListenerFunc(emptyListener)).Handle(NewBasic("order.created", nil)))
+	err, _ = em.FireStruct(orderCreated{ID: 1})
+	assert.NoError(t, err)
+
+	// export
+	defs := reg.Export()
+	assert.Equal(t, []interface{}{"id", "amount"}, defs["order.created"]["required"])
+	assert.Equal(t, M{"type": "array", "items": M{"type": "string"}}, defs["order.created"]["properties"].(M)["tags"])
+
+	bs, err := reg.ExportJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"order.created": {`)
+
+	_, err = NewJSONSchema("{")
+	assert.Error(t, err)
+	_, err = NewStructSchema("abc")
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		MustStructSchema(nil)
+	})
+	assert.Panics(t, func() {
+		MustJSONSchema("")
+	})
+
+	s := MustStructSchema(orderCreated{})
+	assert.Equal(t, "orderCreated", s.Type().Name())
+	assert.Equal(t, "event: the data of 'e1' is invalid: x", (&SchemaError{Event: "e1", Reason: "x"}).Error())
+}
+
+type testLogger struct {
+	logs []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func TestManager_Deprecate(t *testing.T) {
+	logger := &testLogger{}
+	em := NewManager("test", WithLogger(logger))
+	em.Deprecate("user.signup", "user.created", true)
+	em.Deprecate("user.login", "", false)
+
+	r, ok := em.Deprecation("user.signup")
+	assert.True(t, ok)
+	assert.Equal(t, "user.created", r)
+	_, ok = em.Deprecation("user.created")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		em.Deprecate("user.login", "", true)
+	})
+
+	// the listener on the deprecated name will be added to the replacement
+	var got []string
+	em.On("user.signup", ListenerFunc(func(e Event) error {
+		got = append(got, e.Name())
+		return nil
+	}))
+	em.On("user.signup", ListenerFunc(emptyListener))
+	assert.Equal(t, 0, em.ListenersCount("user.signup"))
+	assert.Equal(t, 2, em.ListenersCount("user.created"))
+	assert.Equal(t, []string{"event: listen the deprecated event 'user.signup', use 'user.created' instead"}, logger.logs)
+
+	// fire by name
+	em.MustFire("user.signup", nil)
+	em.MustFire("user.signup", nil)
+	assert.Equal(t, []string{"user.created", "user.created"}, got)
+	assert.Len(t, logger.logs, 2)
+	assert.Equal(t, "event: fire the deprecated event 'user.signup', use 'user.created' instead", logger.logs[1])
+
+	// fire by instance, the name is not changed
+	assert.NoError(t, em.FireEvent(NewBasic("user.signup", nil)))
+	assert.Equal(t, "user.signup", got[2])
+
+	// without reroute
+	em.On("user.login", ListenerFunc(emptyListener))
+	em.MustFire("user.login", nil)
+	assert.Equal(t, 1, em.ListenersCount("user.login"))
+	assert.Equal(t, "event: fire the deprecated event 'user.login'", logger.logs[3])
+
+	em.Seal()
+	assert.Panics(t, func() {
+		em.Deprecate("user.logout", "", false)
+	})
+}
+
+func TestManager_Graph(t *testing.T) {
+	em := NewManager("app")
+	em.AddEvent(NewBasic("order.created", nil))
+	em.AddEventFactory("order.paid", func() Event {
+		return &BasicEvent{}
+	})
+	em.SetEventParents("order.paid", "order.changed")
+	em.Deprecate("order.new", "order.created", false)
+	em.Listen("order.*", ListenerFunc(emptyListener), ListenOpts{Label: "audit", Priority: High})
+	em.Listen("order.created", ListenerFunc(emptyListener), ListenOpts{Label: "mailer"})
+	NewBridge(em, NewManager("billing"), "order.paid")
+
+	g := em.Graph()
+	assert.Equal(t, "app", g.Name)
+
+	nodes := map[string]*GraphNode{}
+	for _, n := range g.Nodes {
+		nodes[n.ID] = n
+	}
+	assert.True(t, nodes["event:order.created"].Declared)
+	assert.True(t, nodes["event:order.paid"].Declared)
+	assert.False(t, nodes["event:order.changed"].Declared)
+	assert.Equal(t, NodePattern, nodes["event:order.*"].Kind)
+	assert.Equal(t, NodeManager, nodes["manager:billing"].Kind)
+
+	edges := map[string]bool{}
+	for _, e := range g.Edges {
+		edges[e.Kind+" "+e.From+" -> "+e.To] = true
+	}
+	assert.True(t, edges["listen event:order.* -> listener:order.*#1"])
+	assert.True(t, edges["listen event:order.created -> listener:order.created#2"])
+	assert.True(t, edges["bridge event:order.paid -> manager:billing"])
+	assert.True(t, edges["parent event:order.paid -> event:order.changed"])
+	assert.True(t, edges["deprecated event:order.new -> event:order.created"])
+	assert.True(t, edges["match event:order.created -> event:order.*"])
+	assert.True(t, edges["match event:order.paid -> event:order.*"])
+
+	dot := g.DOT()
+	assert.Contains(t, dot, `digraph "app" {`)
+	assert.Contains(t, dot, `"event:order.created" [label="order.created", shape=ellipse, style=bold];`)
+	assert.Contains(t, dot, `"event:order.*" -> "listener:order.*#1" [label="listen priority=200"];`)
+	assert.Contains(t, dot, `"event:order.paid" -> "manager:billing" [label="bridge", style=dashed];`)
+
+	bs, err := g.JSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"kind": "listener"`)
+	assert.Contains(t, string(bs), `"label": "audit"`)
+}
+
+func TestManager_Pause(t *testing.T) {
+	em := NewManager("test")
+	var calls int
+	em.On("order.*", ListenerFunc(func(e Event) error {
+		calls++
+		return nil
+	}))
+	em.On("order.bad", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("bad order")
+	}))
+
+	em.Pause("order.*", "user.created")
+	assert.True(t, em.IsPaused("order.created"))
+	assert.False(t, em.IsPaused("user.deleted"))
+	// same as the listeners, the "order.*" does not match it
+	assert.False(t, em.IsPaused("order.item.added"))
+	assert.Equal(t, []string{"order.*", "user.created"}, em.PausedNames())
+
+	em.MustFire("order.created", nil)
+	assert.Equal(t, 0, calls)
+
+	em.Resume("order.*")
+	em.MustFire("order.created", nil)
+	assert.Equal(t, 1, calls)
+	err, _ := em.Fire("order.bad", nil)
+	assert.Error(t, err)
+
+	stats := em.Stats()
+	assert.Equal(t, uint64(2), stats.Fired)
+	assert.Equal(t, uint64(1), stats.Failed)
+	assert.Equal(t, uint64(2), stats.Handled)
+	assert.Equal(t, uint64(1), stats.Paused)
+	assert.Equal(t, map[string]uint64{"order.created": 1, "order.bad": 1}, stats.Events)
+
+	em.ResetStats()
+	stats = em.Stats()
+	assert.Equal(t, uint64(0), stats.Fired)
+	assert.Equal(t, uint64(0), stats.Paused)
+	assert.Empty(t, stats.Events)
+
+	em.Clear()
+	assert.Empty(t, em.PausedNames())
+}
+
+func TestManager_SetPriority(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+	em.Listen("e1", &testListener{"l1"}, ListenOpts{Label: "l1"})
+	em.Listen("e1", &testListener{"l2"}, ListenOpts{Label: "l2"})
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(l1) -> e1(l2)", e.Get("result"))
+
+	assert.NoError(t, em.SetPriority("e1", "l2", High))
+	err, e = em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: e1(l2) -> e1(l1)", e.Get("result"))
+	li := em.ListenersByName("e1").Items()[0]
+	assert.Equal(t, Normal, li.Priority)
+	assert.Equal(t, High, li.CurrentPriority())
+
+	// change the priority on the running dispatches
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, _ = em.Fire("e1", nil)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, em.SetPriority("e1", "l1", Normal+i))
+	}
+	wg.Wait()
+
+	assert.Error(t, em.SetPriority("e2", "l2", High))
+	assert.True(t, errors.Is(em.SetPriority("e1", "l3", High), ErrNoListener))
+
+	em.Seal()
+	assert.Equal(t, ErrSealed, em.SetPriority("e1", "l2", Low))
+	assert.NotPanics(t, func() {
+		em.Pause("e1")
+	})
+}
+
+func TestManager_EventNames(t *testing.T) {
+	em := NewManager("test")
+	em.AddEvent(NewBasic("e2", nil))
+	em.AddEvent(NewBasic("e1", nil))
+	assert.Equal(t, []string{"e1", "e2"}, em.EventNames())
+}
+
+func TestManager_Tail(t *testing.T) {
+	em := NewManager("test")
+	em.On("order.*", ListenerFunc(func(e Event) error {
+		e.Set("result", "ok")
+		return nil
+	}))
+	em.On("user.*", ListenerFunc(emptyListener))
+
+	ch, stop := em.Tail("order.*", 2)
+	ch2, stop2 := em.Tail("*")
+
+	e := em.MustFire("order.created", M{"id": 1})
+	em.MustFire("user.created", nil)
+	em.MustFire("order.paid", nil)
+	em.MustFire("order.cancel", nil)
+
+	// the event is copied
+	te := <-ch
+	assert.Equal(t, "order.created", te.Name())
+	assert.Equal(t, "ok", te.Get("result"))
+	e.Set("id", 2)
+	assert.Equal(t, 1, te.Get("id"))
+
+	assert.Equal(t, "order.paid", (<-ch).Name())
+	assert.Equal(t, uint64(1), em.Stats().TailDropped)
+	assert.Len(t, ch2, 4)
+
+	// the tails are not listeners
+	assert.Equal(t, 1, em.ListenersCount("order.*"))
+	assert.False(t, em.HasListeners("app.run"))
+
+	stop()
+	stop()
+	_, ok := <-ch
+	assert.False(t, ok)
+	em.MustFire("order.created", nil)
+	assert.Len(t, ch2, 5)
+
+	assert.NoError(t, em.Close())
+	assert.Len(t, ch2, 5)
+	for range ch2 {
+	}
+	stop2()
+
+	ch, _ = em.Tail("*")
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+func TestDiffManagers(t *testing.T) {
+	mailer := ListenerFunc(emptyListener)
+
+	prod := NewManager("prod")
+	prod.AddEvent(NewBasic("order.created", nil))
+	prod.SetEventParents("order.created", "order.changed")
+	prod.Listen("order.created", mailer, ListenOpts{Label: "mailer"})
+	prod.Listen("order.created", mailer, ListenOpts{Label: "audit"})
+	prod.Listen("order.paid", &testListener{"billing"}, ListenOpts{})
+
+	staging := NewManager("staging")
+	staging.AddEvent(NewBasic("order.paid", nil))
+	staging.SetEventParents("order.paid", "order.changed")
+	staging.Listen("order.created", mailer, ListenOpts{Label: "audit", Priority: High, Async: true})
+	staging.Listen("order.paid", &testListener{"billing"}, ListenOpts{})
+	staging.Listen("order.paid", mailer, ListenOpts{Label: "notify", Once: true})
+
+	assert.True(t, DiffManagers(prod, prod).Empty())
+
+	d := DiffManagers(prod, staging)
+	assert.False(t, d.Empty())
+	assert.Equal(t, []string{"order.paid"}, d.AddedEvents)
+	assert.Equal(t, []string{"order.created"}, d.RemovedEvents)
+	assert.Equal(t, []string{"order.created", "order.paid"}, d.ChangedParents)
+	assert.Len(t, d.Added, 1)
+	assert.Equal(t, "notify", d.Added[0].Label)
+	assert.Len(t, d.Removed, 1)
+	assert.Equal(t, "mailer", d.Removed[0].Label)
+	assert.Len(t, d.Changed, 1)
+	assert.Equal(t, "audit", d.Changed[0].New.Label)
+
+	assert.Equal(t, `+ event order.paid
+- event order.created
+~ parents order.created
+~ parents order.paid
++ listener order.paid notify priority=0 once
+- listener order.created mailer priority=0
+~ listener order.created audit priority=0 -> priority=200 async
+`, d.String())
+
+	// diff configs
+	RegisterListenerFactory("diff-tl", func() Listener {
+		return &testListener{"diff"}
+	})
+	a := &ManagerConfig{Listeners: []*ListenerConfig{{Event: "e1", Factory: "diff-tl"}}}
+	b := &ManagerConfig{Listeners: []*ListenerConfig{{Event: "e1", Factory: "diff-tl", Where: "data.id > 1"}}}
+	d = DiffConfig(a, b)
+	assert.Equal(t, "~ listener e1 diff-tl priority=0 -> priority=0 where=data.id > 1\n", d.String())
+}
+
+func TestManager_Record(t *testing.T) {
+	em := NewManager("test")
+	em.On("order.*", ListenerFunc(func(e Event) error {
+		e.Set("result", "ok")
+		return nil
+	}))
+	em.On("user.*", ListenerFunc(emptyListener))
+
+	rec := em.Record("order.*")
+	em.MustFire("order.created", M{"id": 1})
+	em.MustFire("user.created", nil)
+	time.Sleep(20 * time.Millisecond)
+	em.MustFire("order.paid", M{"id": 1})
+	rec.Stop()
+	em.MustFire("order.cancel", nil)
+
+	events := rec.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, "order.created", events[0].Name)
+	assert.Equal(t, M{"id": 1}, events[0].Data)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, rec.WriteJSONLines(buf))
+	loaded, err := LoadRecording(buf)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+	assert.Equal(t, "order.paid", loaded[1].Name)
+	assert.Equal(t, float64(1), loaded[1].Data["id"])
+
+	_, err = LoadRecording(bytes.NewBufferString("{\n"))
+	assert.Error(t, err)
+
+	// replay
+	em2 := NewManager("test2")
+	var got []string
+	em2.On("order.*", ListenerFunc(func(e Event) error {
+		got = append(got, e.Name())
+		if e.Name() == "order.created" {
+			return fmt.Errorf("replay error")
+		}
+		return nil
+	}))
+
+	start := time.Now()
+	err = Replay(em2, loaded, ReplayOptions{Speed: 2})
+	assert.Error(t, err)
+	assert.Len(t, err.(Errors), 1)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	assert.Equal(t, []string{"order.created", "order.paid"}, got)
+
+	// stepwise
+	got = got[:0]
+	err = Replay(em2, loaded, ReplayOptions{Step: func(i int, re *RecordedEvent) bool {
+		return i < 1
+	}})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"order.created"}, got)
+
+	got = got[:0]
+	err = Replay(em2, loaded, ReplayOptions{StopOnError: true})
+	assert.Equal(t, "replay error", err.Error())
+	assert.Equal(t, []string{"order.created"}, got)
+}
+
+func TestManager_SetDebugger(t *testing.T) {
+	em := NewManager("test")
+	em.Listen("e1", &testListener{"l1"}, ListenOpts{Label: "l1", Priority: High})
+	em.Listen("e1", &testListener{"l2"}, ListenOpts{Label: "l2"})
+	em.Listen("e1", &testListener{"l3"}, ListenOpts{Label: "l3", Priority: Low})
+
+	var calls []string
+	em.SetDebugger(DebuggerFunc(func(e Event, li *ListenerItem) DebugAction {
+		calls = append(calls, li.Name())
+		switch li.Name() {
+		case "l1":
+			e.Set("debug", true)
+		case "l2":
+			return DebugSkip
+		}
+		return DebugContinue
+	}))
+
+	err, e := em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"l1", "l2", "l3"}, calls)
+	assert.Equal(t, true, e.Get("debug"))
+	assert.Equal(t, "handled: e1(l1) -> e1(l3)", e.Get("result"))
+
+	em.SetDebugger(DebuggerFunc(func(e Event, li *ListenerItem) DebugAction {
+		if li.Name() == "l2" {
+			return DebugAbort
+		}
+		return DebugContinue
+	}))
+	err, e = em.Fire("e1", nil)
+	assert.NoError(t, err)
+	assert.True(t, e.IsAborted())
+	assert.Equal(t, "handled: e1(l1)", e.Get("result"))
+
+	// step debugger
+	sd := NewStepDebugger()
+	em.SetDebugger(sd)
+	done := make(chan Event)
+	go func() {
+		done <- em.MustFire("e1", nil)
+	}()
+
+	step := <-sd.Steps()
+	assert.Equal(t, "l1", step.Listener.Name())
+	step.Event.Set("k", "v")
+	step.Continue()
+	(<-sd.Steps()).Skip()
+	(<-sd.Steps()).Abort()
+
+	e = <-done
+	assert.Equal(t, "v", e.Get("k"))
+	assert.Equal(t, "handled: e1(l1)", e.Get("result"))
+	assert.True(t, e.IsAborted())
+
+	sd.Disable()
+	e = em.MustFire("e1", nil)
+	assert.Equal(t, "handled: e1(l1) -> e1(l2) -> e1(l3)", e.Get("result"))
+	sd.Enable()
+
+	em.SetDebugger(nil)
+	e = em.MustFire("e1", nil)
+	assert.Equal(t, "handled: e1(l1) -> e1(l2) -> e1(l3)", e.Get("result"))
+}
+
+func TestManager_SetProfile(t *testing.T) {
+	em := NewManager("test")
+
+	var calls int
+	em.On("order.created", ListenerFunc(func(e Event) error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("fail %d", calls)
+		}
+		return nil
+	}))
+	em.On("order.created", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("always")
+	}))
+
+	em.SetProfile("order.*", Policy(PolicyCollect), Retry(2, 0))
+	assert.True(t, em.HasProfile("order.*"))
+
+	err, _ := em.Fire("order.created", nil)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "always", err.Error())
+
+	// the exact name profile will override the patterns
+	calls = 0
+	em.SetProfile("order.created", Policy(PolicyIgnore))
+	err, _ = em.Fire("order.created", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	// the given options will override the profile
+	calls = 0
+	err, _ = em.FireWith("order.created", nil, Policy(PolicyStop))
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	em.RemoveProfile("order.created")
+	em.RemoveProfile("order.*")
+	assert.False(t, em.HasProfile("order.*"))
+
+	calls = 0
+	err = em.FireBatch("order.created")[0]
+	assert.Equal(t, "fail 1", err.Error())
+
+	// sampling
+	var fired int
+	em.On("metrics.tick", ListenerFunc(func(e Event) error {
+		fired++
+		return nil
+	}))
+	em.SetProfile("metrics.tick", Sample(0.000001))
+	for i := 0; i < 10; i++ {
+		em.MustFire("metrics.tick", nil)
+	}
+	assert.True(t, fired < 10)
+	assert.Equal(t, uint64(10-fired), em.Stats().Sampled)
+
+	em.Seal()
+	assert.Panics(t, func() {
+		em.SetProfile("metrics.tick")
+	})
+}
+
+func TestManager_MaxListeners(t *testing.T) {
+	logger := &testLogger{}
+	em := NewManager("test", WithLogger(logger), WithMaxListeners(2, false))
+	em.SetMaxListeners("app.tick", 3)
+	assert.Equal(t, 2, em.MaxListeners("app.run"))
+	assert.Equal(t, 3, em.MaxListeners("app.tick"))
+
+	for i := 0; i < 4; i++ {
+		em.On("app.run", &testListener{"l"})
+	}
+	assert.Equal(t, 4, em.ListenersCount("app.run"))
+	assert.Len(t, logger.logs, 1)
+	assert.Contains(t, logger.logs[0], "'app.run' has more than 2 listeners")
+
+	em = NewManager("test", WithMaxListeners(1, true))
+	em.SetMaxListeners("app.tick", 0)
+	assert.NoError(t, em.TryOn("app.run", &testListener{"l"}))
+	err := em.TryOn("app.run", &testListener{"l"})
+	assert.True(t, errors.Is(err, ErrMaxListeners))
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+
+	// unlimited
+	for i := 0; i < 3; i++ {
+		em.On("app.tick", &testListener{"l"})
+	}
+	assert.Equal(t, 3, em.ListenersCount("app.tick"))
+}
+
+func TestManager_Stats_leaks(t *testing.T) {
+	em := NewManager("test")
+	em.On("user.created", &testListener{"l"})
+	em.On("order.*", &testListener{"l"})
+	em.On("order.paid", &testListener{"l"})
+	since := em.Stats().Since
+
+	em.MustFire("order.created", nil)
+	em.MustFire("user.cerated", nil)
+	em.FireBatch("user.cerated", "user.updated")
+	assert.NoError(t, em.FireEvent(NewBasic("user.deleted", nil)))
+
+	stats := em.Stats()
+	assert.Equal(t, map[string]uint64{"user.cerated": 2, "user.updated": 1, "user.deleted": 1}, stats.Unheard)
+	assert.Equal(t, []string{"order.paid", "user.created"}, stats.Unfired)
+
+	em.ResetStats()
+	stats = em.Stats()
+	assert.Empty(t, stats.Unheard)
+	assert.Equal(t, []string{"order.*", "order.paid", "user.created"}, stats.Unfired)
+	assert.False(t, stats.Since.Before(since))
+}
+
+func TestManager_DuplicatePolicy(t *testing.T) {
+	l1 := &testListener{"l1"}
+
+	// default allow
+	em := NewManager("test")
+	em.On("app.run", l1)
+	em.On("app.run", l1)
+	assert.Equal(t, 2, em.ListenersCount("app.run"))
+
+	em = NewManager("test", WithDuplicatePolicy(DuplicateIgnore))
+	em.On("app.run", l1)
+	em.On("app.run", l1)
+	em.Listen("app.run", &testListener{"l2"}, ListenOpts{Label: "mailer"})
+	em.Listen("app.run", &testListener{"l3"}, ListenOpts{Label: "mailer"})
+	em.On("app.stop", l1)
+	assert.Equal(t, 2, em.ListenersCount("app.run"))
+	assert.Equal(t, 1, em.ListenersCount("app.stop"))
+	e := em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l1) -> app.run(l2)", e.Get("result"))
+
+	logger := &testLogger{}
+	em = NewManager("test", WithDuplicatePolicy(DuplicateWarn), WithLogger(logger))
+	em.On("app.run", l1)
+	em.On("app.run", l1)
+	assert.Equal(t, 2, em.ListenersCount("app.run"))
+	assert.Len(t, logger.logs, 1)
+
+	em = NewManager("test", WithDuplicatePolicy(DuplicateError))
+	assert.NoError(t, em.TryOn("app.run", l1))
+	err := em.TryOn("app.run", l1)
+	assert.True(t, errors.Is(err, ErrDuplicateListener))
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+	assert.Panics(t, func() {
+		em.On("app.run", l1)
+	})
+}
+
+func TestManager_Scope(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+	em.On("app.run", &testListener{"l0"})
+
+	s := em.NewScope()
+	s.On("app.run", &testListener{"l1"})
+	s.Listen("app.*", &testListener{"l2"}, ListenOpts{Label: "l2"})
+	assert.NoError(t, s.TryOn("app.stop", &testListener{"l3"}))
+	assert.Equal(t, 3, s.Len())
+	assert.Equal(t, 2, em.ListenersCount("app.run"))
+
+	e := em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l0) -> app.run(l1) -> app.run(l2)", e.Get("result"))
+
+	s.Close()
+	s.Close()
+	assert.True(t, s.IsClosed())
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+	assert.False(t, em.HasListeners("app.stop"))
+	assert.Equal(t, ErrClosed, s.TryOn("app.run", &testListener{"l1"}))
+
+	e = em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l0)", e.Get("result"))
+
+	// close by the context
+	ctx, cancel := context.WithCancel(context.Background())
+	s = em.WithScope(ctx)
+	s.On("app.run", &testListener{"l1"})
+	assert.Equal(t, 2, em.ListenersCount("app.run"))
+
+	cancel()
+	<-s.Done()
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+}
+
+func TestManager_OnWithContext(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+	ctx, cancel := context.WithCancel(context.Background())
+	em.OnWithContext(ctx, "app.run", &testListener{"l1"}, High)
+	em.OnWithContext(context.Background(), "app.run", &testListener{"l2"})
+
+	e := em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l1) -> app.run(l2)", e.Get("result"))
+
+	cancel()
+	for i := 0; i < 100 && em.ListenersCount("app.run") > 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	e = em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l2)", e.Get("result"))
+
+	assert.Panics(t, func() {
+		em.OnWithContext(context.Background(), "app.run", nil)
+	})
+}
+
+type weakOwner struct {
+	name string
+	buf  [32]byte
+}
+
+func TestManager_OnWeak(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+	em.On("app.run", &testListener{"l0"})
+
+	owner := &weakOwner{name: "view"}
+	em.OnWeak("app.run", owner, &testListener{"l1"})
+	e := em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l0) -> app.run(l1)", e.Get("result"))
+	runtime.KeepAlive(owner)
+
+	owner = nil
+	for i := 0; i < 100 && em.ListenersCount("app.run") > 1; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+
+	// alive callback
+	alive := true
+	em.Listen("app.run", &testListener{"l2"}, ListenOpts{Alive: func() bool {
+		return alive
+	}})
+	e = em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l0) -> app.run(l2)", e.Get("result"))
+
+	alive = false
+	e = em.MustFire("app.run", nil)
+	assert.Equal(t, "handled: app.run(l0)", e.Get("result"))
+	assert.Equal(t, 1, em.ListenersCount("app.run"))
+}
+
+func TestManager_PprofLabels(t *testing.T) {
+	em := NewManager("test", WithPprofLabels())
+	em.On("app.run", &testListener{"l1"})
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("fail")
+	}), Low)
+
+	err, e := em.Fire("app.run", nil)
+	assert.Equal(t, "fail", err.Error())
+	assert.Equal(t, "handled: app.run(l1)", e.Get("result"))
+}
+
+func TestErrors_structured(t *testing.T) {
+	em := NewManager("test")
+
+	err, _ := em.TryFire("", nil)
+	assert.True(t, errors.Is(err, ErrInvalidName))
+	err = em.TryOn("invalid name", &testListener{"l1"})
+	assert.True(t, errors.Is(err, ErrInvalidName))
+	err = em.TryOn("app.run", nil)
+	assert.True(t, errors.Is(err, ErrNilListener))
+	err = em.SetPriority("not.exists", "l1", High)
+	assert.True(t, errors.Is(err, ErrNoListener))
+
+	// the panic value is error
+	func() {
+		defer func() {
+			err, ok := recover().(error)
+			assert.True(t, ok)
+			assert.True(t, errors.Is(err, ErrNilListener))
+		}()
+		em.On("app.run", nil)
+	}()
+
+	errFail := fmt.Errorf("fail")
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		return errFail
+	}), ListenOpts{Label: "l1"})
+	err, _ = em.Fire("app.run", nil)
+	assert.Equal(t, "fail", err.Error())
+	assert.True(t, errors.Is(err, errFail))
+
+	var le *ListenerError
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, "app.run", le.Event)
+	assert.Equal(t, "l1", le.Listener)
+	assert.Equal(t, "event: the listener 'l1' of 'app.run' error: fail", le.String())
+
+	// the collected errors
+	err = Errors{ErrClosed, le}
+	assert.True(t, errors.Is(err, ErrClosed))
+	assert.True(t, errors.Is(err, errFail))
+	le = nil
+	assert.True(t, errors.As(err, &le))
+	assert.Equal(t, "l1", le.Listener)
+}
+
+func TestManager_NoPanic(t *testing.T) {
+	var errs []error
+	em := NewManager("test", WithNoPanic(func(err error) {
+		errs = append(errs, err)
+	}))
+
+	em.On("invalid name", &testListener{"l1"})
+	em.On("app.run", nil)
+	em.AddEvent(NewBasic("", nil))
+	em.AddEventFactory("invalid name", func() Event { return NewBasic("", nil) })
+	em.RegisterEventPool("app.run", nil)
+	assert.Len(t, errs, 5)
+	assert.True(t, errors.Is(errs[0], ErrInvalidName))
+	assert.True(t, errors.Is(errs[1], ErrNilListener))
+	assert.True(t, errors.Is(errs[3], ErrInvalidName))
+	assert.True(t, errors.Is(errs[4], ErrNilFactory))
+	assert.False(t, em.HasListeners("app.run"))
+	assert.False(t, em.HasEventFactory("invalid name"))
+	assert.Error(t, em.TryAddEventFactory("app.run", nil))
+
+	err, e := em.Fire("invalid name", nil)
+	assert.True(t, errors.Is(err, ErrInvalidName))
+	assert.Nil(t, e)
+	err, _ = em.FireWith("", nil, Async())
+	assert.True(t, errors.Is(err, ErrInvalidName))
+	ers := em.FireBatch("invalid name")
+	assert.True(t, errors.Is(ers[0], ErrInvalidName))
+
+	// log by the Logger
+	logger := &testLogger{}
+	em = NewManager("test", WithNoPanic(), WithLogger(logger))
+	em.On("app.run", nil)
+	assert.Len(t, logger.logs, 1)
+
+	// default is panic
+	em = NewManager("test")
+	assert.Panics(t, func() {
+		em.Fire("invalid name", nil)
+	})
+}
+
+func TestManager_FireContext(t *testing.T) {
+	em := NewManager("test")
+	ctx, cancel := context.WithCancel(context.Background())
+	em.On("app.run", &testListener{"l1"}, High)
+	em.On("app.run", ContextListenerFunc(func(dc *DispatchContext) error {
+		assert.Equal(t, ctx.Done(), dc.Context().Done())
+		cancel()
+		return nil
+	}))
+	em.On("app.run", &testListener{"l3"}, Low)
+
+	err, e := em.FireContext(ctx, "app.run", nil)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, "event: stop fire 'app.run': context canceled", err.Error())
+	assert.Equal(t, "handled: app.run(l1)", e.Get("result"))
+
+	err, _ = em.FireContext(ctx, "app.run", nil, Policy(PolicyCollect))
+	assert.Len(t, err.(Errors), 1)
+
+	em.RemoveListeners("app.run")
+	em.On("app.run", &testListener{"l1"})
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	err, e = em.FireWith("app.run", nil, Context(ctx2))
+	assert.NoError(t, err)
+	assert.Equal(t, "handled: app.run(l1)", e.Get("result"))
+}
+
+func TestManager_WithTrace(t *testing.T) {
+	em := NewManager("test", WithTrace())
+	em.Listen("app.*", &testListener{"l1"}, ListenOpts{Label: "l1", Priority: High})
+	em.Listen("app.run", ListenerFunc(emptyListener), ListenOpts{Label: "l2", Async: true})
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("fail")
+	}), ListenOpts{Label: "l3", Priority: Low})
+
+	err, e := em.FireWith("app.run", nil, Policy(PolicyCollect))
+	assert.Error(t, err)
+	trace := e.(Tracer).Trace()
+	assert.Len(t, trace, 3)
+	assert.Equal(t, "l2", trace[0].Listener)
+	assert.True(t, trace[0].Async)
+	assert.Equal(t, "l3", trace[1].Listener)
+	assert.Equal(t, "fail", trace[1].Err.Error())
+	assert.Equal(t, "l1", trace[2].Listener)
+	assert.Equal(t, "app.*", trace[2].Listened)
+	assert.Equal(t, High, trace[2].Priority)
+
+	// disabled
+	em = NewManager("test")
+	em.On("app.run", &testListener{"l1"})
+	e = em.MustFire("app.run", nil)
+	assert.Nil(t, e.(Tracer).Trace())
+}
+
+func TestManager_Observer(t *testing.T) {
+	em := NewManager("test")
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		_, ok := e.(*ReadOnlyEvent)
+		assert.True(t, ok)
+		assert.Equal(t, "v", e.Get("k"))
+
+		e.Set("k", "changed")
+		e.Add("new", 1)
+		e.Data()["k"] = "changed"
+		e.SetData(M{"k": "changed"})
+		e.Abort(true)
+		return nil
+	}), ListenOpts{Observer: true, Priority: High})
+	em.Listen("app.run", ContextListenerFunc(func(dc *DispatchContext) error {
+		dc.Abort()
+		dc.Event().Set("k", "changed")
+		return nil
+	}), ListenOpts{Observer: true})
+	em.On("app.run", &testListener{"l3"}, Low)
+
+	e := em.MustFire("app.run", M{"k": "v"})
+	assert.False(t, e.IsAborted())
+	assert.Equal(t, "v", e.Get("k"))
+	assert.Nil(t, e.Get("new"))
+	assert.Equal(t, "handled: app.run(l3)", e.Get("result"))
+
+	ro := NewReadOnlyEvent(e)
+	assert.Equal(t, ro, NewReadOnlyEvent(ro))
+	assert.Equal(t, "app.run", ro.Name())
+	assert.False(t, ro.IsAborted())
+}
+
+func TestManager_Capabilities(t *testing.T) {
+	em := NewManager("test")
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		e.Set("mutator", true)
+		e.Abort(true)
+		return nil
+	}), ListenOpts{Caps: CapMutator, Priority: High})
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		e.Set("observer", true)
+		return nil
+	}), ListenOpts{Caps: CapObserver})
+	em.On("app.run", &testListener{"l3"}, Low)
+
+	e := em.MustFire("app.run", nil)
+	assert.Equal(t, true, e.Get("mutator"))
+	assert.Nil(t, e.Get("observer"))
+	assert.False(t, e.IsAborted())
+	assert.Equal(t, "handled: app.run(l3)", e.Get("result"))
+
+	em.Listen("app.run", ContextListenerFunc(func(dc *DispatchContext) error {
+		dc.Abort()
+		return nil
+	}), ListenOpts{Caps: CapAborter, Priority: Max})
+	e = em.MustFire("app.run", nil)
+	assert.True(t, e.IsAborted())
+	assert.Nil(t, e.Get("result"))
+
+	items := em.ListenersByName("app.run").Items()
+	assert.Equal(t, "observer|aborter", items[0].Capabilities().String())
+	assert.Equal(t, "observer|mutator", items[1].Capabilities().String())
+	assert.Equal(t, "observer", items[2].Capabilities().String())
+	assert.Equal(t, CapAll, items[3].Capabilities())
+}
+
+func TestManager_SetBarriers(t *testing.T) {
+	em := NewManager("test")
+	em.SetBarriers("order.created", Normal, High)
+	assert.Equal(t, []int{High, Normal}, em.Barriers("order.created"))
+
+	var validated, mutated int32
+	for i := 0; i < 2; i++ {
+		em.Listen("order.created", ListenerFunc(func(e Event) error {
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&validated, 1)
+			return nil
+		}), ListenOpts{Priority: High, Async: true})
+	}
+	em.Listen("order.created", ListenerFunc(func(e Event) error {
+		assert.Equal(t, int32(2), atomic.LoadInt32(&validated))
+		atomic.AddInt32(&mutated, 1)
+		return nil
+	}), ListenOpts{Priority: Normal})
+
+	done := make(chan bool)
+	em.Listen("order.created", ListenerFunc(func(e Event) error {
+		assert.Equal(t, int32(1), atomic.LoadInt32(&mutated))
+		done <- true
+		return nil
+	}), ListenOpts{Priority: Low, Async: true})
+
+	em.MustFire("order.created", nil)
+	<-done
+
+	em.SetBarriers("order.created")
+	assert.Nil(t, em.Barriers("order.created"))
+}
+
+func TestManager_Pipeline(t *testing.T) {
+	em := NewManager("test")
+	p := em.Pipeline("order.created")
+	assert.Equal(t, DefaultStages, p.Stages())
+	assert.Equal(t, "order.created", p.Name())
+	assert.Len(t, em.Barriers("order.created"), 4)
+
+	var calls []string
+	var mu sync.Mutex
+	add := func(s string) ListenerFunc {
+		return func(e Event) error {
+			mu.Lock()
+			calls = append(calls, s)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	p.On(StageNotify, add("notify"))
+	p.Listen(StageEnrich, ListenerFunc(func(e Event) error {
+		time.Sleep(10 * time.Millisecond)
+		return add("enrich")(e)
+	}), ListenOpts{Async: true, Label: "loader"})
+	p.On(StageHandle, add("handle"))
+	p.On(StageValidate, add("validate"))
+
+	em.MustFire("order.created", nil)
+	assert.Equal(t, []string{"validate", "enrich", "handle", "notify"}, calls)
+	assert.Equal(t, "loader", p.Listeners(StageEnrich)[0].Label)
+	assert.Nil(t, p.Listeners("unknown"))
+
+	assert.Error(t, p.TryListen("unknown", add("x"), ListenOpts{}))
+	assert.Panics(t, func() {
+		p.On("unknown", add("x"))
+	})
+
+	p = em.Pipeline("user.created", "check", "save")
+	p.On("save", add("save"))
+	p.On("check", add("check"))
+	calls = nil
+	em.MustFire("user.created", nil)
+	assert.Equal(t, []string{"check", "save"}, calls)
+}
+
+func TestNewContext(t *testing.T) {
+	em := NewManager("test")
+	ctx := NewContext(context.Background(), em)
+	assert.Equal(t, em, FromContext(ctx))
+
+	got, ok := ManagerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, em, got)
+
+	_, ok = ManagerFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Equal(t, DefaultEM, FromContext(context.Background()))
+	assert.Equal(t, DefaultEM, FromContext(NewContext(context.Background(), nil)))
+}
+
+func TestManager_ReportHealth(t *testing.T) {
+	em := NewManager("test")
+	var unhealthy []Event
+	em.On(EventUnhealthy, ListenerFunc(func(e Event) error {
+		unhealthy = append(unhealthy, e)
+		return nil
+	}))
+
+	r := em.HealthReport()
+	assert.True(t, r.Ready && r.Live)
+	assert.Empty(t, r.Components)
+
+	em.ReportHealth("mailer", HealthStatus{Ready: true, Live: true})
+	em.ReportHealth("consumer", HealthStatus{Ready: false, Live: true, Message: "connecting"})
+	assert.Len(t, unhealthy, 1)
+	assert.Equal(t, "consumer", unhealthy[0].Get("component"))
+	assert.Equal(t, "connecting", unhealthy[0].Get("message"))
+
+	// only fire on becomes unhealthy
+	em.ReportHealth("consumer", HealthStatus{Ready: false, Live: true})
+	assert.Len(t, unhealthy, 1)
+
+	r = em.HealthReport()
+	assert.False(t, r.Ready)
+	assert.True(t, r.Live)
+	assert.Equal(t, []string{"consumer"}, r.Unhealthy())
+
+	em.ReportHealth("consumer", HealthStatus{Ready: true, Live: true})
+	em.ReportHealth("mailer", HealthStatus{Ready: true, Live: true, TTL: time.Millisecond})
+	time.Sleep(5 * time.Millisecond)
+	r = em.HealthReport()
+	assert.True(t, r.Ready)
+	assert.False(t, r.Live)
+	assert.Equal(t, []string{"mailer"}, r.Unhealthy())
+
+	em.RemoveHealth("mailer")
+	r = em.HealthReport()
+	assert.True(t, r.Ready && r.Live)
+}
+
+func TestManager_HealthListener(t *testing.T) {
+	em := NewManager("test")
+	fail := true
+	em.On("mail.send", em.HealthListener("mailer", 2, ListenerFunc(func(e Event) error {
+		if fail {
+			return fmt.Errorf("smtp down")
+		}
+		return nil
+	})))
+	assert.True(t, em.HealthReport().Ready)
+
+	em.Fire("mail.send", nil)
+	assert.True(t, em.HealthReport().Ready)
+	em.Fire("mail.send", nil)
+	r := em.HealthReport()
+	assert.False(t, r.Ready)
+	assert.Equal(t, "smtp down", r.Components["mailer"].Message)
+
+	fail = false
+	em.MustFire("mail.send", nil)
+	assert.True(t, em.HealthReport().Ready)
+}
+
+func TestManager_Pressure(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe(), WithBackpressure(2))
+	signals := make(chan bool, 4)
+	em.On(EventBackpressure, ListenerFunc(func(e Event) error {
+		signals <- e.Get("active").(bool)
+		return nil
+	}))
+
+	release := make(chan bool)
+	em.Listen("job.run", ListenerFunc(func(e Event) error {
+		<-release
+		return nil
+	}), ListenOpts{Async: true})
+
+	for i := 0; i < 3; i++ {
+		em.MustFire("job.run", nil)
+	}
+	assert.Equal(t, int64(3), em.Pending())
+	assert.Equal(t, 1.5, em.Pressure())
+	assert.Equal(t, int64(3), em.Stats().Pending)
+	assert.True(t, <-signals)
+
+	close(release)
+	assert.False(t, <-signals)
+	for i := 0; i < 100 && em.Pending() > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int64(0), em.Pending())
+
+	// async fire is counted
+	em.RemoveListeners("job.run")
+	done := make(chan bool)
+	em.On("job.run", ListenerFunc(func(e Event) error {
+		assert.Equal(t, int64(1), em.Pending())
+		done <- true
+		return nil
+	}))
+	em.FireWith("job.run", nil, Async())
+	<-done
+
+	assert.Equal(t, float64(0), NewManager("test").Pressure())
+}
+
+func TestManager_Meta(t *testing.T) {
+	em := NewManager("test")
+	meta := em.Meta()
+	assert.Equal(t, meta, em.Meta())
+	assert.Equal(t, meta, meta.Meta())
+
+	var events []string
+	meta.On("*", ListenerFunc(func(e Event) error {
+		events = append(events, fmt.Sprintf("%s:%s:%v", e.Name(), e.Get("event"), e.Get("error")))
+		return nil
+	}))
+
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		return fmt.Errorf("fail")
+	}), ListenOpts{Label: "l1"})
+	em.Fire("app.run", nil)
+	em.Fire("app.stop", nil)
+	em.RemoveListeners("app.run")
+
+	assert.Equal(t, []string{
+		"listener.added:app.run:<nil>",
+		"listener.error:app.run:fail",
+		"event.unhandled:app.stop:<nil>",
+		"listener.removed:app.run:<nil>",
+	}, events)
+
+	// once listener
+	events = nil
+	em.Listen("app.run", &testListener{"l2"}, ListenOpts{Once: true})
+	em.MustFire("app.run", nil)
+	assert.Equal(t, []string{"listener.added:app.run:<nil>", "listener.removed:app.run:<nil>"}, events)
+}
+
+func TestManager_MaxFireDepth(t *testing.T) {
+	em := NewManager("test", WithMaxFireDepth(3))
+
+	// a -> b -> a -> ...
+	var calls, depth int
+	em.On("app.a", ContextListenerFunc(func(dc *DispatchContext) error {
+		calls++
+		depth = em.FireDepth(dc.Context(), "app.a")
+		err, _ := em.FireContext(dc.Context(), "app.b", nil)
+		return err
+	}))
+	em.On("app.b", ContextListenerFunc(func(dc *DispatchContext) error {
+		err, _ := em.FireContext(dc.Context(), "app.a", nil)
+		return err
+	}))
+
+	err, _ := em.Fire("app.a", nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrFireLoop))
+	assert.Contains(t, err.Error(), "'app.a'")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 3, depth)
+	assert.Equal(t, 0, em.FireDepth(context.Background(), "app.a"))
+
+	// the concurrent fires are not recursion
+	em = NewManager("test", WithMaxFireDepth(2), WithConcurrencySafe())
+	start := make(chan struct{})
+	em.On("app.c", ListenerFunc(func(e Event) error {
+		<-start
+		return nil
+	}))
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			err, _ := em.Fire("app.c", nil)
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(start)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-errs)
+	}
+
+	// disabled
+	em = NewManager("test")
+	calls = 0
+	em.On("app.a", ListenerFunc(func(e Event) error {
+		if calls++; calls < 5 {
+			em.MustFire("app.a", nil)
+		}
+		return nil
+	}))
+	em.MustFire("app.a", nil)
+	assert.Equal(t, 5, calls)
+}
+
+func TestManager_MaxDispatchDepth(t *testing.T) {
+	em := NewManager("test", WithMaxDispatchDepth(3))
+
+	var chain []string
+	next := func(name string) Listener {
+		return ContextListenerFunc(func(dc *DispatchContext) error {
+			chain = dc.Chain()
+			err, _ := em.FireContext(dc.Context(), name, nil)
+			return err
+		})
+	}
+	em.On("app.a", next("app.b"))
+	em.On("app.b", next("app.c"))
+	em.On("app.c", next("app.d"))
+	em.On("app.d", ListenerFunc(func(e Event) error {
+		return nil
+	}))
+
+	err, _ := em.Fire("app.a", nil)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrMaxDepth))
+	var de *DepthError
+	assert.True(t, errors.As(err, &de))
+	assert.Equal(t, []string{"app.a", "app.b", "app.c", "app.d"}, de.Chain)
+	assert.Equal(t, "event: exceeds the max dispatch depth 3: app.a -> app.b -> app.c -> app.d", de.Error())
+	assert.Equal(t, []string{"app.a", "app.b", "app.c"}, chain)
+
+	// the chain in the ctx
+	err, _ = em.FireContext(context.Background(), "app.b", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ChainFromContext(context.Background()))
+}
+
+func TestManager_reentrantRegister(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+
+	var calls []string
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		calls = append(calls, "l1")
+		em.On("app.run", ListenerFunc(func(e Event) error {
+			calls = append(calls, "new")
+			return nil
+		}))
+		em.RemoveListeners("app.run")
+		return nil
+	}), ListenOpts{Priority: High})
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		calls = append(calls, "l2")
+		return nil
+	}))
+
+	// the removed listener is called on the current fire, the new listener is called from the next fire.
+	em.MustFire("app.run", nil)
+	assert.Equal(t, []string{"l1", "l2"}, calls)
+	assert.False(t, em.HasListeners("app.run"))
+
+	// reentrant fire in the once listener
+	calls = nil
+	em.Listen("app.once", ListenerFunc(func(e Event) error {
+		calls = append(calls, "once")
+		em.MustFire("app.once", nil)
+		return nil
+	}), ListenOpts{Once: true})
+	em.MustFire("app.once", nil)
+	assert.Equal(t, []string{"once"}, calls)
+	assert.False(t, em.HasListeners("app.once"))
+
+	// the custom queue
+	em = NewManager("test", WithQueueFactory(func() Queue {
+		return &rotateQueue{}
+	}))
+	calls = nil
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		calls = append(calls, "l1")
+		em.RemoveListeners("app.run")
+		return nil
+	}))
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		calls = append(calls, "l2")
+		return nil
+	}))
+	em.MustFire("app.run", nil)
+	assert.Len(t, calls, 2)
+}
+
+func TestManager_fireSnapshot(t *testing.T) {
+	em := NewManager("test", WithConcurrencySafe())
+
+	var calls []string
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		calls = append(calls, "l1")
+		return nil
+	}))
+	// the interceptor changes the listeners after the snapshot
+	em.AddInterceptor(func(e Event) error {
+		if e.Name() == "app.run" {
+			em.RemoveListeners("app.run")
+			em.On("app.run", ListenerFunc(func(e Event) error {
+				calls = append(calls, "l2")
+				return nil
+			}))
+		}
+		return nil
+	})
+
+	em.MustFire("app.run", nil)
+	assert.Equal(t, []string{"l1"}, calls)
+
+	// concurrent registration and removal
+	em = NewManager("test", WithConcurrencySafe())
+	em.On("app.run", ListenerFunc(emptyListener), High)
+	churn := &testListener{"churn"}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				em.On("app.run", churn)
+				em.RemoveListener("app.run", churn)
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		dc, err := em.Dispatch(NewBasic("app.run", nil))
+		assert.NoError(t, err)
+		assert.True(t, len(dc.Visited()) > 0)
+		assert.Equal(t, High, dc.Visited()[0].Priority)
+	}
+	close(stop)
+	wg.Wait()
+}
+
+type paymentEvent struct {
+	BasicEvent
+	id string
+}
+
+func (e *paymentEvent) IdempotencyKey() string {
+	return e.id
+}
+
+func TestManager_WithIdempotency(t *testing.T) {
+	em := NewManager("test", WithIdempotency(IdempotencyConfig{}))
+
+	var dups []string
+	em.Meta().On(MetaEventDuplicate, ListenerFunc(func(e Event) error {
+		dups = append(dups, e.Get("error").(error).Error())
+		return nil
+	}))
+
+	var calls int
+	em.On("payment.*", ListenerFunc(func(e Event) error {
+		calls++
+		if e.Get("fail") == true {
+			return fmt.Errorf("fail")
+		}
+		return nil
+	}))
+
+	em.MustFire("payment.captured", M{IdempotencyKey: "p1"})
+	em.MustFire("payment.captured", M{IdempotencyKey: "p1"})
+	em.MustFire("payment.refunded", M{IdempotencyKey: "p1"})
+	em.MustFire("payment.captured", nil)
+	em.MustFire("payment.captured", nil)
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, uint64(1), em.Stats().Duplicates)
+	assert.Equal(t, []string{"event: duplicate event 'payment.captured:p1'"}, dups)
+
+	// by the Idempotent interface
+	e := &paymentEvent{BasicEvent: *NewBasic("payment.captured", nil), id: "p2"}
+	dc, err := em.Dispatch(e)
+	assert.NoError(t, err)
+	assert.False(t, dc.IsDuplicate())
+	dc, err = em.Dispatch(e)
+	assert.NoError(t, err)
+	assert.True(t, dc.IsDuplicate())
+	assert.Equal(t, 5, calls)
+
+	// release the key on error
+	err, _ = em.Fire("payment.captured", M{IdempotencyKey: "p3", "fail": true})
+	assert.Error(t, err)
+	err, _ = em.Fire("payment.captured", M{IdempotencyKey: "p3"})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, calls)
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+
+	ok, err := s.Claim("k1", 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = s.Claim("k1", 10*time.Millisecond)
+	assert.False(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+	ok, _ = s.Claim("k2", 10*time.Millisecond)
+	assert.True(t, ok)
+	// the expired keys are purged
+	assert.Equal(t, 1, s.Len())
+	ok, _ = s.Claim("k1", 10*time.Millisecond)
+	assert.True(t, ok)
+
+	assert.NoError(t, s.Release("k1"))
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestLRUDedupeStore(t *testing.T) {
+	s := NewLRUDedupeStore(2)
+
+	ok, _ := s.Claim("k1", time.Minute)
+	assert.True(t, ok)
+	ok, _ = s.Claim("k1", time.Minute)
+	assert.False(t, ok)
+
+	// evict the least recently claimed
+	s.Claim("k2", time.Minute)
+	s.Claim("k3", time.Minute)
+	assert.Equal(t, 2, s.Len())
+	ok, _ = s.Claim("k1", time.Minute)
+	assert.True(t, ok)
+
+	// expired
+	ok, _ = s.Claim("k4", -time.Second)
+	assert.True(t, ok)
+	ok, _ = s.Claim("k4", time.Minute)
+	assert.True(t, ok)
+
+	assert.NoError(t, s.Release("k4"))
+	assert.Equal(t, 1, s.Len())
+}
+
+func TestStampKey(t *testing.T) {
+	e := NewBasic("app.run", nil)
+	key := StampKey(e)
+	assert.Len(t, key, 32)
+	assert.Equal(t, key, e.Get(IdempotencyKey))
+	assert.Equal(t, key, StampKey(e))
+	assert.NotEqual(t, key, NewIdempotencyKey())
+}
+
+func TestCompressCodec(t *testing.T) {
+	c := NewCompressCodec(nil, GzipCompressor{}, 64)
+
+	// small event is not compressed
+	bs, err := c.Encode(NewBasic("app.run", M{"k": "v"}))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"app.run","data":{"k":"v"}}`, string(bs))
+	e, err := c.Decode(bs)
+	assert.NoError(t, err)
+	assert.Equal(t, "v", e.Get("k"))
+
+	body := strings.Repeat("abc", 1000)
+	bs, err = c.Encode(NewBasic("app.run", M{"body": body}))
+	assert.NoError(t, err)
+	assert.True(t, GzipCompressor{}.IsCompressed(bs))
+	assert.True(t, len(bs) < 200)
+	e, err = c.Decode(bs)
+	assert.NoError(t, err)
+	assert.Equal(t, body, e.Get("body"))
+
+	_, err = c.Decode([]byte{0x1f, 0x8b, 1})
+	assert.Error(t, err)
+	assert.Panics(t, func() {
+		NewCompressCodec(nil, nil, 0)
+	})
+}
+
+func TestCompressText(t *testing.T) {
+	c := GzipCompressor{Level: gzip.BestSpeed}
+	s, err := compressText(c, 10, []byte(`{"k":"v"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"k":"v"}`, s)
+
+	data := `{"body":"` + strings.Repeat("x", 100) + `"}`
+	s, err = compressText(c, 10, []byte(data))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "z:"))
+
+	// the compressor is not setting on load
+	bs, err := decompressText(nil, s)
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(bs))
+	bs, err = decompressText(c, `{"k":"v"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"k":"v"}`, string(bs))
+}
+
+func TestClaimCheck(t *testing.T) {
+	store := NewMemoryBlobStore()
+	cc := NewClaimCheck(store, 100)
+	body := strings.Repeat("x", 200)
+
+	data := M{"id": 1, "body": body}
+	newData, err := cc.Offload(data)
+	assert.NoError(t, err)
+	assert.Equal(t, body, data["body"])
+	ref, ok := BlobRefOf(newData["body"])
+	assert.True(t, ok)
+	assert.Equal(t, 1, newData["id"])
+	assert.Equal(t, 1, store.Len())
+
+	// no large values
+	newData, err = cc.Offload(M{"id": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, M{"id": 1}, newData)
+
+	// the codec
+	codec := cc.Codec(nil)
+	bs, err := codec.Encode(NewBasic("doc.uploaded", data))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), ref)
+	assert.NotContains(t, string(bs), body)
+
+	e, err := codec.Decode(bs)
+	assert.NoError(t, err)
+	_, ok = BlobRefOf(e.Get("body"))
+	assert.True(t, ok)
+
+	// rehydrate for the listener
+	em := NewManager("test")
+	em.On("doc.uploaded", cc.Listener(ListenerFunc(func(e Event) error {
+		assert.Equal(t, body, e.Get("body"))
+		return nil
+	})))
+	assert.NoError(t, em.FireEvent(e))
+
+	// the event store
+	ms := NewMemoryStore()
+	es := cc.EventStore(ms)
+	se := &StoredEvent{Name: "doc.uploaded", Data: data}
+	assert.NoError(t, es.Append("doc-1", se))
+	assert.Equal(t, body, se.Data["body"])
+	assert.Equal(t, uint64(1), se.Version)
+	ses, err := es.Load("doc-1")
+	assert.NoError(t, err)
+	_, ok = BlobRefOf(ses[0].Data["body"])
+	assert.True(t, ok)
+
+	// the journal and snapshots of the wrapped store
+	ses, err = es.(Journal).ReadAll(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, ses, 1)
+	assert.NoError(t, TakeSnapshot(es, "doc-1", 1, M{"size": 200}))
+	snap, err := es.(SnapshotStore).LoadSnapshot("doc-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), snap.Version)
+
+	// not found
+	e = NewBasic("doc.uploaded", M{"body": M{BlobRefKey: "not-exist"}})
+	assert.Error(t, cc.Rehydrate(e))
+}
+
+func TestFileBlobStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blobs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := NewFileBlobStore(filepath.Join(dir, "sub"))
+	ref, err := s.Put([]byte("hello"))
+	assert.NoError(t, err)
+	ref2, err := s.Put([]byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, ref, ref2)
+
+	bs, err := s.Get(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(bs))
+
+	_, err = s.Get("../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestEncryption(t *testing.T) {
+	p, err := NewAESProvider([]byte("0123456789abcdef"))
+	assert.NoError(t, err)
+	_, err = NewAESProvider([]byte("short"))
+	assert.Error(t, err)
+
+	data := M{"id": "u1", "email": "tom@example.com"}
+
+	// the fields
+	enc := NewEncryption(p, "email")
+	ed, err := enc.EncryptData(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", ed["id"])
+	assert.NotContains(t, fmt.Sprint(ed), "tom@example.com")
+	assert.Equal(t, "tom@example.com", data["email"])
+	dd, err := enc.DecryptData(ed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, dd)
+
+	// the codec with the whole data
+	codec := NewEncryption(p).Codec(nil)
+	bs, err := codec.Encode(NewBasic("user.created", data))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"name":"user.created"`)
+	assert.NotContains(t, string(bs), "tom@example.com")
+	e, err := codec.Decode(bs)
+	assert.NoError(t, err)
+	assert.Equal(t, "tom@example.com", e.Get("email"))
+
+	// the event store
+	ms := NewMemoryStore()
+	es := enc.EventStore(ms)
+	assert.NoError(t, es.Append("u1", &StoredEvent{Name: "user.created", Data: data}))
+	raw, _ := ms.Load("u1")
+	assert.NotContains(t, fmt.Sprint(raw[0].Data), "tom@example.com")
+	ses, err := es.Load("u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "tom@example.com", ses[0].Data["email"])
+	raw, _ = ms.Load("u1")
+	assert.NotContains(t, fmt.Sprint(raw[0].Data), "tom@example.com")
+
+	// the recording
+	buf := new(bytes.Buffer)
+	err = enc.WriteJSONLines(buf, []*RecordedEvent{{Name: "user.created", Data: data}})
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "tom@example.com")
+	res, err := enc.LoadRecording(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "tom@example.com", res[0].Data["email"])
+
+	// decrypt by the wrong key
+	p2, _ := NewAESProvider([]byte("fedcba9876543210"))
+	_, err = NewEncryption(p2, "email").DecryptData(ed)
+	assert.Error(t, err)
+}
+
+func TestEncryption_EventStore(t *testing.T) {
+	p, _ := NewAESProvider([]byte("0123456789abcdef"))
+	ms := NewMemoryStore()
+	es := NewEncryption(p).EventStore(ms)
+
+	se := &StoredEvent{Name: "order.paid", Data: M{"amount": 10}}
+	assert.NoError(t, es.Append("order-1", se))
+	// the given event is not changed, the version is synced back
+	assert.Equal(t, M{"amount": 10}, se.Data)
+	assert.Equal(t, uint64(1), se.Version)
+	assert.Equal(t, uint64(1), se.Position)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, es.Append("order-1", &StoredEvent{Name: "order.paid", Data: M{"amount": 10}}))
+	}
+
+	// the snapshot state is encrypted
+	agg := &orderAggregate{}
+	version, err := LoadAggregate(es, "order-1", agg, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), version)
+	assert.Equal(t, float64(40), agg.Amount)
+	snap, _ := ms.LoadSnapshot("order-1")
+	assert.NotNil(t, snap)
+	assert.NotContains(t, string(snap.State), "Amount")
+	assert.Contains(t, string(snap.State), EncryptedKey)
+
+	agg = &orderAggregate{}
+	version, err = LoadAggregate(es, "order-1", agg, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), version)
+	assert.Equal(t, float64(40), agg.Amount)
+	assert.Equal(t, 0, agg.Applied)
+
+	// the projection read the decrypted journal
+	var total float64
+	pj := NewProjection("order-total", es.(Journal))
+	pj.On("order.*", ListenerFunc(func(e Event) error {
+		total += e.Get("amount").(float64)
+		return nil
+	}))
+	assert.NoError(t, pj.Run())
+	assert.Equal(t, float64(40), total)
+
+	// the wrapped store not support snapshot and journal
+	es = NewEncryption(p).EventStore(struct{ EventStore }{ms})
+	assert.Error(t, TakeSnapshot(es, "order-1", 4, agg))
+	_, err = es.(Journal).ReadAll(0, 10)
+	assert.Error(t, err)
+	snap, err = es.(SnapshotStore).LoadSnapshot("order-1")
+	assert.NoError(t, err)
+	assert.Nil(t, snap)
+}
+
+func TestManager_SetRedaction(t *testing.T) {
+	em := NewManager("test")
+	em.SetRedaction("user.*", Mask("password", "card.number")...)
+	em.SetRedaction("user.created", append(Drop("token"), Hash("email")...)...)
+
+	data := M{
+		"name":     "tom",
+		"password": "secret",
+		"token":    "abc",
+		"email":    "tom@example.com",
+		"card":     M{"number": "4242", "exp": "12/30"},
+	}
+	rd := em.RedactData("user.created", data)
+	assert.Equal(t, "tom", rd["name"])
+	assert.Equal(t, RedactMaskValue, rd["password"])
+	assert.NotContains(t, rd, "token")
+	assert.Len(t, rd["email"], 64)
+	assert.Equal(t, M{"number": RedactMaskValue, "exp": "12/30"}, rd["card"])
+	// the data is not changed
+	assert.Equal(t, "secret", data["password"])
+	assert.Equal(t, "4242", data["card"].(M)["number"])
+
+	// the listeners see the full data, the sinks see the redacted data
+	rec := em.Record("*")
+	ch, cancel := em.Tail("user.*")
+	defer cancel()
+	em.On("user.*", ListenerFunc(func(e Event) error {
+		assert.Equal(t, "secret", e.Get("password"))
+		return nil
+	}))
+	em.MustFire("user.updated", M{"password": "secret", "token": "abc"})
+
+	e := <-ch
+	assert.Equal(t, RedactMaskValue, e.Get("password"))
+	assert.Equal(t, "abc", e.Get("token"))
+	assert.Equal(t, RedactMaskValue, rec.Events()[0].Data["password"])
+
+	// no rules
+	pe := NewBasic("order.paid", M{"password": "x"})
+	assert.Equal(t, Event(pe), em.Redact(pe))
+	em.RemoveRedaction("user.*")
+	assert.Equal(t, "secret", em.Redact(NewBasic("user.updated", M{"password": "secret"})).Get("password"))
+}
+
+type closingListener struct {
+	name  string
+	calls *[]string
+	wait  time.Duration
+}
+
+func (l *closingListener) Handle(e Event) error {
+	return nil
+}
+
+func (l *closingListener) Drain(ctx context.Context) error {
+	select {
+	case <-time.After(l.wait):
+		*l.calls = append(*l.calls, "drain:"+l.name)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *closingListener) Close() error {
+	*l.calls = append(*l.calls, "close:"+l.name)
+	return nil
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	em := NewManager("test", WithCloseTimeout(50*time.Millisecond))
+
+	var calls []string
+	l1 := &closingListener{name: "l1", calls: &calls}
+	em.On("app.run", l1, High)
+	em.On("app.stop", l1)
+	em.On("app.run", &closingListener{name: "l2", calls: &calls})
+	em.On("app.run", &closingListener{name: "slow", calls: &calls, wait: time.Second}, Min)
+	em.On("app.run", ListenerFunc(emptyListener))
+
+	var done int32
+	em.Listen("app.run", ListenerFunc(func(e Event) error {
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&done, 1)
+		return nil
+	}), ListenOpts{Async: true})
+	em.MustFire("app.run", nil)
+
+	err := em.Shutdown(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'*event.closingListener'")
+	assert.True(t, errors.Is(err.(Errors)[0], context.DeadlineExceeded))
+	// wait the pending async listener
+	assert.Equal(t, int32(1), atomic.LoadInt32(&done))
+	// the reverse priority order, the l1 is closed once
+	assert.Equal(t, []string{"drain:l2", "close:l2", "drain:l1", "close:l1"}, calls)
+
+	assert.True(t, em.IsClosed())
+	err, _ = em.Fire("app.run", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, em.Shutdown(context.Background()))
+}
+
+type initListener struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (l *initListener) Handle(e Event) error {
+	return nil
+}
+
+func (l *initListener) Init(ctx context.Context, em *Manager) error {
+	*l.calls = append(*l.calls, l.name)
+	return l.err
+}
+
+func TestManager_Start(t *testing.T) {
+	em := NewManager("test", WithNoPanic(func(err error) {}))
+
+	var calls []string
+	l1 := &initListener{name: "l1", calls: &calls}
+	em.On("app.run", l1)
+	em.On("app.stop", l1)
+	em.On("app.run", &initListener{name: "l2", calls: &calls}, High)
+	em.On("app.run", &initListener{name: "bad", calls: &calls, err: errors.New("dial error")}, Min)
+	// not inited before started
+	assert.Len(t, calls, 0)
+	assert.False(t, em.IsStarted())
+
+	err := em.Start(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dial error")
+	assert.True(t, em.IsStarted())
+	assert.Equal(t, []string{"l2", "l1", "bad"}, calls)
+	assert.NoError(t, em.Start(context.Background()))
+
+	// init on registration after started
+	calls = nil
+	em.On("app.done", l1)
+	assert.NoError(t, em.TryOn("app.done", &initListener{name: "l3", calls: &calls}))
+	err = em.TryOn("app.done", &initListener{name: "bad2", calls: &calls, err: errors.New("fail")})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"l3", "bad2"}, calls)
+	assert.Len(t, em.ListenersByName("app.done").Items(), 2)
+}
+
+func TestManager_lifecycle(t *testing.T) {
+	em := NewManager("test")
+	assert.Equal(t, StateCreated, em.State())
+
+	var states []string
+	meta := em.Meta()
+	meta.On("manager.*", ListenerFunc(func(e Event) error {
+		states = append(states, fmt.Sprintf("%s:%s->%s", e.Name(), e.Get("from"), e.Get("to")))
+		return nil
+	}))
+
+	var calls int
+	em.On("app.run", ListenerFunc(func(e Event) error {
+		calls++
+		return nil
+	}))
+	// the created manager can fire
+	em.MustFire("app.run", nil)
+
+	ctx := context.Background()
+	assert.NoError(t, em.Start(ctx))
+	assert.Equal(t, StateRunning, em.State())
+	em.MustFire("app.run", nil)
+
+	assert.NoError(t, em.PauseAll())
+	assert.Equal(t, "paused", em.State().String())
+	em.MustFire("app.run", nil)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, uint64(1), em.Stats().Paused)
+	assert.NoError(t, em.Start(ctx))
+	assert.NoError(t, em.ResumeAll())
+	assert.True(t, errors.Is(em.ResumeAll(), ErrInvalidState))
+
+	assert.NoError(t, em.Stop(ctx))
+	assert.False(t, em.IsStarted())
+	err, _ := em.Fire("app.run", nil)
+	assert.Equal(t, ErrStopped, err)
+	assert.Equal(t, ErrStopped, em.TryOn("app.run", ListenerFunc(emptyListener)))
+	assert.True(t, errors.Is(em.PauseAll(), ErrInvalidState))
+
+	assert.NoError(t, em.Restart(ctx))
+	em.MustFire("app.run", nil)
+	assert.Equal(t, 3, calls)
+
+	assert.NoError(t, em.Shutdown(ctx))
+	assert.Equal(t, StateStopped, em.State())
+	assert.Equal(t, "State(9)", State(9).String())
+	// the closed manager cannot be restarted
+	assert.Equal(t, ErrClosed, em.Start(ctx))
+	assert.Equal(t, ErrClosed, em.Restart(ctx))
+	assert.Equal(t, StateStopped, em.State())
+	assert.Equal(t, []string{
+		"manager.started:created->running",
+		"manager.paused:running->paused",
+		"manager.resumed:paused->running",
+		"manager.stopped:running->stopped",
+		"manager.started:stopped->running",
+		"manager.stopped:running->stopped",
+	}, states)
+}
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"billing.*", "*ing.charged", true},
+		{"billing.*", "*.charged", true},
+		{"billing.*", "user.*", false},
+		{"billing.*", "billing", false},
+		{"a*b", "*c", false},
+		{"a*b", "*b", true},
+		{"*", "", true},
+		{"a.*.c", "*.b.*", true},
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"billing.*", "*.x.y", false},
+		{"a.*", "*", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, patternsOverlap(tt.a, tt.b), tt.a+" "+tt.b)
+		assert.Equal(t, tt.want, patternsOverlap(tt.b, tt.a), tt.b+" "+tt.a)
+	}
+}
+
+// fakeSQLResult the result of the fake SQL query
+type fakeSQLResult struct {
+	cols     []string
+	rows     [][]driver.Value
+	affected int64
+}
+
+// fakeSQL a fake database/sql driver, the queries are handled by the func.
+type fakeSQL struct {
+	mu      sync.Mutex
+	queries []string
+	handle  func(query string, args []driver.Value) (*fakeSQLResult, error)
+	// onTx called on the transaction commit or rollback. optional
+	onTx func(commit bool)
+}
+
+func openFakeSQL(handle func(query string, args []driver.Value) (*fakeSQLResult, error)) (*sql.DB, *fakeSQL) {
+	f := &fakeSQL{handle: handle}
+	return sql.OpenDB(f), f
+}
+
+func (f *fakeSQL) Connect(context.Context) (driver.Conn, error) { return f, nil }
+func (f *fakeSQL) Driver() driver.Driver                        { return f }
+func (f *fakeSQL) Open(string) (driver.Conn, error)             { return f, nil }
+func (f *fakeSQL) Close() error                                 { return nil }
+func (f *fakeSQL) Begin() (driver.Tx, error)                    { return f, nil }
+
+func (f *fakeSQL) Commit() error {
+	f.endTx(true)
+	return nil
+}
+
+func (f *fakeSQL) Rollback() error {
+	f.endTx(false)
+	return nil
+}
+
+func (f *fakeSQL) endTx(commit bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.onTx != nil {
+		f.onTx(commit)
+	}
+}
+
+func (f *fakeSQL) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{f: f, query: query}, nil
+}
+
+func (f *fakeSQL) query(query string, args []driver.Value) (*fakeSQLResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queries = append(f.queries, query)
+	res, err := f.handle(query, args)
+	if res == nil && err == nil {
+		res = &fakeSQLResult{}
+	}
+	return res, err
+}
+
+// Queries get the executed queries
+func (f *fakeSQL) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+type fakeSQLStmt struct {
+	f     *fakeSQL
+	query string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, err := s.f.query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(res.affected), nil
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	res, err := s.f.query(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeSQLRows{res: res}, nil
+}
+
+type fakeSQLRows struct {
+	res *fakeSQLResult
+	idx int
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.res.cols }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.res.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.res.rows[r.idx])
+	r.idx++
+	return nil
+}
+
+// fakeOutboxRow a row of the fake outbox table
+type fakeOutboxRow struct {
+	id, name, data string
+	published      time.Time
+}
+
+// fakeOutboxDB a fake outbox table
+func fakeOutboxDB(rows *[]*fakeOutboxRow) (*sql.DB, *fakeSQL) {
+	return openFakeSQL(func(query string, args []driver.Value) (*fakeSQLResult, error) {
+		switch {
+		case strings.HasPrefix(query, "INSERT INTO"):
+			*rows = append(*rows, &fakeOutboxRow{id: args[0].(string), name: args[1].(string), data: args[2].(string)})
+		case strings.HasPrefix(query, "SELECT"):
+			var limit int
+			_, _ = fmt.Sscanf(query[strings.Index(query, "LIMIT"):], "LIMIT %d", &limit)
+
+			res := &fakeSQLResult{cols: []string{"id", "name", "data"}}
+			for _, row := range *rows {
+				if row.published.IsZero() && len(res.rows) < limit {
+					res.rows = append(res.rows, []driver.Value{row.id, row.name, row.data})
+				}
+			}
+			return res, nil
+		case strings.HasPrefix(query, "UPDATE"):
+			for _, row := range *rows {
+				if row.id == args[1].(string) {
+					row.published = args[0].(time.Time)
+					return &fakeSQLResult{affected: 1}, nil
+				}
+			}
+		case strings.HasPrefix(query, "DELETE"):
+			var n int64
+			var keep []*fakeOutboxRow
+			for _, row := range *rows {
+				if !row.published.IsZero() && row.published.Before(args[0].(time.Time)) {
+					n++
+				} else {
+					keep = append(keep, row)
+				}
+			}
+			*rows = keep
+			return &fakeSQLResult{affected: n}, nil
+		}
+		return nil, nil
+	})
+}
+
+func TestOutbox(t *testing.T) {
+	var rows []*fakeOutboxRow
+	db, fs := fakeOutboxDB(&rows)
+	defer db.Close()
+
+	ob := NewOutbox(db, "")
+	assert.Equal(t, "event_outbox", ob.Table)
+	assert.NoError(t, ob.Init())
+	assert.Contains(t, fs.Queries()[0], "CREATE TABLE IF NOT EXISTS event_outbox")
+
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	for _, name := range []string{"order.created", "order.paid", "order.shipped"} {
+		assert.NoError(t, ob.Write(tx, NewBasic(name, M{"id": 23})))
+	}
+	assert.NoError(t, tx.Commit())
+	assert.Len(t, rows, 3)
+	assert.Equal(t, `{"id":23}`, rows[0].data)
+
+	// stop on the first error for keep the order
+	var published []string
+	failed := map[string]bool{"order.paid": true}
+	relay := NewOutboxRelay(ob, func(e Event) error {
+		if failed[e.Name()] {
+			delete(failed, e.Name())
+			return errors.New("broker is down")
+		}
+		published = append(published, e.Name())
+		assert.Equal(t, float64(23), e.Get("id"))
+		return nil
+	})
+
+	n, err := relay.RelayOnce()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "order.paid")
+	assert.Equal(t, 1, n)
+	assert.False(t, rows[0].published.IsZero())
+	assert.True(t, rows[1].published.IsZero())
+	assert.True(t, rows[2].published.IsZero())
+
+	// retry
+	n, err = relay.RelayOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"order.created", "order.paid", "order.shipped"}, published)
+	for _, row := range rows {
+		assert.False(t, row.published.IsZero())
+	}
+
+	// nothing to relay
+	n, err = relay.RelayOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// the batch size
+	tx, _ = db.Begin()
+	assert.NoError(t, ob.Write(tx, NewBasic("order.closed", M{"id": 23})))
+	assert.NoError(t, ob.Write(tx, NewBasic("order.archived", M{"id": 23})))
+	assert.NoError(t, tx.Commit())
+	relay.BatchSize = 1
+	n, err = relay.RelayOnce()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "order.closed", published[3])
+
+	// purge the published records
+	num, err := ob.Purge(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), num)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "order.archived", rows[0].name)
+}
+
+func TestOutboxRelay_Start(t *testing.T) {
+	var rows []*fakeOutboxRow
+	db, fs := fakeOutboxDB(&rows)
+	defer db.Close()
+
+	ob := NewOutbox(db, "outbox")
+	ob.Placeholder = DollarPlaceholder
+	tx, _ := db.Begin()
+	assert.NoError(t, ob.Write(tx, NewBasic("order.created", nil)))
+	assert.NoError(t, tx.Commit())
+	assert.Contains(t, fs.Queries()[0], "VALUES ($1, $2, $3, $4)")
+
+	var mu sync.Mutex
+	var calls int
+	errs := make(chan error, 1)
+	relay := NewOutboxRelay(ob, func(e Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 1 {
+			return errors.New("broker is down")
+		}
+		return nil
+	})
+	relay.Interval = time.Millisecond
+	relay.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	relay.Start()
+	relay.Start()
+	assert.Error(t, <-errs)
+	for {
+		mu.Lock()
+		n := calls
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	relay.Stop()
+	relay.Stop()
+
+	fs.mu.Lock()
+	assert.False(t, rows[0].published.IsZero())
+	fs.mu.Unlock()
+}
+
+// fakeEventRow a row of the fake events table
+type fakeEventRow struct {
+	seq, version       int64
+	stream, name, data string
+	created            time.Time
+}
+
+// fakeEventsTable the fake events and snapshots table of the SQLStore
+type fakeEventsTable struct {
+	rows, staged []*fakeEventRow
+	snaps        map[string][]driver.Value
+	// beforeInsert called before insert an event row. optional
+	beforeInsert func(t *fakeEventsTable)
+}
+
+func (t *fakeEventsTable) all() []*fakeEventRow {
+	return append(append([]*fakeEventRow(nil), t.rows...), t.staged...)
+}
+
+func (t *fakeEventsTable) maxOf(stream string) (version, seq interface{}) {
+	for _, row := range t.all() {
+		if stream == "" || row.stream == stream {
+			if version == nil || row.version > version.(int64) {
+				version = row.version
+			}
+		}
+		if seq == nil || row.seq > seq.(int64) {
+			seq = row.seq
+		}
+	}
+	return
+}
+
+func (t *fakeEventsTable) insert(row *fakeEventRow) error {
+	if t.beforeInsert != nil {
+		t.beforeInsert(t)
+	}
+
+	for _, r := range t.all() {
+		if r.seq == row.seq || (r.stream == row.stream && r.version == row.version) {
+			return errors.New("duplicate key")
+		}
+	}
+	t.staged = append(t.staged, row)
+	return nil
+}
+
+func (t *fakeEventsTable) result(rows []*fakeEventRow) *fakeSQLResult {
+	res := &fakeSQLResult{cols: []string{"seq", "stream", "version", "name", "data", "created_at"}}
+	for _, row := range rows {
+		res.rows = append(res.rows, []driver.Value{row.seq, row.stream, row.version, row.name, row.data, row.created})
+	}
+	return res
+}
+
+func (t *fakeEventsTable) handle(query string, args []driver.Value) (*fakeSQLResult, error) {
+	switch {
+	case strings.HasPrefix(query, "CREATE"):
+	case strings.HasPrefix(query, "SELECT MAX(version)"):
+		version, _ := t.maxOf(args[0].(string))
+		return &fakeSQLResult{cols: []string{"max"}, rows: [][]driver.Value{{version}}}, nil
+	case strings.HasPrefix(query, "SELECT MAX(seq)"):
+		_, seq := t.maxOf("")
+		return &fakeSQLResult{cols: []string{"max"}, rows: [][]driver.Value{{seq}}}, nil
+	case strings.Contains(query, "_snapshots"):
+		return t.handleSnapshot(query, args)
+	case strings.HasPrefix(query, "INSERT INTO"):
+		row := &fakeEventRow{seq: args[0].(int64), stream: args[1].(string), version: args[2].(int64)}
+		row.name, row.data, row.created = args[3].(string), args[4].(string), args[5].(time.Time)
+		return nil, t.insert(row)
+	case strings.Contains(query, "WHERE stream"):
+		var rows []*fakeEventRow
+		for _, row := range t.rows {
+			if row.stream == args[0].(string) && row.version >= args[1].(int64) {
+				rows = append(rows, row)
+			}
+		}
+		return t.result(rows), nil
+	case strings.Contains(query, "WHERE seq"):
+		var limit int
+		_, _ = fmt.Sscanf(query[strings.Index(query, "LIMIT"):], "LIMIT %d", &limit)
+
+		var rows []*fakeEventRow
+		for _, row := range t.rows {
+			if row.seq > args[0].(int64) {
+				rows = append(rows, row)
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i].seq < rows[j].seq
+		})
+		if len(rows) > limit {
+			rows = rows[:limit]
+		}
+		return t.result(rows), nil
+	default:
+		return nil, fmt.Errorf("unknown query: %s", query)
+	}
+	return nil, nil
+}
+
+func (t *fakeEventsTable) handleSnapshot(query string, args []driver.Value) (*fakeSQLResult, error) {
+	switch {
+	case strings.HasPrefix(query, "DELETE"):
+		delete(t.snaps, args[0].(string))
+	case strings.HasPrefix(query, "INSERT"):
+		t.snaps[args[0].(string)] = args
+	case strings.HasPrefix(query, "SELECT"):
+		res := &fakeSQLResult{cols: []string{"stream", "version", "state", "created_at"}}
+		if row, ok := t.snaps[args[0].(string)]; ok {
+			res.rows = append(res.rows, row)
+		}
+		return res, nil
+	}
+	return nil, nil
+}
+
+// fakeEventsDB open a fake db for the SQLStore
+func fakeEventsDB() (*sql.DB, *fakeSQL, *fakeEventsTable) {
+	t := &fakeEventsTable{snaps: make(map[string][]driver.Value)}
+	db, fs := openFakeSQL(t.handle)
+	fs.onTx = func(commit bool) {
+		if commit {
+			t.rows = append(t.rows, t.staged...)
+		}
+		t.staged = nil
+	}
+	return db, fs, t
+}
+
+func TestSQLStore_ReadAll(t *testing.T) {
+	db, fs, table := fakeEventsDB()
+	defer db.Close()
+
+	store := NewSQLStore(db, "")
+	assert.NoError(t, store.Init())
+	assert.Contains(t, fs.Queries()[0], "seq BIGINT NOT NULL UNIQUE")
+
+	// the events has same created time
+	now := time.Now()
+	for i, stream := range []string{"order-2", "order-1", "order-2", "order-1", "order-3"} {
+		se := &StoredEvent{Name: "order.paid", Data: M{"i": i}, Time: now}
+		assert.NoError(t, store.Append(stream, se))
+		assert.Equal(t, uint64(i+1), se.Position)
+	}
+
+	var ses []*StoredEvent
+	var pos uint64
+	for {
+		list, err := store.ReadAll(pos, 2)
+		assert.NoError(t, err)
+		if len(list) == 0 {
+			break
+		}
+		ses = append(ses, list...)
+		pos = list[len(list)-1].Position
+	}
+
+	assert.Len(t, ses, 5)
+	for i, se := range ses {
+		assert.Equal(t, uint64(i+1), se.Position)
+		assert.Equal(t, float64(i), se.Data["i"])
+	}
+	assert.Equal(t, "order-2", ses[2].Stream)
+	assert.Equal(t, uint64(2), ses[2].Version)
+	assert.Contains(t, fs.Queries()[len(fs.Queries())-1], "WHERE seq > ? ORDER BY seq LIMIT 2")
+
+	// the new events after the position
+	assert.NoError(t, store.Append("order-1", &StoredEvent{Name: "order.closed", Time: now}))
+	list, err := store.ReadAll(pos, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, uint64(6), list[0].Position)
+	assert.Equal(t, "order.closed", list[0].Name)
+	assert.Len(t, table.rows, 6)
+}
+
+func TestSQLStore(t *testing.T) {
+	db, _, table := fakeEventsDB()
+	defer db.Close()
+
+	store := NewSQLStore(db, "events")
+	assert.NoError(t, store.Init())
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, store.Append("order-1", &StoredEvent{Name: "order.paid", Data: M{"amount": i}}))
+	}
+	assert.NoError(t, store.Append("order-2", &StoredEvent{Name: "order.created"}, &StoredEvent{Name: "order.paid"}))
+
+	ses, err := store.Load("order-1")
+	assert.NoError(t, err)
+	assert.Len(t, ses, 3)
+	assert.Equal(t, uint64(3), ses[2].Version)
+	assert.Equal(t, float64(3), ses[2].Data["amount"])
+	assert.False(t, ses[2].Time.IsZero())
+
+	ses, err = store.LoadFrom("order-1", 2)
+	assert.NoError(t, err)
+	assert.Len(t, ses, 2)
+	assert.Equal(t, uint64(2), ses[0].Version)
+	ses, _ = store.LoadFrom("order-2", 1)
+	assert.Len(t, ses, 2)
+	assert.Equal(t, uint64(5), ses[1].Position)
+
+	// the concurrent append with the same version, will be retried
+	var once sync.Once
+	table.beforeInsert = func(t *fakeEventsTable) {
+		once.Do(func() {
+			t.rows = append(t.rows, &fakeEventRow{seq: 6, stream: "order-1", version: 4, name: "order.shipped", data: "{}"})
+		})
+	}
+	se := &StoredEvent{Name: "order.closed"}
+	assert.NoError(t, store.Append("order-1", se, &StoredEvent{Name: "order.archived"}))
+	assert.Equal(t, uint64(5), se.Version)
+	assert.Equal(t, uint64(7), se.Position)
+	ses, _ = store.Load("order-1")
+	assert.Len(t, ses, 6)
+
+	// the conflict exceeds the retries, the event fields are not changed
+	seq := int64(8)
+	table.beforeInsert = func(t *fakeEventsTable) {
+		seq++
+		t.rows = append(t.rows, &fakeEventRow{seq: seq, stream: "order-3", version: seq, name: "order.paid", data: "{}"})
+	}
+	se2 := &StoredEvent{Name: "order.closed"}
+	assert.Error(t, store.Append("order-2", se2))
+	assert.Empty(t, table.staged)
+	assert.Equal(t, int64(12), seq)
+	assert.Equal(t, uint64(0), se2.Version)
+	assert.Equal(t, uint64(0), se2.Position)
+	assert.True(t, se2.Time.IsZero())
+	table.beforeInsert = nil
+
+	// snapshot
+	snap, err := store.LoadSnapshot("order-1")
+	assert.NoError(t, err)
+	assert.Nil(t, snap)
+	assert.NoError(t, store.SaveSnapshot(&Snapshot{Stream: "order-1", Version: 3, State: []byte(`{"Amount":6}`)}))
+	assert.NoError(t, store.SaveSnapshot(&Snapshot{Stream: "order-1", Version: 4, State: []byte(`{"Amount":8}`)}))
+	snap, err = store.LoadSnapshot("order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), snap.Version)
+	assert.Equal(t, `{"Amount":8}`, string(snap.State))
+	assert.False(t, snap.Time.IsZero())
+	assert.Len(t, table.snaps, 1)
+
+	agg := &orderAggregate{}
+	version, err := LoadAggregate(store, "order-1", agg, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), version)
+	assert.Equal(t, 2, agg.Applied)
+}
+
+func TestSQLStore_compress(t *testing.T) {
+	db, _, table := fakeEventsDB()
+	defer db.Close()
+
+	store := NewSQLStore(db, "events")
+	store.Compressor = GzipCompressor{}
+	store.CompressThreshold = 100
+
+	body := strings.Repeat("x", 200)
+	assert.NoError(t, store.Append("doc-1", &StoredEvent{Name: "doc.small", Data: M{"body": "x"}}))
+	assert.NoError(t, store.Append("doc-1", &StoredEvent{Name: "doc.large", Data: M{"body": body}}))
+	assert.Equal(t, `{"body":"x"}`, table.rows[0].data)
+	assert.True(t, strings.HasPrefix(table.rows[1].data, "z:"))
+	assert.True(t, len(table.rows[1].data) < len(body))
+
+	ses, err := store.Load("doc-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", ses[0].Data["body"])
+	assert.Equal(t, body, ses[1].Data["body"])
+
+	ses, err = store.ReadAll(1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, ses, 1)
+	assert.Equal(t, body, ses[0].Data["body"])
+
+	// the store without compressor can read the compressed data
+	ses, err = NewSQLStore(db, "events").Load("doc-1")
+	assert.NoError(t, err)
+	assert.Equal(t, body, ses[1].Data["body"])
+}
@@ -0,0 +1,234 @@
+package amqp
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChannel struct {
+	mu        sync.Mutex
+	fails     int
+	closed    bool
+	published []*Message
+	ds        chan *Delivery
+}
+
+func (c *testChannel) Publish(exchange string, msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fails > 0 {
+		c.fails--
+		return errors.New("nacked")
+	}
+
+	c.published = append(c.published, msg)
+	return nil
+}
+
+func (c *testChannel) Consume(queue string) (<-chan *Delivery, error) {
+	return c.ds, nil
+}
+
+func (c *testChannel) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+type testBroker struct {
+	mu    sync.Mutex
+	dials int
+	chs   []*testChannel
+}
+
+func (tb *testBroker) dial() (Channel, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	ch := &testChannel{ds: make(chan *Delivery, 4)}
+	if tb.dials == 0 {
+		ch.fails = 1
+	}
+	tb.dials++
+	tb.chs = append(tb.chs, ch)
+	return ch, nil
+}
+
+func (tb *testBroker) channel(i int) *testChannel {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.chs[i]
+}
+
+func TestBridge_Publish(t *testing.T) {
+	em := event.NewManager("test")
+	tb := &testBroker{}
+
+	b := New(em, "events", tb.dial)
+	b.RetryWait = time.Millisecond
+	b.Publish("order.*")
+	assert.Equal(t, "events", b.Exchange())
+
+	// the first channel nack the message, will reconnect and retry
+	em.MustFire("order.created", event.M{"id": 1})
+	assert.Equal(t, 2, tb.dials)
+	assert.True(t, tb.channel(0).closed)
+	assert.Len(t, tb.channel(1).published, 1)
+	assert.Equal(t, "order.created", tb.channel(1).published[0].RoutingKey)
+
+	// over the max retries
+	tb.channel(1).fails = 10
+	b.Retries = 0
+	err := em.FireEvent(event.NewBasic("order.paid", nil))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "nacked")
+
+	assert.NoError(t, b.Close())
+	em.MustFire("order.created", nil)
+	assert.Equal(t, 2, tb.dials)
+}
+
+func TestBridge_Consume(t *testing.T) {
+	em := event.NewManager("test")
+	tb := &testBroker{dials: 1}
+
+	b := New(em, "events", tb.dial)
+	b.RetryWait = time.Millisecond
+	b.Publish("order.*")
+
+	got := make(chan event.Event, 4)
+	em.On("order.*", event.ListenerFunc(func(e event.Event) error {
+		got <- e
+		if e.Name() == "order.bad" {
+			return errors.New("bad order")
+		}
+		return nil
+	}))
+
+	var errs []error
+	var mu sync.Mutex
+	b.OnError = func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	assert.NoError(t, b.Consume("billing"))
+
+	acked, nacked := make(chan bool, 4), make(chan bool, 4)
+	newDelivery := func(key, body string) *Delivery {
+		return &Delivery{
+			Message: Message{RoutingKey: key, Body: []byte(body)},
+			Ack: func() error {
+				acked <- true
+				return nil
+			},
+			Nack: func(requeue bool) error {
+				nacked <- requeue
+				return nil
+			},
+		}
+	}
+
+	tb.channel(0).ds <- newDelivery("order.created", `{"name":"order.created","data":{"id":1}}`)
+	e := <-got
+	assert.Equal(t, "order.created", e.Name())
+	assert.Equal(t, "billing", e.Get(OriginKey))
+	assert.True(t, <-acked)
+
+	tb.channel(0).ds <- newDelivery("order.bad", "raw")
+	e = <-got
+	assert.Equal(t, "raw", e.Get("payload"))
+	assert.False(t, <-nacked)
+
+	// the consumed events are not published again
+	assert.Len(t, tb.channel(0).published, 0)
+
+	// reconnect on the deliveries closed
+	close(tb.channel(0).ds)
+	for {
+		tb.mu.Lock()
+		n := len(tb.chs)
+		tb.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	tb.channel(1).ds <- newDelivery("order.paid", `{"name":"order.paid"}`)
+	assert.Equal(t, "order.paid", (<-got).Name())
+	<-acked
+
+	assert.NoError(t, b.Close())
+	assert.True(t, tb.channel(1).closed)
+	assert.Equal(t, ErrClosed, b.Consume("billing"))
+
+	mu.Lock()
+	assert.Len(t, errs, 1)
+	mu.Unlock()
+}
+
+func TestBridge_dedupe(t *testing.T) {
+	em := event.NewManager("test")
+	tb := &testBroker{dials: 1}
+
+	pub := New(em, "events", tb.dial)
+	pub.StampKeys = true
+	pub.Publish("order.*")
+	em.MustFire("order.created", event.M{"id": 1})
+	assert.NoError(t, pub.Close())
+
+	msg := tb.channel(0).published[0]
+	assert.Contains(t, string(msg.Body), event.IdempotencyKey)
+
+	// the redelivered message is handled once
+	em2 := event.NewManager("test", event.WithIdempotency(event.IdempotencyConfig{
+		Store: event.NewLRUDedupeStore(100),
+	}))
+	var calls int
+	em2.On("order.*", event.ListenerFunc(func(e event.Event) error {
+		calls++
+		return nil
+	}))
+
+	var acks int
+	sub := New(em2, "events", tb.dial)
+	for i := 0; i < 2; i++ {
+		err := sub.Handle("billing", &Delivery{Message: *msg, Ack: func() error {
+			acks++
+			return nil
+		}})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, acks)
+	assert.Equal(t, uint64(1), em2.Stats().Duplicates)
+}
+
+func TestBridge_Close_multi(t *testing.T) {
+	em := event.NewManager("test")
+	tb1, tb2 := &testBroker{dials: 1}, &testBroker{dials: 1}
+
+	b1, b2 := New(em, "events", tb1.dial), New(em, "audit", tb2.dial)
+	b1.Publish("order.*")
+	b2.Publish("order.*")
+
+	em.MustFire("order.created", nil)
+	assert.Len(t, tb1.channel(0).published, 1)
+	assert.Len(t, tb2.channel(0).published, 1)
+
+	// close b1 will not remove the publisher of b2
+	assert.NoError(t, b1.Close())
+	em.MustFire("order.created", nil)
+	assert.Len(t, tb2.channel(0).published, 2)
+	assert.NoError(t, b2.Close())
+	assert.Panics(t, func() {
+		b1.Publish("order.*")
+	})
+}
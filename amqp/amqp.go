@@ -0,0 +1,322 @@
+// Package amqp bridge the events between the event manager and AMQP brokers. eg: RabbitMQ
+//
+// the package has no client dependency, the Channel can be wrapped from the
+// amqp091-go channel. the events are published to the exchange, use the event
+// name as the routing key. so the listen pattern "app.*" can be used as the
+// binding key of the topic exchange.
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// OriginKey the event data key for mark the event is consumed from AMQP.
+// the events has the key will not be published again, for avoid loop.
+const OriginKey = "__amqp_queue"
+
+// ErrClosed the bridge has been closed
+var ErrClosed = errors.New("amqp: the bridge is closed")
+
+// Message the AMQP message for publish
+type Message struct {
+	RoutingKey  string
+	ContentType string
+	Body        []byte
+}
+
+// Delivery the AMQP message consumed from queue
+type Delivery struct {
+	Message
+	Ack  func() error
+	Nack func(requeue bool) error
+}
+
+// Channel the AMQP channel interface.
+//
+// NOTICE: the Publish should wait the publisher confirm, and return error
+// if the message is nacked by the broker.
+//
+// Usage by amqp091-go:
+// 	func (c *channel) Publish(exchange string, msg *amqp.Message) error {
+// 		dc, err := c.Channel.PublishWithDeferredConfirm(exchange, msg.RoutingKey, true, false, amqp091.Publishing{
+// 			ContentType: msg.ContentType,
+// 			Body:        msg.Body,
+// 		})
+// 		if err != nil {
+// 			return err
+// 		}
+// 		if !dc.Wait() {
+// 			return errors.New("the message is nacked")
+// 		}
+// 		return nil
+// 	}
+type Channel interface {
+	Publish(exchange string, msg *Message) error
+	Consume(queue string) (<-chan *Delivery, error)
+	Close() error
+}
+
+// Dialer func for create a new channel, it will be called on reconnect
+type Dialer func() (Channel, error)
+
+// Bridge the events between the Manager and AMQP broker
+//
+// Usage:
+// 	b := amqp.New(em, "events", dialer)
+// 	// publish local "order.*" events to the exchange "events"
+// 	b.Publish("order.*")
+// 	// fire the events consumed from the queue
+// 	err := b.Consume("billing")
+//
+// 	defer b.Close()
+type Bridge struct {
+	mu       sync.Mutex
+	em       *event.Manager
+	dial     Dialer
+	ch       Channel
+	exchange string
+	// Codec for encode and decode events. default is event.DefaultCodec
+	Codec event.Codec
+	// Retries the max retry times on publish failed. will reconnect before retry.
+	Retries int
+	// RetryWait the wait time before reconnect. default is 1s
+	RetryWait time.Duration
+	// Requeue the delivery on the listeners return error
+	Requeue bool
+	// StampKeys set the idempotency key to the published events without key, so the consumers
+	// can dedupe the redelivered messages by the event.WithIdempotency()
+	StampKeys bool
+	// OnError callback on the consume error. optional
+	OnError func(err error)
+	// scope of the publish listeners
+	scope  *event.Scope
+	wg     sync.WaitGroup
+	stop   chan struct{}
+	closed bool
+}
+
+// New create a AMQP bridge
+func New(em *event.Manager, exchange string, dial Dialer) *Bridge {
+	return &Bridge{
+		em:        em,
+		dial:      dial,
+		exchange:  exchange,
+		scope:     em.NewScope(),
+		stop:      make(chan struct{}),
+		Codec:     event.DefaultCodec,
+		Retries:   3,
+		RetryWait: time.Second,
+	}
+}
+
+// Exchange name
+func (b *Bridge) Exchange() string {
+	return b.exchange
+}
+
+// channel get the current channel, will dial new channel if not connected.
+func (b *Bridge) channel() (Channel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	if b.ch == nil {
+		ch, err := b.dial()
+		if err != nil {
+			return nil, err
+		}
+		b.ch = ch
+	}
+	return b.ch, nil
+}
+
+// reset the broken channel, the next call will reconnect.
+func (b *Bridge) reset(ch Channel) {
+	b.mu.Lock()
+	if b.ch == ch {
+		b.ch = nil
+		_ = ch.Close()
+	}
+	b.mu.Unlock()
+}
+
+// wait before reconnect. return false if the bridge is closed.
+func (b *Bridge) wait() bool {
+	select {
+	case <-b.stop:
+		return false
+	case <-time.After(b.RetryWait):
+		return true
+	}
+}
+
+// Publish the local events matched the patterns to the exchange.
+// the events consumed from AMQP will not be published.
+// NOTICE: will panic event.ErrClosed if the bridge is closed.
+func (b *Bridge) Publish(patterns ...string) {
+	for _, pattern := range patterns {
+		b.scope.On(pattern, event.ListenerFunc(b.publish))
+	}
+}
+
+func (b *Bridge) publish(e event.Event) error {
+	if e.Get(OriginKey) != nil {
+		return nil
+	}
+
+	if b.StampKeys {
+		event.StampKey(e)
+	}
+
+	bs, err := b.Codec.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	msg := &Message{RoutingKey: e.Name(), ContentType: "application/json", Body: bs}
+	for i := 0; ; i++ {
+		var ch Channel
+		if ch, err = b.channel(); err == nil {
+			if err = ch.Publish(b.exchange, msg); err == nil {
+				return nil
+			}
+			b.reset(ch)
+		}
+
+		if err == ErrClosed || i >= b.Retries || !b.wait() {
+			break
+		}
+	}
+	return fmt.Errorf("amqp: publish the event '%s' error: %v", e.Name(), err)
+}
+
+// Consume the queue and fire the events in background, will reconnect
+// if the channel is broken. returns error if the first consume failed.
+func (b *Bridge) Consume(queue string) error {
+	ch, err := b.channel()
+	if err != nil {
+		return err
+	}
+
+	ds, err := ch.Consume(queue)
+	if err != nil {
+		b.reset(ch)
+		return fmt.Errorf("amqp: consume the queue '%s' error: %v", queue, err)
+	}
+
+	b.wg.Add(1)
+	go b.consume(queue, ch, ds)
+	return nil
+}
+
+func (b *Bridge) consume(queue string, ch Channel, ds <-chan *Delivery) {
+	defer b.wg.Done()
+
+	for {
+		b.deliver(queue, ds)
+		b.reset(ch)
+
+		// reconnect
+		var err error
+		for {
+			if !b.wait() {
+				return
+			}
+
+			if ch, err = b.channel(); err == nil {
+				if ds, err = ch.Consume(queue); err == nil {
+					break
+				}
+				b.reset(ch)
+			}
+
+			if err == ErrClosed {
+				return
+			}
+			b.onError(fmt.Errorf("amqp: reconnect the queue '%s' error: %v", queue, err))
+		}
+	}
+}
+
+// deliver the messages until the deliveries closed or the bridge stopped.
+func (b *Bridge) deliver(queue string, ds <-chan *Delivery) {
+	for {
+		select {
+		case <-b.stop:
+			return
+		case d, ok := <-ds:
+			if !ok {
+				return
+			}
+
+			if err := b.Handle(queue, d); err != nil {
+				b.onError(err)
+			}
+		}
+	}
+}
+
+func (b *Bridge) onError(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// Handle the delivery, fire the decoded event. the delivery will be acked
+// if the listeners are successful, otherwise it will be nacked.
+//
+// if decode failed, the event name is the routing key and the body will be
+// set to the event data "payload".
+func (b *Bridge) Handle(queue string, d *Delivery) error {
+	e, err := b.Codec.Decode(d.Body)
+	if err != nil {
+		e = event.NewBasic(d.RoutingKey, event.M{"payload": string(d.Body)})
+	}
+
+	e.Set(OriginKey, queue)
+	if err = b.em.FireEvent(e); err != nil {
+		if d.Nack != nil {
+			_ = d.Nack(b.Requeue)
+		}
+		return err
+	}
+
+	if d.Ack != nil {
+		return d.Ack()
+	}
+	return nil
+}
+
+// Close stop the consumers and publish events, then close the channel
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+
+	b.closed = true
+	b.mu.Unlock()
+
+	b.scope.Close()
+
+	close(b.stop)
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ch != nil {
+		err := b.ch.Close()
+		b.ch = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// There are audit actions
+const (
+	AuditListen = "listen"
+	AuditRemove = "remove"
+	AuditFire   = "fire"
+	AuditHandle = "handle"
+)
+
+// AuditRecord a record of the audit log
+type AuditRecord struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Event  string    `json:"event"`
+	// Label the listener name
+	Label string `json:"label,omitempty"`
+	// Caller the code position of the action. format: "file:line"
+	Caller string `json:"caller,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AuditLog an audit trail of registrations, removals and fires.
+//
+// Usage:
+// 	audit := NewAuditLog(1000)
+// 	em := NewManager("app", WithAuditLog(audit))
+// 	...
+// 	audit.WriteJSONLines(os.Stdout)
+type AuditLog struct {
+	mu      sync.RWMutex
+	records []*AuditRecord
+	// max records to keep. 0 is unlimited
+	max int
+	// stream records to the writer. optional
+	out io.Writer
+}
+
+// NewAuditLog create new audit log. max is the max records to keep, 0 is unlimited.
+func NewAuditLog(max int) *AuditLog {
+	return &AuditLog{max: max}
+}
+
+// WithAuditLog enable audit log for the manager
+func WithAuditLog(audit *AuditLog) Option {
+	return func(o *Options) {
+		o.AuditLog = audit
+	}
+}
+
+// StreamTo write each record as JSON line to the writer on recorded.
+func (a *AuditLog) StreamTo(w io.Writer) *AuditLog {
+	a.mu.Lock()
+	a.out = w
+	a.mu.Unlock()
+	return a
+}
+
+// Record add a record to the audit log
+func (a *AuditLog) Record(r *AuditRecord) {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.records = append(a.records, r)
+	if a.max > 0 && len(a.records) > a.max {
+		a.records = a.records[len(a.records)-a.max:]
+	}
+
+	if a.out != nil {
+		_ = json.NewEncoder(a.out).Encode(r)
+	}
+}
+
+// Records get all records
+func (a *AuditLog) Records() []*AuditRecord {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rs := make([]*AuditRecord, len(a.records))
+	copy(rs, a.records)
+	return rs
+}
+
+// Filter get records matched the action and event name pattern.
+// empty action for match all actions.
+func (a *AuditLog) Filter(action, pattern string) []*AuditRecord {
+	var rs []*AuditRecord
+	for _, r := range a.Records() {
+		if (action == "" || r.Action == action) && MatchName(pattern, r.Event) {
+			rs = append(rs, r)
+		}
+	}
+	return rs
+}
+
+// WriteJSONLines export all records as JSON lines
+func (a *AuditLog) WriteJSONLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, r := range a.Records() {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clear all records
+func (a *AuditLog) Reset() {
+	a.mu.Lock()
+	a.records = nil
+	a.mu.Unlock()
+}
+
+// audit record an action if the audit log enabled.
+// NOTICE: should not call it with lock, the record maybe write to the stream.
+func (em *Manager) audit(action, name, label string, err error) {
+	if em.opts.AuditLog == nil {
+		return
+	}
+
+	r := &AuditRecord{Action: action, Event: name, Label: label}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	// the handle action is called by the manager
+	if action != AuditHandle {
+		r.Caller = callerPos()
+	}
+	em.opts.AuditLog.Record(r)
+}
+
+// Name get the listener name. it's the Label, or the listener func/type name.
+func (li *ListenerItem) Name() string {
+	if li.Label != "" {
+		return li.Label
+	}
+	return listenerName(li.Listener)
+}
+
+// listenerName get the listener func name or type name
+func listenerName(l Listener) string {
+	if fn, ok := l.(ListenerFunc); ok {
+		return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	}
+	return fmt.Sprintf("%T", l)
+}
+
+// the dir of the package, used for skip the package frames.
+var pkgDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// callerPos find the first caller position outside the package
+func callerPos() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if filepath.Dir(fr.File) != pkgDir || strings.HasSuffix(fr.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", fr.File, fr.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package event
+
+import "sort"
+
+// SetBarriers split the listeners of the event name to priority bands by the priorities.
+// all listeners in a band must be finished (including the async listeners) before the next band starts.
+// call it without priorities will remove the barriers.
+//
+// Usage:
+// 	// bands: validators(>= High) -> mutators(>= Normal) -> notifiers(< Normal)
+// 	em.SetBarriers("order.created", High, Normal)
+// 	em.Listen("order.created", validator, ListenOpts{Priority: High, Async: true})
+// 	em.Listen("order.created", mutator, ListenOpts{Priority: Normal})
+// 	em.Listen("order.created", notifier, ListenOpts{Priority: Low, Async: true})
+func (em *Manager) SetBarriers(name string, priorities ...int) {
+	em.mustNotSealed()
+	name = em.goodName(name)
+
+	em.lock()
+	defer em.unlock()
+
+	if len(priorities) == 0 {
+		delete(em.barriers, name)
+		return
+	}
+
+	ps := append([]int(nil), priorities...)
+	sort.Sort(sort.Reverse(sort.IntSlice(ps)))
+	em.barriers[name] = ps
+}
+
+// Barriers get the band barriers of the event name, is sorted by desc.
+func (em *Manager) Barriers(name string) []int {
+	em.rLock()
+	defer em.rUnlock()
+	return em.barriers[em.normalize(name)]
+}
+
+// band get the band index of the priority
+func (dc *DispatchContext) band(priority int) (idx int) {
+	for _, b := range dc.barriers {
+		if priority >= b {
+			break
+		}
+		idx++
+	}
+	return
+}
+
+// enterBand wait the listeners of the previous band are finished, if the priority is in a new band.
+func (dc *DispatchContext) enterBand(priority int) {
+	if len(dc.barriers) == 0 {
+		return
+	}
+
+	idx := dc.band(priority)
+	if dc.inBand && idx != dc.curBand {
+		dc.pending.Wait()
+	}
+	dc.curBand, dc.inBand = idx, true
+}
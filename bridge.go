@@ -0,0 +1,98 @@
+package event
+
+// Bridge forward events matched the pattern from a Manager to another Manager.
+//
+// Usage:
+// 	// forward all "lib.*" events to the app manager
+// 	b := NewBridge(libEM, appEM, "lib.*")
+// 	// with rename
+// 	b := NewBridge(libEM, appEM, "lib.*").Rename(func(name string) string {
+// 		return "app." + name
+// 	})
+// 	// only forward the matched events
+// 	b.Filter(MustCompileExpr(`data.level == "error"`).Match)
+// 	// stop forward
+// 	b.Close()
+type Bridge struct {
+	from *Manager
+	to   *Manager
+	// the listened pattern on the from manager. eg: "app.*", "*"
+	pattern string
+	rename  func(name string) string
+	filter  func(e Event) bool
+}
+
+// NewBridge create and start a bridge for forward events from -> to
+func NewBridge(from, to *Manager, pattern string, priority ...int) *Bridge {
+	if from == nil || to == nil || from == to {
+		panic("event: the bridge managers cannot be empty or same")
+	}
+
+	b := &Bridge{from: from, to: to, pattern: pattern}
+	from.On(pattern, b, priority...)
+	return b
+}
+
+// Rename setting the func for rename the event name on forward
+func (b *Bridge) Rename(fn func(name string) string) *Bridge {
+	b.rename = fn
+	return b
+}
+
+// Filter setting the func for filter the forwarded events
+func (b *Bridge) Filter(fn func(e Event) bool) *Bridge {
+	b.filter = fn
+	return b
+}
+
+// Pattern get the listened pattern
+func (b *Bridge) Pattern() string {
+	return b.pattern
+}
+
+// Handle forward the event. implements the Listener interface
+func (b *Bridge) Handle(e Event) error {
+	return b.HandleContext(newDispatchContext(e, PolicyStop))
+}
+
+// HandleContext forward the event. implements the ContextListener interface.
+// the managers the event has passed through are carried by the ctx, for the loop detection.
+func (b *Bridge) HandleContext(dc *DispatchContext) error {
+	e := dc.Event()
+	if b.filter != nil && !b.filter(e) {
+		return nil
+	}
+
+	ctx := dc.Context()
+	path := bridgePathFromContext(ctx)
+
+	// loop detected, stop forward.
+	for _, em := range path {
+		if em == b.to {
+			return nil
+		}
+	}
+
+	name := e.Name()
+	if b.rename != nil {
+		name = b.rename(name)
+	}
+
+	data := make(M, len(e.Data()))
+	for k, v := range e.Data() {
+		data[k] = v
+	}
+
+	// append new slice, the path can be shared by multi bridges.
+	newPath := make([]*Manager, len(path), len(path)+1)
+	copy(newPath, path)
+	ctx = withBridgePath(ctx, append(newPath, b.from))
+
+	fo := b.to.fireOptions(b.to.normalize(name), []FireOption{Context(ctx)})
+	return b.to.fireEvent(NewBasic(name, data), fo)
+}
+
+// Close stop forward events
+func (b *Bridge) Close() {
+	b.from.RemoveListener(b.pattern, b)
+}
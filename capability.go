@@ -0,0 +1,99 @@
+package event
+
+import "strings"
+
+// Capability flags of the listener. the manager will enforce them on call the listener.
+// if no capability is declared, the listener has all capabilities.
+type Capability uint8
+
+// There are some listener capabilities
+const (
+	// CapObserver the listener only read the event, it's receive the ReadOnlyEvent.
+	CapObserver Capability = 1 << iota
+	// CapMutator the listener can change the event data
+	CapMutator
+	// CapAborter the listener can abort the event
+	CapAborter
+	// CapAll all capabilities, it's same as not declared
+	CapAll = CapObserver | CapMutator | CapAborter
+)
+
+// Has check the capability is contained
+func (c Capability) Has(flag Capability) bool {
+	return c&flag == flag
+}
+
+// String get the capability names. eg: "observer|mutator"
+func (c Capability) String() string {
+	var ss []string
+	if c.Has(CapObserver) {
+		ss = append(ss, "observer")
+	}
+	if c.Has(CapMutator) {
+		ss = append(ss, "mutator")
+	}
+	if c.Has(CapAborter) {
+		ss = append(ss, "aborter")
+	}
+	return strings.Join(ss, "|")
+}
+
+// Capabilities get the effective capabilities of the listener
+func (li *ListenerItem) Capabilities() Capability {
+	if li.Observer {
+		return CapObserver
+	}
+	if li.Caps == 0 {
+		return CapAll
+	}
+	// the mutator and aborter also can read the event
+	return li.Caps | CapObserver
+}
+
+// noAbortEvent wrap the event, the Abort() is no-op
+type noAbortEvent struct {
+	Event
+}
+
+// Abort is no-op
+func (e *noAbortEvent) Abort(bool) {}
+
+// abortOnlyEvent wrap the event as read-only, but can abort it
+type abortOnlyEvent struct {
+	*ReadOnlyEvent
+}
+
+// Abort the wrapped event
+func (e *abortOnlyEvent) Abort(abort bool) {
+	e.e.Abort(abort)
+}
+
+// guard call the listener with the capabilities. the listener without CapMutator will
+// receive the ReadOnlyEvent, and the listener without CapAborter cannot abort the dispatch.
+func (dc *DispatchContext) guard(li *ListenerItem, caps Capability) (err error) {
+	var e Event
+	switch {
+	case caps.Has(CapMutator):
+		e = &noAbortEvent{dc.event}
+	case caps.Has(CapAborter):
+		e = &abortOnlyEvent{NewReadOnlyEvent(dc.event)}
+	default:
+		e = NewReadOnlyEvent(dc.event)
+	}
+
+	// the ContextListener will get a copied context, so can check it's aborted.
+	if cl, ok := li.Listener.(ContextListener); ok {
+		cp := &DispatchContext{event: e, policy: dc.policy, ctx: dc.ctx, chain: dc.chain}
+		err = cl.HandleContext(cp)
+		if cp.IsAborted() && caps.Has(CapAborter) {
+			dc.Abort()
+		}
+		return
+	}
+
+	err = li.Listener.Handle(e)
+	if dc.event.IsAborted() {
+		dc.Abort()
+	}
+	return
+}
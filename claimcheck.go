@@ -0,0 +1,332 @@
+package event
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlobRefKey the key of the blob reference, the offloaded data value is replaced by
+// a map: {"$blob": "ref", "size": 1024}. see ClaimCheck
+const BlobRefKey = "$blob"
+
+// BlobStore interface for storage the large payloads. eg: S3, GCS, local files
+type BlobStore interface {
+	// Put the data and returns the reference
+	Put(data []byte) (ref string, err error)
+	// Get the data by the reference
+	Get(ref string) ([]byte, error)
+}
+
+// ClaimCheck the claim-check pattern for the large payloads. the large data values are
+// stored in the BlobStore, and only the references are passed in the events, so the
+// transports and journals can be kept small. the listeners need the payloads can be
+// wrapped by the Listener(), the references will be rehydrated before handle.
+//
+// Usage:
+// 	cc := event.NewClaimCheck(event.NewFileBlobStore("/data/blobs"), 256*1024)
+// 	// offload on the transports and journal
+// 	b.Codec = cc.Codec(event.DefaultCodec)
+// 	em := event.NewManager("app", event.WithEventStore(cc.EventStore(store)))
+// 	// rehydrate the payloads for the listener
+// 	em.On("doc.uploaded", cc.Listener(indexer))
+type ClaimCheck struct {
+	store BlobStore
+	// Threshold the min JSON size of a data value for offload. default is 256KB
+	Threshold int
+	// Keys limit the data keys for offload. empty is all keys
+	Keys []string
+}
+
+// NewClaimCheck create. if threshold <= 0, will use the 256KB
+func NewClaimCheck(store BlobStore, threshold int) *ClaimCheck {
+	if store == nil {
+		panic("event: the blob store cannot be nil")
+	}
+	if threshold <= 0 {
+		threshold = 256 * 1024
+	}
+
+	return &ClaimCheck{store: store, Threshold: threshold}
+}
+
+// BlobRefOf get the blob reference from the data value, ok is false if it's not a reference.
+func BlobRefOf(val interface{}) (ref string, ok bool) {
+	if mp, isMap := val.(map[string]interface{}); isMap {
+		ref, ok = mp[BlobRefKey].(string)
+	} else if mp, isM := val.(M); isM {
+		ref, ok = mp[BlobRefKey].(string)
+	}
+	return
+}
+
+// Offload store the large values of the data to the BlobStore, returns the new data
+// with the references. the given data will not be changed.
+func (cc *ClaimCheck) Offload(data M) (M, error) {
+	var newData M
+	for key, val := range data {
+		if !cc.offloadable(key, val) {
+			continue
+		}
+
+		bs, err := json.Marshal(val)
+		if err != nil {
+			return nil, fmt.Errorf("event: encode the data '%s' error: %w", key, err)
+		}
+		if len(bs) < cc.Threshold {
+			continue
+		}
+
+		ref, err := cc.store.Put(bs)
+		if err != nil {
+			return nil, fmt.Errorf("event: offload the data '%s' error: %w", key, err)
+		}
+
+		if newData == nil {
+			newData = make(M, len(data))
+			for k, v := range data {
+				newData[k] = v
+			}
+		}
+		newData[key] = M{BlobRefKey: ref, "size": len(bs)}
+	}
+
+	if newData == nil {
+		return data, nil
+	}
+	return newData, nil
+}
+
+func (cc *ClaimCheck) offloadable(key string, val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	if _, ok := BlobRefOf(val); ok {
+		return false
+	}
+
+	if len(cc.Keys) == 0 {
+		return true
+	}
+	for _, k := range cc.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Rehydrate load the referenced values from the BlobStore, and set them to the event data.
+func (cc *ClaimCheck) Rehydrate(e Event) error {
+	refs := make(map[string]string)
+	for key, val := range e.Data() {
+		if ref, ok := BlobRefOf(val); ok {
+			refs[key] = ref
+		}
+	}
+
+	for key, ref := range refs {
+		bs, err := cc.store.Get(ref)
+		if err != nil {
+			return fmt.Errorf("event: load the data '%s' of '%s' error: %w", key, e.Name(), err)
+		}
+
+		var val interface{}
+		if err = json.Unmarshal(bs, &val); err != nil {
+			return fmt.Errorf("event: decode the data '%s' of '%s' error: %w", key, e.Name(), err)
+		}
+		e.Set(key, val)
+	}
+	return nil
+}
+
+// Listener wrap the listener, the references of the event will be rehydrated before handle.
+//
+// NOTICE: the rehydrated values are set to the event, the next listeners will see them.
+func (cc *ClaimCheck) Listener(l Listener) Listener {
+	return ListenerFunc(func(e Event) error {
+		if err := cc.Rehydrate(e); err != nil {
+			return err
+		}
+		return l.Handle(e)
+	})
+}
+
+// Codec wrap the codec, the large values are offloaded on encode.
+// the decoded events keep the references, see Listener() for rehydrate them.
+func (cc *ClaimCheck) Codec(codec Codec) Codec {
+	if codec == nil {
+		codec = DefaultCodec
+	}
+	return &claimCheckCodec{Codec: codec, cc: cc}
+}
+
+type claimCheckCodec struct {
+	Codec
+	cc *ClaimCheck
+}
+
+// Encode the event with the offloaded data
+func (c *claimCheckCodec) Encode(e Event) ([]byte, error) {
+	data, err := c.cc.Offload(e.Data())
+	if err != nil {
+		return nil, err
+	}
+	return c.Codec.Encode(NewBasic(e.Name(), data))
+}
+
+// EventStore wrap the EventStore, the large values are offloaded on append.
+// the wrapper implements the Journal and SnapshotStore by the wrapped store.
+// the loaded events keep the references, see Listener() for rehydrate them.
+func (cc *ClaimCheck) EventStore(es EventStore) EventStore {
+	return &claimCheckStore{EventStore: es, cc: cc}
+}
+
+type claimCheckStore struct {
+	EventStore
+	cc *ClaimCheck
+}
+
+// Append the events with the offloaded data. the given events data will not be changed,
+// the stream, version, position and time set by the store are synced back to them.
+func (s *claimCheckStore) Append(stream string, events ...*StoredEvent) error {
+	list := make([]*StoredEvent, len(events))
+	for i, se := range events {
+		data, err := s.cc.Offload(se.Data)
+		if err != nil {
+			return err
+		}
+
+		cp := *se
+		cp.Data = data
+		list[i] = &cp
+	}
+
+	if err := s.EventStore.Append(stream, list...); err != nil {
+		return err
+	}
+
+	for i, se := range events {
+		se.Stream, se.Version, se.Position, se.Time = list[i].Stream, list[i].Version, list[i].Position, list[i].Time
+	}
+	return nil
+}
+
+// ReadAll read events after the position. implements the Journal
+func (s *claimCheckStore) ReadAll(position uint64, limit int) ([]*StoredEvent, error) {
+	j, ok := s.EventStore.(Journal)
+	if !ok {
+		return nil, fmt.Errorf("event: the event store is not support journal")
+	}
+	return j.ReadAll(position, limit)
+}
+
+// SaveSnapshot save the snapshot. implements the SnapshotStore
+func (s *claimCheckStore) SaveSnapshot(snap *Snapshot) error {
+	ss, ok := s.EventStore.(SnapshotStore)
+	if !ok {
+		return fmt.Errorf("event: the event store is not support snapshot")
+	}
+	return ss.SaveSnapshot(snap)
+}
+
+// LoadSnapshot load the snapshot. implements the SnapshotStore
+func (s *claimCheckStore) LoadSnapshot(stream string) (*Snapshot, error) {
+	if ss, ok := s.EventStore.(SnapshotStore); ok {
+		return ss.LoadSnapshot(stream)
+	}
+	return nil, nil
+}
+
+/*************************************************************
+ * Blob stores
+ *************************************************************/
+
+// MemoryBlobStore an in-memory BlobStore. useful for testing.
+type MemoryBlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryBlobStore create
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+// Put the data. implements the BlobStore interface
+func (s *MemoryBlobStore) Put(data []byte) (string, error) {
+	ref := blobRef(data)
+	s.mu.Lock()
+	s.blobs[ref] = data
+	s.mu.Unlock()
+	return ref, nil
+}
+
+// Get the data. implements the BlobStore interface
+func (s *MemoryBlobStore) Get(ref string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bs, ok := s.blobs[ref]
+	if !ok {
+		return nil, fmt.Errorf("event: the blob '%s' is not found", ref)
+	}
+	return bs, nil
+}
+
+// Len get the number of the blobs
+func (s *MemoryBlobStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.blobs)
+}
+
+// FileBlobStore storage the blobs as files in the dir.
+// the blobs are content-addressed, the reference is the sha256 of the data.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore create
+func NewFileBlobStore(dir string) *FileBlobStore {
+	return &FileBlobStore{dir: dir}
+}
+
+// Put the data. implements the BlobStore interface
+func (s *FileBlobStore) Put(data []byte) (string, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", err
+	}
+
+	ref := blobRef(data)
+	file := filepath.Join(s.dir, ref)
+	if _, err := os.Stat(file); err == nil {
+		return ref, nil
+	}
+
+	// write to a temp file and rename, so the readers never see a partial blob
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	return ref, os.Rename(tmp, file)
+}
+
+// Get the data. implements the BlobStore interface
+func (s *FileBlobStore) Get(ref string) ([]byte, error) {
+	if _, err := hex.DecodeString(ref); err != nil || len(ref) != sha256.Size*2 {
+		return nil, fmt.Errorf("event: invalid blob ref '%s'", ref)
+	}
+	return ioutil.ReadFile(filepath.Join(s.dir, ref))
+}
+
+// blobRef the sha256 of the data
+func blobRef(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
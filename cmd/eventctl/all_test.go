@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/gookit/event/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSplitArgs(t *testing.T) {
+	assert.Equal(t, []string{"fire", "order.created", `{"id": 1}`}, splitArgs(`  fire  order.created {"id": 1}`))
+	assert.Equal(t, []string{"fire", "order.created", `{"id": 1}`}, splitArgs(`fire order.created '{"id": 1}'`))
+	assert.Equal(t, []string{"stats"}, splitArgs("stats"))
+	assert.Len(t, splitArgs("  "), 0)
+}
+
+func TestClient(t *testing.T) {
+	em := event.NewManager("test")
+	em.Listen("order.*", event.ListenerFunc(func(e event.Event) error {
+		e.Set("result", "ok")
+		return nil
+	}), event.ListenOpts{Label: "l1"})
+
+	h := admin.New(em)
+	h.Authorize = admin.AllowAll
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin", h))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	out := new(bytes.Buffer)
+	c := &client{addr: srv.URL + "/admin/", http: srv.Client(), out: out}
+
+	assert.NoError(t, c.run([]string{"fire", "order.created", `{"id": 1}`}))
+	assert.Contains(t, out.String(), `"result": "ok"`)
+
+	out.Reset()
+	assert.NoError(t, c.run([]string{"listeners", "order.*"}))
+	assert.Contains(t, out.String(), `"name": "l1"`)
+
+	assert.NoError(t, c.run([]string{"priority", "order.*", "l1", "300"}))
+	assert.Equal(t, 300, em.ListenersByName("order.*").Items()[0].CurrentPriority())
+	assert.Error(t, c.run([]string{"priority", "order.*", "l1"}))
+	assert.Error(t, c.run([]string{"priority", "order.*", "l2", "1"}))
+
+	assert.NoError(t, c.run([]string{"pause", "order.*"}))
+	assert.True(t, em.IsPaused("order.created"))
+	assert.NoError(t, c.run([]string{"resume", "order.*"}))
+	assert.False(t, em.IsPaused("order.created"))
+	assert.Error(t, c.run([]string{"pause"}))
+
+	out.Reset()
+	assert.NoError(t, c.run([]string{"graph", "dot"}))
+	assert.Contains(t, out.String(), `digraph "test"`)
+
+	assert.Error(t, c.run([]string{"fire"}))
+	assert.Error(t, c.run([]string{"fire", "order.created", "{"}))
+	assert.Error(t, c.run([]string{"unknown"}))
+	assert.Equal(t, errExit, c.run([]string{"exit"}))
+
+	// repl
+	out.Reset()
+	c.repl(strings.NewReader("help\n\nstats\nbad\nexit\nstats\n"))
+	assert.Contains(t, out.String(), "Commands:")
+	assert.Contains(t, out.String(), `"fired": 1`)
+	assert.Contains(t, out.String(), "ERROR: unknown command 'bad'")
+	assert.Equal(t, 1, strings.Count(out.String(), `"fired"`))
+
+	// tail
+	sb := &syncBuffer{}
+	c.out = sb
+	done := make(chan error)
+	go func() {
+		done <- c.tail("order.*")
+	}()
+
+	// wait the tail started
+	for !strings.Contains(sb.String(), "order.wait") {
+		em.MustFire("order.wait", nil)
+		time.Sleep(time.Millisecond)
+	}
+
+	em.MustFire("user.created", nil)
+	em.MustFire("order.paid", event.M{"id": 2})
+	assert.NoError(t, em.Close())
+	assert.NoError(t, <-done)
+	assert.NotContains(t, sb.String(), "user.created")
+	assert.Contains(t, sb.String(), "order.paid {\"name\":\"order.paid\",\"data\":{\"id\":2,\"result\":\"ok\"}}\n")
+}
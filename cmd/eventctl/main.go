@@ -0,0 +1,257 @@
+// Command eventctl is the CLI and REPL for manage the running event manager
+// by the admin HTTP endpoints. see the package "github.com/gookit/event/admin"
+//
+// Usage:
+// 	eventctl -addr http://localhost:8080/admin/events stats
+// 	eventctl -addr http://localhost:8080/admin/events fire order.created '{"id": 1}'
+// 	// start the REPL
+// 	eventctl -addr http://localhost:8080/admin/events
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var usage = `Commands:
+  events                             list the events and listened names
+  listeners [name]                   list the listeners
+  fire <name> [json data]            fire a test event
+  pause <name>                       pause the event name or pattern
+  resume <name>                      resume the event name or pattern
+  priority <event> <listener> <n>    change the listener priority
+  stats                              show the runtime stats
+  graph [dot]                        show the event flow graph
+  tail [pattern]                     tail the events stream
+  help                               show the help
+  exit                               exit the REPL
+`
+
+// errExit exit the REPL
+var errExit = errors.New("exit")
+
+// client for the admin endpoints
+type client struct {
+	addr  string
+	token string
+	http  *http.Client
+	out   io.Writer
+}
+
+func main() {
+	c := &client{http: &http.Client{}, out: os.Stdout}
+	flag.StringVar(&c.addr, "addr", "http://localhost:8080/admin/events", "the admin endpoints address")
+	flag.StringVar(&c.token, "token", "", "the value of the header X-Admin-Token")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: eventctl [options] [command] [args...]\n\nOptions:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n%s", usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		c.repl(os.Stdin)
+		return
+	}
+
+	if err := c.run(flag.Args()); err != nil && err != errExit {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		os.Exit(1)
+	}
+}
+
+// repl read and run the commands from the input
+func (c *client) repl(in io.Reader) {
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(c.out, "event> ")
+		if !sc.Scan() {
+			fmt.Fprintln(c.out)
+			return
+		}
+
+		args := splitArgs(sc.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		if err := c.run(args); err == errExit {
+			return
+		} else if err != nil {
+			fmt.Fprintln(c.out, "ERROR:", err)
+		}
+	}
+}
+
+// splitArgs split the line by spaces, the args starts with '{' will take the rest of line.
+// eg: `fire order.created {"id": 1}`
+func splitArgs(line string) []string {
+	var args []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '{' || line[0] == '\'' {
+			args = append(args, strings.Trim(line, "'"))
+			break
+		}
+
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			args = append(args, line)
+			break
+		}
+
+		args = append(args, line[:i])
+		line = strings.TrimSpace(line[i:])
+	}
+	return args
+}
+
+// run the command
+func (c *client) run(args []string) error {
+	cmd, args := args[0], args[1:]
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+
+	switch cmd {
+	case "events", "stats":
+		return c.do("GET", "/"+cmd, nil)
+	case "listeners":
+		return c.do("GET", "/listeners?name="+arg(0), nil)
+	case "graph":
+		if arg(0) == "dot" {
+			return c.do("GET", "/graph?format=dot", nil)
+		}
+		return c.do("GET", "/graph", nil)
+	case "pause", "resume":
+		if arg(0) == "" {
+			return fmt.Errorf("the event name is required")
+		}
+		return c.do("POST", "/"+cmd+"?name="+arg(0), nil)
+	case "fire":
+		if arg(0) == "" {
+			return fmt.Errorf("the event name is required")
+		}
+
+		body := map[string]interface{}{"name": arg(0)}
+		if data := arg(1); data != "" {
+			var mp map[string]interface{}
+			if err := json.Unmarshal([]byte(data), &mp); err != nil {
+				return fmt.Errorf("invalid event data: %v", err)
+			}
+			body["data"] = mp
+		}
+		return c.do("POST", "/fire", body)
+	case "priority":
+		n, err := strconv.Atoi(arg(2))
+		if err != nil {
+			return fmt.Errorf("usage: priority <event> <listener> <n>")
+		}
+		return c.do("POST", "/priority", map[string]interface{}{"event": arg(0), "listener": arg(1), "priority": n})
+	case "tail":
+		pattern := arg(0)
+		if pattern == "" {
+			pattern = "*"
+		}
+		return c.tail(pattern)
+	case "help":
+		fmt.Fprint(c.out, usage)
+		return nil
+	case "exit", "quit":
+		return errExit
+	}
+	return fmt.Errorf("unknown command '%s', run 'help' for usage", cmd)
+}
+
+func (c *client) request(method, path string, body interface{}) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rd = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.addr, "/")+path, rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.token != "" {
+		req.Header.Set("X-Admin-Token", c.token)
+	}
+	return c.http.Do(req)
+}
+
+// do the request and print the response
+func (c *client) do(method, path string, body interface{}) error {
+	res, err := c.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	bs, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(bs, &e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("the server response status: %s", res.Status)
+	}
+
+	// pretty print JSON
+	buf := new(bytes.Buffer)
+	if json.Indent(buf, bs, "", "  ") == nil {
+		bs = buf.Bytes()
+	}
+
+	_, err = fmt.Fprintln(c.out, strings.TrimSpace(string(bs)))
+	return err
+}
+
+// tail print the events from the SSE stream until it's closed.
+func (c *client) tail(pattern string) error {
+	res, err := c.request("GET", "/tail?events="+pattern, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("the server response status: %s", res.Status)
+	}
+
+	var name string
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = line[7:]
+		case strings.HasPrefix(line, "data: "):
+			fmt.Fprintf(c.out, "%s %s\n", name, line[6:])
+		}
+	}
+	return sc.Err()
+}
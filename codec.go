@@ -0,0 +1,44 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Codec interface for encode and decode events on the transports. eg: MQTT, AMQP, WebSocket
+type Codec interface {
+	// Encode the event to bytes
+	Encode(e Event) ([]byte, error)
+	// Decode the bytes to event
+	Decode(bs []byte) (Event, error)
+}
+
+// DefaultCodec the default codec for the transports
+var DefaultCodec Codec = JSONCodec{}
+
+// jsonEvent the JSON format of the event
+type jsonEvent struct {
+	Name string `json:"name"`
+	Data M      `json:"data"`
+}
+
+// JSONCodec encode the event as JSON object. eg: {"name": "app.run", "data": {"k": "v"}}
+type JSONCodec struct{}
+
+// Encode the event to JSON
+func (JSONCodec) Encode(e Event) ([]byte, error) {
+	return json.Marshal(&jsonEvent{Name: e.Name(), Data: e.Data()})
+}
+
+// Decode the JSON to event. the event name cannot be empty.
+func (JSONCodec) Decode(bs []byte) (Event, error) {
+	var je jsonEvent
+	if err := json.Unmarshal(bs, &je); err != nil {
+		return nil, err
+	}
+
+	if je.Name == "" {
+		return nil, fmt.Errorf("event: the decoded event name cannot be empty")
+	}
+	return NewBasic(je.Name, je.Data), nil
+}
@@ -0,0 +1,145 @@
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CommandResultKey the data key for storage the command result
+const CommandResultKey = "__result"
+
+// CommandHandler func for handle the command and return result.
+// the command data can be got by cmd.Data(), the struct command can be got by Payload(cmd).
+type CommandHandler func(cmd Event) (interface{}, error)
+
+// CommandBus a command bus built on the Manager.
+//
+// Different from events, each command must have exactly one handler, and
+// the dispatch will return the handler result. the command names are
+// registered as Unicast delivery on the Manager, the pattern and wildcard
+// listeners(eg: a logger on "*") are called as observers of the commands.
+//
+// Usage:
+// 	bus := NewCommandBus(em)
+// 	bus.RegisterHandler("user.create", func(cmd Event) (interface{}, error) {
+// 		return createUser(cmd.Get("name").(string))
+// 	})
+//
+// 	ret, err := bus.Dispatch("user.create", M{"name": "inhere"})
+// 	// bind result to typed var
+// 	var user *User
+// 	err = bus.DispatchTo("user.create", M{"name": "inhere"}, &user)
+type CommandBus struct {
+	mu       sync.RWMutex
+	em       *Manager
+	handlers map[string]CommandHandler
+}
+
+// NewCommandBus create a command bus. if em is nil, will create a new Manager.
+func NewCommandBus(em *Manager) *CommandBus {
+	if em == nil {
+		em = NewManager("command-bus")
+	}
+
+	return &CommandBus{em: em, handlers: make(map[string]CommandHandler)}
+}
+
+// Manager get the event manager
+func (b *CommandBus) Manager() *Manager {
+	return b.em
+}
+
+// RegisterHandler register the handler for the command. will panic on the command has handler.
+func (b *CommandBus) RegisterHandler(name string, handler CommandHandler) {
+	if handler == nil {
+		panic("event: the command handler cannot be nil")
+	}
+
+	name = b.em.goodName(name)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.handlers[name]; ok {
+		panic(fmt.Sprintf("event: the command '%s' handler has been registered", name))
+	}
+
+	b.em.SetDelivery(name, Unicast)
+	b.em.On(name, ListenerFunc(func(e Event) error {
+		ret, err := handler(e)
+		if err == nil {
+			e.Set(CommandResultKey, ret)
+		}
+		return err
+	}))
+	b.handlers[name] = handler
+}
+
+// HasHandler check the command has handler
+func (b *CommandBus) HasHandler(name string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	_, ok := b.handlers[b.em.normalize(name)]
+	return ok
+}
+
+// Dispatch the command by name, return the handler result.
+// will return ErrNoListener if the command has no handler, the pattern
+// and wildcard listeners on the Manager are not the command handlers.
+func (b *CommandBus) Dispatch(name string, params M) (interface{}, error) {
+	if !b.HasHandler(name) {
+		return nil, ErrNoListener
+	}
+
+	// copy the params, the handler result will be set to the event data.
+	err, e := b.em.TryFire(name, copyMap(params))
+	if err != nil {
+		return nil, err
+	}
+
+	if e == nil {
+		return nil, ErrNoListener
+	}
+	return e.Get(CommandResultKey), nil
+}
+
+// DispatchTo dispatch the command by name, and bind the result to the ptr.
+func (b *CommandBus) DispatchTo(name string, params M, ptr interface{}) error {
+	ret, err := b.Dispatch(name, params)
+	if err != nil {
+		return err
+	}
+	return bindResult(ret, ptr)
+}
+
+// Send the struct command, the command name is derived from the struct type. see StructName()
+// the handler can get the command by Payload(cmd).
+func (b *CommandBus) Send(cmd interface{}) (interface{}, error) {
+	if cmd == nil {
+		return nil, fmt.Errorf("event: the command cannot be nil")
+	}
+
+	return b.Dispatch(StructName(cmd), M{PayloadKey: cmd})
+}
+
+// bindResult set the result value to the ptr
+func bindResult(ret, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("event: the result receiver must be a non-nil pointer")
+	}
+
+	if ret == nil {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		return nil
+	}
+
+	val := reflect.ValueOf(ret)
+	if !val.Type().AssignableTo(rv.Elem().Type()) {
+		return fmt.Errorf("event: cannot bind result type %s to %s", val.Type(), rv.Elem().Type())
+	}
+
+	rv.Elem().Set(val)
+	return nil
+}
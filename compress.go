@@ -0,0 +1,173 @@
+package event
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+)
+
+// Compressor interface for compress the encoded events. eg: gzip, zstd
+//
+// Usage by klauspost/compress zstd:
+// 	type zstdCompressor struct {
+// 		enc *zstd.Encoder
+// 		dec *zstd.Decoder
+// 	}
+// 	func (c *zstdCompressor) Compress(bs []byte) ([]byte, error) {
+// 		return c.enc.EncodeAll(bs, nil), nil
+// 	}
+// 	func (c *zstdCompressor) Decompress(bs []byte) ([]byte, error) {
+// 		return c.dec.DecodeAll(bs, nil)
+// 	}
+// 	func (c *zstdCompressor) IsCompressed(bs []byte) bool {
+// 		return bytes.HasPrefix(bs, []byte{0x28, 0xb5, 0x2f, 0xfd})
+// 	}
+type Compressor interface {
+	// Compress the data
+	Compress(bs []byte) ([]byte, error)
+	// Decompress the data
+	Decompress(bs []byte) ([]byte, error)
+	// IsCompressed check the data is compressed by the compressor. eg: by the magic number
+	IsCompressed(bs []byte) bool
+}
+
+// GzipCompressor compress the data by gzip
+type GzipCompressor struct {
+	// Level the compression level. default is gzip.DefaultCompression
+	Level int
+}
+
+// Compress the data. implements the Compressor interface
+func (c GzipCompressor) Compress(bs []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(bs); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress the data. implements the Compressor interface
+func (c GzipCompressor) Decompress(bs []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// IsCompressed check the data has the gzip magic number. implements the Compressor interface
+func (c GzipCompressor) IsCompressed(bs []byte) bool {
+	return len(bs) > 1 && bs[0] == 0x1f && bs[1] == 0x8b
+}
+
+// compress the data if it's size reach the threshold
+func compress(c Compressor, threshold int, bs []byte) ([]byte, error) {
+	if c == nil || len(bs) < threshold {
+		return bs, nil
+	}
+	return c.Compress(bs)
+}
+
+// decompress the data if it's compressed
+func decompress(c Compressor, bs []byte) ([]byte, error) {
+	if c == nil || !c.IsCompressed(bs) {
+		return bs, nil
+	}
+	return c.Decompress(bs)
+}
+
+/*************************************************************
+ * Compress codec
+ *************************************************************/
+
+// CompressCodec wrap a Codec, compress the encoded event if the size reach the threshold.
+// the decoder accepts both of the compressed and uncompressed data.
+//
+// Usage:
+// 	b := amqp.New(em, "events", dialer)
+// 	b.Codec = event.NewCompressCodec(event.DefaultCodec, event.GzipCompressor{}, 64*1024)
+type CompressCodec struct {
+	codec      Codec
+	compressor Compressor
+	// Threshold the min size of the encoded event for compress. 0 is compress all
+	Threshold int
+}
+
+// NewCompressCodec create. if the codec is nil, will use the DefaultCodec
+func NewCompressCodec(codec Codec, c Compressor, threshold int) *CompressCodec {
+	if codec == nil {
+		codec = DefaultCodec
+	}
+	if c == nil {
+		panic("event: the compressor cannot be nil")
+	}
+
+	return &CompressCodec{codec: codec, compressor: c, Threshold: threshold}
+}
+
+// Encode the event and compress it. implements the Codec interface
+func (cc *CompressCodec) Encode(e Event) ([]byte, error) {
+	bs, err := cc.codec.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+	return compress(cc.compressor, cc.Threshold, bs)
+}
+
+// Decode the data, decompress it if compressed. implements the Codec interface
+func (cc *CompressCodec) Decode(bs []byte) (Event, error) {
+	bs, err := decompress(cc.compressor, bs)
+	if err != nil {
+		return nil, err
+	}
+	return cc.codec.Decode(bs)
+}
+
+// compressedPrefix the prefix of the compressed text, the compressed data is base64 encoded.
+const compressedPrefix = "z:"
+
+// compressText compress the text if it's size reach the threshold, for store in the text column.
+func compressText(c Compressor, threshold int, bs []byte) (string, error) {
+	if c == nil || len(bs) < threshold {
+		return string(bs), nil
+	}
+
+	bs, err := c.Compress(bs)
+	if err != nil {
+		return "", err
+	}
+	return compressedPrefix + base64.StdEncoding.EncodeToString(bs), nil
+}
+
+// decompressText decompress the text returned by compressText
+func decompressText(c Compressor, s string) ([]byte, error) {
+	if !strings.HasPrefix(s, compressedPrefix) {
+		return []byte(s), nil
+	}
+
+	bs, err := base64.StdEncoding.DecodeString(s[len(compressedPrefix):])
+	if err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		c = GzipCompressor{}
+	}
+	return c.Decompress(bs)
+}
@@ -0,0 +1,376 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ListenerFactory func for create a listener instance
+type ListenerFactory func() Listener
+
+// factories storage the named listener factories
+var factories = struct {
+	sync.RWMutex
+	m map[string]ListenerFactory
+}{m: make(map[string]ListenerFactory)}
+
+// RegisterListenerFactory register a named listener factory.
+// the listeners can be referenced by the factory name on import config.
+func RegisterListenerFactory(name string, fn ListenerFactory) {
+	if name == "" || fn == nil {
+		panic("event: the listener factory name and func cannot be empty")
+	}
+
+	factories.Lock()
+	factories.m[name] = fn
+	factories.Unlock()
+}
+
+// GetListenerFactory get a registered listener factory by name
+func GetListenerFactory(name string) (fn ListenerFactory, ok bool) {
+	factories.RLock()
+	fn, ok = factories.m[name]
+	factories.RUnlock()
+	return
+}
+
+// ListenerConfig the serializable config of a listener
+type ListenerConfig struct {
+	Event    string `json:"event" yaml:"event"`
+	Factory  string `json:"factory" yaml:"factory"`
+	Label    string `json:"label,omitempty" yaml:"label,omitempty"`
+	Priority int    `json:"priority" yaml:"priority"`
+	Once     bool   `json:"once,omitempty" yaml:"once,omitempty"`
+	Async    bool   `json:"async,omitempty" yaml:"async,omitempty"`
+	// Where the filter expression of the listener. see CompileExpr()
+	Where string `json:"where,omitempty" yaml:"where,omitempty"`
+}
+
+// ManagerConfig the serializable config of the manager wiring
+type ManagerConfig struct {
+	Name string `json:"name" yaml:"name"`
+	// Events the registered event names
+	Events []string `json:"events,omitempty" yaml:"events,omitempty"`
+	// Parents the event parents mapping
+	Parents   map[string][]string `json:"parents,omitempty" yaml:"parents,omitempty"`
+	Listeners []*ListenerConfig   `json:"listeners" yaml:"listeners"`
+}
+
+// ListenFactory register a listener created by the named factory.
+// Usage:
+// 	RegisterListenerFactory("mailer", func() Listener { return &Mailer{} })
+// 	em.ListenFactory("user.created", "mailer", ListenOpts{Priority: High})
+func (em *Manager) ListenFactory(name, factory string, opts ListenOpts) error {
+	_, err := em.listenFactory(name, factory, opts, "", false)
+	return err
+}
+
+func (em *Manager) listenFactory(name, factory string, opts ListenOpts, where string, imported bool) (*ListenerItem, error) {
+	fn, ok := GetListenerFactory(factory)
+	if !ok {
+		return nil, fmt.Errorf("event: the listener factory '%s' is not registered", factory)
+	}
+
+	if where != "" {
+		x, err := CompileExpr(where)
+		if err != nil {
+			return nil, err
+		}
+		opts.Filter = x.Match
+	}
+
+	li := newListenerItem(fn(), opts)
+	li.where, li.imported, li.Factory = where, imported, factory
+	return li, em.tryAddListenerItem(name, li)
+}
+
+// ExportConfig export the wiring config of the manager.
+// listeners are referenced by the factory name, the Factory is empty if the
+// listener is not created by factory.
+func (em *Manager) ExportConfig() *ManagerConfig {
+	return em.exportConfig(false)
+}
+
+// exportConfig export the wiring config. if named is true, the Label will be
+// filled by the listener name. see ListenerItem.Name()
+func (em *Manager) exportConfig(named bool) *ManagerConfig {
+	em.lock()
+	defer em.unlock()
+
+	cfg := &ManagerConfig{Name: em.name, Listeners: []*ListenerConfig{}}
+	for name := range em.events {
+		cfg.Events = append(cfg.Events, name)
+	}
+	sort.Strings(cfg.Events)
+
+	if len(em.parents) > 0 {
+		cfg.Parents = make(map[string][]string, len(em.parents))
+		for name, ps := range em.parents {
+			cfg.Parents[name] = append([]string(nil), ps...)
+		}
+	}
+
+	names := make([]string, 0, len(em.listeners))
+	for name := range em.listeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, li := range em.listeners[name].Items() {
+			label := li.Label
+			if named {
+				label = li.Name()
+			}
+
+			cfg.Listeners = append(cfg.Listeners, &ListenerConfig{
+				Event:    name,
+				Factory:  li.Factory,
+				Label:    label,
+				Priority: li.CurrentPriority(),
+				Once:     li.Once,
+				Async:    li.Async,
+				Where:    li.where,
+			})
+		}
+	}
+	return cfg
+}
+
+// ImportConfig import the wiring config to the manager.
+// will add BasicEvent for the event names not exists, and create listeners by factories.
+// the config is validated before import, and the imported parts are rolled back on error.
+func (em *Manager) ImportConfig(cfg *ManagerConfig) error {
+	if err := em.checkConfig(cfg); err != nil {
+		return err
+	}
+	return em.importConfig(cfg)
+}
+
+// checkConfig validate the config before import
+func (em *Manager) checkConfig(cfg *ManagerConfig) error {
+	if em.IsSealed() {
+		return ErrSealed
+	}
+
+	for _, name := range cfg.Events {
+		if _, err := em.checkName(name); err != nil {
+			return err
+		}
+	}
+
+	for name, parents := range cfg.Parents {
+		for _, n := range append([]string{name}, parents...) {
+			if _, err := em.checkName(n); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, lc := range cfg.Listeners {
+		if lc.Factory == "" {
+			return fmt.Errorf("event: cannot import the listener of '%s' without factory", lc.Event)
+		}
+
+		if lc.Event != Wildcard {
+			if _, err := em.checkName(lc.Event); err != nil {
+				return err
+			}
+		}
+
+		if _, ok := GetListenerFactory(lc.Factory); !ok {
+			return fmt.Errorf("event: the listener factory '%s' is not registered", lc.Factory)
+		}
+
+		if lc.Where != "" {
+			if _, err := CompileExpr(lc.Where); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importConfig import the checked config, will roll back the imported parts on error.
+func (em *Manager) importConfig(cfg *ManagerConfig) (err error) {
+	var events []string
+	oldParents := make(map[string][]string, len(cfg.Parents))
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		em.lock()
+		for _, name := range events {
+			delete(em.events, name)
+		}
+		for name, parents := range oldParents {
+			if parents == nil {
+				delete(em.parents, name)
+			} else {
+				em.parents[name] = parents
+			}
+		}
+		em.unlock()
+	}()
+
+	for _, name := range cfg.Events {
+		if !em.HasEvent(name) {
+			if err = em.TryAddEvent(NewBasic(name, nil)); err != nil {
+				return err
+			}
+			events = append(events, em.normalize(name))
+		}
+	}
+
+	for name, parents := range cfg.Parents {
+		name = em.normalize(name)
+		oldParents[name] = em.EventParents(name)
+		if err = em.TrySetEventParents(name, append([]string(nil), parents...)...); err != nil {
+			return err
+		}
+	}
+
+	added := make(map[*ListenerItem]bool, len(cfg.Listeners))
+	for _, lc := range cfg.Listeners {
+		li, err := em.listenFactory(lc.Event, lc.Factory, ListenOpts{
+			Priority: lc.Priority,
+			Once:     lc.Once,
+			Async:    lc.Async,
+			Label:    lc.Label,
+		}, lc.Where, true)
+		if err != nil {
+			em.lock()
+			em.removeImported(added)
+			em.unlock()
+			return err
+		}
+		added[li] = true
+	}
+	return nil
+}
+
+// ReloadConfig remove all listeners imported by config before, then import the new config.
+// it's useful for re-route events without code deploy.
+// the new config is validated before remove the old listeners,
+// and the old listeners are restored if import the new config failed.
+func (em *Manager) ReloadConfig(cfg *ManagerConfig) error {
+	if err := em.checkConfig(cfg); err != nil {
+		return err
+	}
+
+	em.lock()
+	old := em.removeImported(nil)
+	em.unlock()
+
+	if err := em.importConfig(cfg); err != nil {
+		em.lock()
+		for name, items := range old {
+			em.restoreItems(name, items)
+		}
+		em.unlock()
+		return err
+	}
+	return nil
+}
+
+// removeImported remove the listeners imported by config, returns the removed items by the listened name.
+// if the items is not nil, only remove the items in it.
+// NOTICE: should call it with lock.
+func (em *Manager) removeImported(items map[*ListenerItem]bool) map[string][]*ListenerItem {
+	removed := make(map[string][]*ListenerItem)
+	for name, lq := range em.listeners {
+		for _, li := range lq.Items() {
+			if li.imported && (items == nil || items[li]) {
+				lq.RemoveItem(li)
+				removed[name] = append(removed[name], li)
+			}
+		}
+		em.syncQueue(name)
+	}
+	return removed
+}
+
+// restoreItems add back the removed listener items, the queue is rebuilt by the registration order.
+// NOTICE: should call it with lock.
+func (em *Manager) restoreItems(name string, items []*ListenerItem) {
+	if lq, ok := em.listeners[name]; ok {
+		items = append(items, lq.Items()...)
+	} else {
+		em.matcher.Add(name)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Seq < items[j].Seq
+	})
+
+	lq := em.newQueue()
+	for _, li := range items {
+		lq.Add(li)
+	}
+	em.listeners[name] = lq
+	em.listenedNames[name] = lq.Len()
+}
+
+// ConfigDecoder func for decode the config data. eg: json.Unmarshal, yaml.Unmarshal
+type ConfigDecoder func(data []byte, v interface{}) error
+
+// configDecoders storage decoders by the file ext.
+var configDecoders = struct {
+	sync.RWMutex
+	m map[string]ConfigDecoder
+}{m: map[string]ConfigDecoder{
+	".json": json.Unmarshal,
+}}
+
+// RegisterConfigDecoder register a decoder for the config file ext.
+// Usage:
+// 	event.RegisterConfigDecoder(".yaml", yaml.Unmarshal)
+// 	event.RegisterConfigDecoder(".yml", yaml.Unmarshal)
+func RegisterConfigDecoder(ext string, fn ConfigDecoder) {
+	configDecoders.Lock()
+	configDecoders.m[strings.ToLower(ext)] = fn
+	configDecoders.Unlock()
+}
+
+// ParseConfig parse the config data by the decoder. if decode is nil, will use json.Unmarshal
+func ParseConfig(data []byte, decode ConfigDecoder) (*ManagerConfig, error) {
+	if decode == nil {
+		decode = json.Unmarshal
+	}
+
+	cfg := &ManagerConfig{}
+	if err := decode(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFile load wiring config from the file and reload it to the manager.
+// the decoder is selected by the file ext, default support ".json".
+// see RegisterConfigDecoder()
+func (em *Manager) LoadConfigFile(file string) error {
+	ext := strings.ToLower(filepath.Ext(file))
+	configDecoders.RLock()
+	decode, ok := configDecoders.m[ext]
+	configDecoders.RUnlock()
+	if !ok {
+		return fmt.Errorf("event: not supported config file ext '%s'", ext)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ParseConfig(data, decode)
+	if err != nil {
+		return err
+	}
+	return em.ReloadConfig(cfg)
+}
@@ -0,0 +1,131 @@
+package confwatch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	d := Compare(
+		map[string]interface{}{"a": 1, "log": map[string]interface{}{"level": "info", "file": "a.log"}},
+		map[string]interface{}{"b": 2, "log": map[string]interface{}{"level": "debug", "file": "a.log"}},
+	)
+
+	assert.Equal(t, map[string]interface{}{"b": 2}, d.Added)
+	assert.Equal(t, map[string]interface{}{"a": 1}, d.Removed)
+	assert.Equal(t, map[string]Change{"log.level": {Old: "info", New: "debug"}}, d.Changed)
+	assert.Equal(t, []string{"a", "b", "log.level"}, d.Keys())
+	assert.Equal(t, "-a +b ~log.level", d.String())
+	assert.False(t, d.Empty())
+	assert.True(t, Compare(nil, nil).Empty())
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	em := event.NewManager("test")
+	var fired []event.Event
+	em.On(EventChanged, event.ListenerFunc(func(e event.Event) error {
+		fired = append(fired, e)
+		return nil
+	}))
+
+	data := map[string]interface{}{"level": "info"}
+	w := New(em, func() (map[string]interface{}, error) {
+		if data == nil {
+			return nil, fmt.Errorf("load error")
+		}
+		return data, nil
+	})
+	w.Debounce = time.Second
+
+	var errs []error
+	w.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+
+	now := time.Now()
+	assert.Nil(t, w.Poll(now))
+	assert.Equal(t, data, w.Current())
+
+	data = map[string]interface{}{"level": "debug"}
+	assert.Nil(t, w.Poll(now.Add(time.Second)))
+	// changed again, reset the debounce
+	data = map[string]interface{}{"level": "warn"}
+	assert.Nil(t, w.Poll(now.Add(1500*time.Millisecond)))
+	assert.Nil(t, w.Poll(now.Add(2*time.Second)))
+
+	d := w.Poll(now.Add(3 * time.Second))
+	assert.NotNil(t, d)
+	assert.Equal(t, Change{Old: "info", New: "warn"}, d.Changed["level"])
+	assert.Len(t, fired, 1)
+	assert.Equal(t, []string{"level"}, fired[0].Get("keys"))
+	assert.Equal(t, data, w.Current())
+
+	// not changed
+	assert.Nil(t, w.Poll(now.Add(5*time.Second)))
+
+	data = nil
+	assert.Nil(t, w.Poll(now.Add(6*time.Second)))
+	assert.Len(t, errs, 1)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "confwatch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`{"log": {"level": "info"}}`), 0644))
+
+	em := event.NewManager("test", event.WithConcurrencySafe())
+	ch := make(chan *Diff, 1)
+	em.On(EventChanged, event.ListenerFunc(func(e event.Event) error {
+		ch <- e.Get("diff").(*Diff)
+		return nil
+	}))
+
+	w := New(em, FileLoader(file))
+	w.Interval = 5 * time.Millisecond
+	w.Debounce = 10 * time.Millisecond
+	assert.NoError(t, w.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`{"log": {"level": "debug"}}`), 0644))
+	select {
+	case d := <-ch:
+		assert.Equal(t, "~log.level", d.String())
+	case <-time.After(2 * time.Second):
+		t.Fatal("the changed event is not fired")
+	}
+	w.Stop()
+}
+
+func TestHTTPLoader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"a": 1}`))
+	}))
+	defer srv.Close()
+
+	data, err := HTTPLoader(srv.URL + "/config")()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, data)
+
+	_, err = HTTPLoader(srv.URL + "/404")()
+	assert.Error(t, err)
+}
@@ -0,0 +1,316 @@
+// Package confwatch watch the config source, fire the "config.changed" event with the diff on changed.
+//
+// Usage:
+// 	w := confwatch.New(em, confwatch.FileLoader("config.json"))
+// 	w.Debounce = time.Second
+// 	go w.Run(ctx)
+//
+// 	em.On(confwatch.EventChanged, event.ListenerFunc(func(e event.Event) error {
+// 		diff := e.Get("diff").(*confwatch.Diff)
+// 		if c, ok := diff.Changed["log.level"]; ok {
+// 			setLevel(c.New)
+// 		}
+// 		return nil
+// 	}))
+package confwatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// EventChanged the default event name of the config changed
+const EventChanged = "config.changed"
+
+// Loader load the config data from the source
+type Loader func() (map[string]interface{}, error)
+
+// FileLoader load the config from the JSON file. can custom the decode func, eg: yaml.Unmarshal
+func FileLoader(path string, decode ...func([]byte, interface{}) error) Loader {
+	return func() (map[string]interface{}, error) {
+		bs, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return unmarshal(bs, decode)
+	}
+}
+
+// HTTPLoader load the config from the JSON endpoint by GET. can custom the decode func.
+func HTTPLoader(url string, decode ...func([]byte, interface{}) error) Loader {
+	return func() (map[string]interface{}, error) {
+		resp, err := http.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("confwatch: load config from '%s' status: %d", url, resp.StatusCode)
+		}
+
+		bs, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return unmarshal(bs, decode)
+	}
+}
+
+func unmarshal(bs []byte, decode []func([]byte, interface{}) error) (map[string]interface{}, error) {
+	fn := json.Unmarshal
+	if len(decode) > 0 {
+		fn = decode[0]
+	}
+
+	data := make(map[string]interface{})
+	err := fn(bs, &data)
+	return data, err
+}
+
+// Change of a config key
+type Change struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Diff of the config, the keys are flatten by '.'. eg: "log.level"
+type Diff struct {
+	Added   map[string]interface{} `json:"added"`
+	Removed map[string]interface{} `json:"removed"`
+	Changed map[string]Change      `json:"changed"`
+}
+
+// Empty check the diff is empty
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Keys get all changed keys, is sorted.
+func (d *Diff) Keys() []string {
+	keys := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	for k := range d.Added {
+		keys = append(keys, k)
+	}
+	for k := range d.Removed {
+		keys = append(keys, k)
+	}
+	for k := range d.Changed {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// String get the diff summary. eg: "+a.b -c ~d"
+func (d *Diff) String() string {
+	var ss []string
+	for _, k := range d.Keys() {
+		if _, ok := d.Added[k]; ok {
+			ss = append(ss, "+"+k)
+		} else if _, ok := d.Removed[k]; ok {
+			ss = append(ss, "-"+k)
+		} else {
+			ss = append(ss, "~"+k)
+		}
+	}
+	return strings.Join(ss, " ")
+}
+
+// Compare the old and new config data
+func Compare(old, new map[string]interface{}) *Diff {
+	d := &Diff{
+		Added:   make(map[string]interface{}),
+		Removed: make(map[string]interface{}),
+		Changed: make(map[string]Change),
+	}
+
+	of, nf := Flatten(old), Flatten(new)
+	for k, nv := range nf {
+		ov, ok := of[k]
+		if !ok {
+			d.Added[k] = nv
+		} else if !reflect.DeepEqual(ov, nv) {
+			d.Changed[k] = Change{Old: ov, New: nv}
+		}
+	}
+
+	for k, ov := range of {
+		if _, ok := nf[k]; !ok {
+			d.Removed[k] = ov
+		}
+	}
+	return d
+}
+
+// Flatten the nested maps to the keys joined by '.'. the slices are kept as value.
+func Flatten(data map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{}, len(data))
+	flatten("", data, flat)
+	return flat
+}
+
+func flatten(prefix string, data map[string]interface{}, flat map[string]interface{}) {
+	for k, v := range data {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			flatten(key, sub, flat)
+		} else {
+			flat[key] = v
+		}
+	}
+}
+
+// Watcher poll the config source, fire the changed event with the Diff after the
+// config is stable for the Debounce duration.
+//
+// the event data:
+// 	diff    *Diff      the diff of the config
+// 	config  map        the new config data
+// 	keys    []string   the changed keys
+type Watcher struct {
+	mu     sync.Mutex
+	em     *event.Manager
+	load   Loader
+	stopCh chan struct{}
+	once   sync.Once
+	// the fired config and the candidate config
+	current   map[string]interface{}
+	candidate map[string]interface{}
+	changedAt time.Time
+	loaded    bool
+	// EventName of the changed event. default is EventChanged
+	EventName string
+	// Interval for poll the source. default is 2s
+	Interval time.Duration
+	// Debounce wait the config is stable before fire. default is 500ms
+	Debounce time.Duration
+	// OnError handle the load error. optional
+	OnError func(err error)
+}
+
+// New create the config watcher
+func New(em *event.Manager, load Loader) *Watcher {
+	return &Watcher{
+		em:        em,
+		load:      load,
+		stopCh:    make(chan struct{}),
+		EventName: EventChanged,
+		Interval:  2 * time.Second,
+		Debounce:  500 * time.Millisecond,
+	}
+}
+
+// Current get the current config data, it's the last fired config.
+func (w *Watcher) Current() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Load the config as the current config, will not fire event.
+// it's called by Run() if the config is not loaded.
+func (w *Watcher) Load() error {
+	data, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.current, w.candidate, w.loaded = data, data, true
+	w.mu.Unlock()
+	return nil
+}
+
+// Run poll the source until the ctx is done or Stop() is called.
+func (w *Watcher) Run(ctx context.Context) {
+	w.mu.Lock()
+	loaded := w.loaded
+	w.mu.Unlock()
+
+	if !loaded {
+		if err := w.Load(); err != nil {
+			w.onError(err)
+		}
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			w.Poll(now)
+		case <-ctx.Done():
+			return
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// Stop the Run()
+func (w *Watcher) Stop() {
+	w.once.Do(func() {
+		close(w.stopCh)
+	})
+}
+
+// Poll load the config once, fire the changed event if the changed config is stable
+// for the Debounce duration. returns the fired diff, nil if not fired.
+func (w *Watcher) Poll(now time.Time) *Diff {
+	data, err := w.load()
+	if err != nil {
+		w.onError(err)
+		return nil
+	}
+
+	w.mu.Lock()
+	if !w.loaded {
+		w.current, w.candidate, w.loaded = data, data, true
+		w.mu.Unlock()
+		return nil
+	}
+
+	if !reflect.DeepEqual(data, w.candidate) {
+		w.candidate, w.changedAt = data, now
+	}
+
+	if now.Sub(w.changedAt) < w.Debounce || reflect.DeepEqual(w.candidate, w.current) {
+		w.mu.Unlock()
+		return nil
+	}
+
+	diff := Compare(w.current, w.candidate)
+	config := w.candidate
+	w.current = config
+	w.mu.Unlock()
+
+	_, _ = w.em.Fire(w.EventName, event.M{
+		"diff":   diff,
+		"config": config,
+		"keys":   diff.Keys(),
+	})
+	return diff
+}
+
+func (w *Watcher) onError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,77 @@
+package event
+
+import "context"
+
+// the context key type for store the Manager
+type managerCtxKey struct{}
+
+// NewContext returns a new context that carries the manager.
+// Usage:
+// 	ctx = event.NewContext(r.Context(), em)
+// 	next.ServeHTTP(w, r.WithContext(ctx))
+func NewContext(ctx context.Context, em *Manager) context.Context {
+	return context.WithValue(ctx, managerCtxKey{}, em)
+}
+
+// FromContext get the manager from the context, returns the DefaultEM if not found.
+// Usage:
+// 	event.FromContext(ctx).FireContext(ctx, "order.created", params)
+func FromContext(ctx context.Context) *Manager {
+	if em, ok := ManagerFromContext(ctx); ok {
+		return em
+	}
+	return DefaultEM
+}
+
+// ManagerFromContext get the manager from the context, ok is false if not found.
+func ManagerFromContext(ctx context.Context) (em *Manager, ok bool) {
+	em, ok = ctx.Value(managerCtxKey{}).(*Manager)
+	return em, ok && em != nil
+}
+
+// the context key type for store the dispatch chain
+type chainCtxKey struct{}
+
+// withChain returns a new context that carries the dispatch chain
+func withChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, chainCtxKey{}, chain)
+}
+
+// ChainFromContext get the event names of the dispatch chain from the context.
+// the ctx should be got from DispatchContext.Context()
+func ChainFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+
+	chain, _ := ctx.Value(chainCtxKey{}).([]string)
+	return chain
+}
+
+// the context key type for store the managers the event has passed through. see Bridge
+type bridgePathCtxKey struct{}
+
+// withBridgePath returns a new context that carries the bridge path
+func withBridgePath(ctx context.Context, path []*Manager) context.Context {
+	return context.WithValue(ctx, bridgePathCtxKey{}, path)
+}
+
+// bridgePathFromContext get the managers the event has passed through
+func bridgePathFromContext(ctx context.Context) []*Manager {
+	path, _ := ctx.Value(bridgePathCtxKey{}).([]*Manager)
+	return path
+}
+
+// pushChain append the event name to the dispatch chain of the fire ctx,
+// returns *DepthError on exceeding the Options.MaxDispatchDepth.
+func (em *Manager) pushChain(dc *DispatchContext) error {
+	parent := ChainFromContext(dc.ctx)
+	chain := make([]string, len(parent), len(parent)+1)
+	copy(chain, parent)
+	dc.chain = append(chain, dc.event.Name())
+
+	if max := em.opts.MaxDispatchDepth; max > 0 && len(dc.chain) > max {
+		return &DepthError{Chain: dc.chain, Max: max}
+	}
+	return nil
+}
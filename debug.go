@@ -0,0 +1,124 @@
+package event
+
+import "sync/atomic"
+
+// DebugAction the action returned by the Debugger
+type DebugAction uint8
+
+// There are some debug actions
+const (
+	// DebugContinue call the listener
+	DebugContinue DebugAction = iota
+	// DebugSkip skip the listener, continue call next listeners
+	DebugSkip
+	// DebugAbort abort the event, stop call next listeners
+	DebugAbort
+)
+
+// Debugger interface, called before each listener invocation.
+// it can inspect or modify the event data, and returns the action.
+// the dispatch is paused until it returns, so it can wait the user input.
+type Debugger interface {
+	BeforeListener(e Event, li *ListenerItem) DebugAction
+}
+
+// DebuggerFunc func definition.
+type DebuggerFunc func(e Event, li *ListenerItem) DebugAction
+
+// BeforeListener implements the Debugger interface
+func (fn DebuggerFunc) BeforeListener(e Event, li *ListenerItem) DebugAction {
+	return fn(e, li)
+}
+
+// debuggerBox for storage the debugger by atomic.Value
+type debuggerBox struct {
+	d Debugger
+}
+
+// SetDebugger setting the debugger for the manager, nil is remove it.
+// Usage:
+// 	em.SetDebugger(event.DebuggerFunc(func(e event.Event, li *event.ListenerItem) event.DebugAction {
+// 		fmt.Println("call", li.Name(), "for", e.Name(), e.Data())
+// 		return event.DebugContinue
+// 	}))
+func (em *Manager) SetDebugger(d Debugger) {
+	em.debugger.Store(&debuggerBox{d})
+}
+
+// debug call the debugger before the listener, returns the action
+func (em *Manager) debug(e Event, li *ListenerItem) DebugAction {
+	box, ok := em.debugger.Load().(*debuggerBox)
+	if !ok || box.d == nil {
+		return DebugContinue
+	}
+	return box.d.BeforeListener(e, li)
+}
+
+// DebugStep the paused step of the StepDebugger
+type DebugStep struct {
+	Event    Event
+	Listener *ListenerItem
+	action   chan DebugAction
+}
+
+// Continue call the listener
+func (s *DebugStep) Continue() {
+	s.action <- DebugContinue
+}
+
+// Skip the listener
+func (s *DebugStep) Skip() {
+	s.action <- DebugSkip
+}
+
+// Abort the event
+func (s *DebugStep) Abort() {
+	s.action <- DebugAbort
+}
+
+// StepDebugger pause the dispatch before each listener, until the step is resolved.
+//
+// Usage:
+// 	sd := event.NewStepDebugger()
+// 	em.SetDebugger(sd)
+//
+// 	for step := range sd.Steps() {
+// 		fmt.Println(step.Event.Name(), step.Listener.Name())
+// 		step.Event.Set("debug", true)
+// 		step.Continue()
+// 	}
+type StepDebugger struct {
+	steps    chan *DebugStep
+	disabled int32
+}
+
+// NewStepDebugger create a step debugger
+func NewStepDebugger() *StepDebugger {
+	return &StepDebugger{steps: make(chan *DebugStep)}
+}
+
+// Steps get the channel of the paused steps
+func (sd *StepDebugger) Steps() <-chan *DebugStep {
+	return sd.steps
+}
+
+// Disable the debugger, all listeners will be called without pause.
+func (sd *StepDebugger) Disable() {
+	atomic.StoreInt32(&sd.disabled, 1)
+}
+
+// Enable the debugger
+func (sd *StepDebugger) Enable() {
+	atomic.StoreInt32(&sd.disabled, 0)
+}
+
+// BeforeListener implements the Debugger interface
+func (sd *StepDebugger) BeforeListener(e Event, li *ListenerItem) DebugAction {
+	if atomic.LoadInt32(&sd.disabled) == 1 {
+		return DebugContinue
+	}
+
+	step := &DebugStep{Event: e, Listener: li, action: make(chan DebugAction, 1)}
+	sd.steps <- step
+	return <-step.action
+}
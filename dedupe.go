@@ -0,0 +1,111 @@
+package event
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DedupeStore the store for dedupe the events by the idempotency keys, it's same as the IdempotencyStore.
+// the events bridged by the transports can be processed effectively-once by the WithIdempotency(),
+// the publisher should stamp the idempotency key by StampKey(), so the redelivered messages have the same key.
+//
+// the implementations: MemoryIdempotencyStore, LRUDedupeStore and the package redisdedupe
+type DedupeStore = IdempotencyStore
+
+// NewIdempotencyKey generate a random idempotency key
+func NewIdempotencyKey() string {
+	bs := make([]byte, 16)
+	if _, err := rand.Read(bs); err != nil {
+		panic("event: generate the idempotency key error: " + err.Error())
+	}
+	return hex.EncodeToString(bs)
+}
+
+// StampKey set a new idempotency key to the event data, if the event has no key.
+// returns the idempotency key of the event.
+func StampKey(e Event) string {
+	if key := IdempotencyKeyOf(e); key != "" {
+		return key
+	}
+
+	key := NewIdempotencyKey()
+	e.Set(IdempotencyKey, key)
+	return key
+}
+
+/*************************************************************
+ * LRU dedupe store
+ *************************************************************/
+
+// LRUDedupeStore an in-memory DedupeStore limited by the capacity,
+// the least recently claimed keys will be evicted on exceeding the capacity.
+type LRUDedupeStore struct {
+	mu       sync.Mutex
+	capacity int
+	keys     map[string]*list.Element
+	// the claimed keys, the front is the recently claimed.
+	ll *list.List
+}
+
+type lruEntry struct {
+	key    string
+	expire time.Time
+}
+
+// NewLRUDedupeStore create. the capacity <= 0 is unlimited.
+func NewLRUDedupeStore(capacity int) *LRUDedupeStore {
+	return &LRUDedupeStore{
+		capacity: capacity,
+		keys:     make(map[string]*list.Element),
+		ll:       list.New(),
+	}
+}
+
+// Claim the key for the window. implements the DedupeStore interface
+func (s *LRUDedupeStore) Claim(key string, window time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.keys[key]; ok {
+		entry := el.Value.(*lruEntry)
+		if now.Before(entry.expire) {
+			return false, nil
+		}
+
+		entry.expire = now.Add(window)
+		s.ll.MoveToFront(el)
+		return true, nil
+	}
+
+	s.keys[key] = s.ll.PushFront(&lruEntry{key: key, expire: now.Add(window)})
+	if s.capacity > 0 && s.ll.Len() > s.capacity {
+		s.remove(s.ll.Back())
+	}
+	return true, nil
+}
+
+// Release the claimed key. implements the DedupeStore interface
+func (s *LRUDedupeStore) Release(key string) error {
+	s.mu.Lock()
+	if el, ok := s.keys[key]; ok {
+		s.remove(el)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Len get the number of the claimed keys
+func (s *LRUDedupeStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *LRUDedupeStore) remove(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.keys, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,163 @@
+package event
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// Delivery the delivery mode of an event. see Manager.SetDelivery()
+type Delivery uint8
+
+// There are some delivery modes
+const (
+	// Broadcast deliver the event to all matched listeners. it's default mode.
+	Broadcast Delivery = iota
+	// RoundRobin deliver the event to one of the listeners on the event name in turn.
+	RoundRobin
+	// Weighted deliver the event to one of the listeners on the event name,
+	// it's random selected by the listener Weight.
+	Weighted
+	// Unicast deliver the event to exactly one listener on the event name. will return
+	// ErrNoListener if no listener, ErrAmbiguousListener if multi listeners.
+	// the pattern, wildcard and parent listeners are not counted, they are called as observers.
+	Unicast
+)
+
+// String get the delivery mode name
+func (d Delivery) String() string {
+	switch d {
+	case RoundRobin:
+		return "round-robin"
+	case Weighted:
+		return "weighted"
+	case Unicast:
+		return "unicast"
+	default:
+		return "broadcast"
+	}
+}
+
+// delivery the delivery state of an event
+type delivery struct {
+	mode Delivery
+	// the round-robin counter
+	next uint64
+}
+
+// SetDelivery setting the delivery mode for the event name.
+// the listeners on the event name are competing consumers on the RoundRobin and Weighted mode,
+// only one of them will handle the event on each fire. the pattern, wildcard and parent
+// listeners are not competing, they are always called as observers.
+// the Unicast mode is for command-style events, must have exactly one listener.
+//
+// Usage:
+// 	em.SetDelivery("job.created", RoundRobin)
+// 	em.On("job.created", worker1)
+// 	em.On("job.created", worker2)
+//
+// 	em.SetDelivery("job.created", Weighted)
+// 	em.Listen("job.created", worker1, ListenOpts{Weight: 3})
+// 	em.Listen("job.created", worker2, ListenOpts{Weight: 1})
+func (em *Manager) SetDelivery(name string, mode Delivery) {
+	em.mustNotSealed()
+	name = em.goodName(name)
+
+	em.lock()
+	if mode == Broadcast {
+		delete(em.deliveries, name)
+	} else {
+		em.deliveries[name] = &delivery{mode: mode}
+	}
+	em.unlock()
+}
+
+// DeliveryOf get the delivery mode of the event name
+func (em *Manager) DeliveryOf(name string) Delivery {
+	if d := em.delivery(em.normalize(name)); d != nil {
+		return d.mode
+	}
+	return Broadcast
+}
+
+// checkUnicast return ErrNoListener if the event is unicast. called on no matched listeners.
+func (em *Manager) checkUnicast(name string) error {
+	if d := em.delivery(name); d != nil && d.mode == Unicast {
+		return ErrNoListener
+	}
+	return nil
+}
+
+// delivery get the delivery state by the checked name
+func (em *Manager) delivery(name string) *delivery {
+	em.rLock()
+	defer em.rUnlock()
+	return em.deliveries[name]
+}
+
+// deliver select the listeners to call by the delivery mode of the event.
+// name is the checked event name, only the listeners on the exact name are the candidates,
+// the pattern, wildcard and parent listeners are observers.
+func (em *Manager) deliver(e Event, name string, gs []*listenerGroup) ([]*listenerGroup, error) {
+	d := em.delivery(em.normalize(e.Name()))
+	if d == nil {
+		return gs, nil
+	}
+
+	// collect the candidates. the Filter is checked here, will not check again on call.
+	var cs, observers []*listenerGroup
+	for _, g := range gs {
+		if g.name != name {
+			observers = append(observers, g)
+			continue
+		}
+
+		for _, li := range g.items {
+			if li.Filter != nil && !li.Filter(e) {
+				continue
+			}
+
+			if em.opts.Adaptive != nil && li.IsSuspended() {
+				continue
+			}
+			cs = append(cs, &listenerGroup{name: g.name, items: []*ListenerItem{li}, filtered: true})
+		}
+	}
+
+	if d.mode == Unicast {
+		switch len(cs) {
+		case 0:
+			return nil, ErrNoListener
+		case 1:
+			return append(cs, observers...), nil
+		default:
+			return nil, ErrAmbiguousListener
+		}
+	}
+
+	if len(cs) > 1 {
+		switch d.mode {
+		case RoundRobin:
+			n := atomic.AddUint64(&d.next, 1) - 1
+			cs = cs[n%uint64(len(cs)):][:1]
+		case Weighted:
+			cs = cs[weightedIndex(cs):][:1]
+		}
+	}
+	return append(cs, observers...), nil
+}
+
+// weightedIndex random select an index by the listener Weight
+func weightedIndex(cs []*listenerGroup) int {
+	var total int
+	for _, g := range cs {
+		total += g.items[0].weight()
+	}
+
+	n := rand.Intn(total)
+	for i, g := range cs {
+		if n -= g.items[0].weight(); n < 0 {
+			return i
+		}
+	}
+	return len(cs) - 1
+}
@@ -0,0 +1,95 @@
+package event
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// Logger interface for the manager warnings. *log.Logger is implemented it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// logf log the message by the Logger, will use the std log if not setting.
+func (em *Manager) logf(format string, v ...interface{}) {
+	if em.opts.Logger != nil {
+		em.opts.Logger.Printf(format, v...)
+	} else {
+		log.Printf(format, v...)
+	}
+}
+
+// deprecation of the event name
+type deprecation struct {
+	replacement string
+	reroute     bool
+	// mark the warning has been logged. 1: logged
+	fireWarned   int32
+	listenWarned int32
+}
+
+// Deprecate mark the event name is deprecated by the replacement name.
+// firing or listening to it will log a one-time warning by the Logger.
+// if the reroute is true, the event will be fired to and the listener will be added to the replacement.
+//
+// Usage:
+// 	em.Deprecate("user.signup", "user.created", true)
+// 	// log warning and fire "user.created"
+// 	em.Fire("user.signup", nil)
+func (em *Manager) Deprecate(name, replacement string, reroute bool) {
+	name = em.goodName(name)
+	if replacement != "" {
+		replacement = em.goodName(replacement)
+	}
+
+	if reroute && (replacement == "" || replacement == name) {
+		panic("event: the reroute replacement of '" + name + "' cannot be empty or same")
+	}
+
+	em.mustNotSealed()
+	em.lock()
+	em.deprecations[name] = &deprecation{replacement: replacement, reroute: reroute}
+	em.unlock()
+}
+
+// Deprecation get the replacement of the deprecated event name
+func (em *Manager) Deprecation(name string) (replacement string, ok bool) {
+	em.rLock()
+	d, ok := em.deprecations[em.normalize(name)]
+	em.rUnlock()
+
+	if ok {
+		replacement = d.replacement
+	}
+	return
+}
+
+// deprecated check the event name is deprecated, will log warning on first
+// fire or listen. returns the replacement name if reroute, otherwise the name.
+func (em *Manager) deprecated(name string, listen bool) string {
+	em.rLock()
+	d, ok := em.deprecations[name]
+	em.rUnlock()
+
+	if !ok {
+		return name
+	}
+
+	flag, action := &d.fireWarned, "fire"
+	if listen {
+		flag, action = &d.listenWarned, "listen"
+	}
+
+	if atomic.CompareAndSwapInt32(flag, 0, 1) {
+		if d.replacement == "" {
+			em.logf("event: %s the deprecated event '%s'", action, name)
+		} else {
+			em.logf("event: %s the deprecated event '%s', use '%s' instead", action, name, d.replacement)
+		}
+	}
+
+	if d.reroute {
+		return d.replacement
+	}
+	return name
+}
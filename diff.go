@@ -0,0 +1,168 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListenerChange the changed listener config
+type ListenerChange struct {
+	Old *ListenerConfig
+	New *ListenerConfig
+}
+
+// WiringDiff the differences of two wiring configs. see DiffConfig()
+type WiringDiff struct {
+	AddedEvents   []string
+	RemovedEvents []string
+	// ChangedParents the event names the parents are changed
+	ChangedParents []string
+	Added          []*ListenerConfig
+	Removed        []*ListenerConfig
+	Changed        []*ListenerChange
+}
+
+// Empty check the two configs are same
+func (d *WiringDiff) Empty() bool {
+	return len(d.AddedEvents)+len(d.RemovedEvents)+len(d.ChangedParents)+
+		len(d.Added)+len(d.Removed)+len(d.Changed) == 0
+}
+
+// String the diff report. eg:
+// 	+ event order.paid
+// 	- listener order.created mailer priority=0
+// 	~ listener order.created audit priority=0 -> priority=200
+func (d *WiringDiff) String() string {
+	var sb strings.Builder
+	for _, name := range d.AddedEvents {
+		sb.WriteString("+ event " + name + "\n")
+	}
+	for _, name := range d.RemovedEvents {
+		sb.WriteString("- event " + name + "\n")
+	}
+	for _, name := range d.ChangedParents {
+		sb.WriteString("~ parents " + name + "\n")
+	}
+	for _, lc := range d.Added {
+		sb.WriteString("+ listener " + lc.describe() + "\n")
+	}
+	for _, lc := range d.Removed {
+		sb.WriteString("- listener " + lc.describe() + "\n")
+	}
+	for _, c := range d.Changed {
+		sb.WriteString("~ listener " + c.Old.describe() + " -> " + c.New.options() + "\n")
+	}
+	return sb.String()
+}
+
+// key the identity of the listener config
+func (lc *ListenerConfig) key() string {
+	return lc.Event + "|" + lc.Factory + "|" + lc.Label
+}
+
+func (lc *ListenerConfig) describe() string {
+	name := lc.Label
+	if name == "" {
+		name = lc.Factory
+	}
+	return lc.Event + " " + name + " " + lc.options()
+}
+
+// options string of the listener config
+func (lc *ListenerConfig) options() string {
+	s := fmt.Sprintf("priority=%d", lc.Priority)
+	if lc.Once {
+		s += " once"
+	}
+	if lc.Async {
+		s += " async"
+	}
+	if lc.Where != "" {
+		s += " where=" + lc.Where
+	}
+	return s
+}
+
+// DiffConfig compare the two wiring configs, report the changes from a to b.
+// the listeners are identified by the event name, factory and label.
+//
+// Usage:
+// 	d := event.DiffConfig(prodCfg, stagingCfg)
+// 	if !d.Empty() {
+// 		fmt.Print(d)
+// 	}
+func DiffConfig(a, b *ManagerConfig) *WiringDiff {
+	d := &WiringDiff{}
+	d.AddedEvents, d.RemovedEvents = diffStrings(a.Events, b.Events)
+
+	for name, ps := range a.Parents {
+		if strings.Join(ps, ",") != strings.Join(b.Parents[name], ",") {
+			d.ChangedParents = append(d.ChangedParents, name)
+		}
+	}
+	for name := range b.Parents {
+		if _, ok := a.Parents[name]; !ok {
+			d.ChangedParents = append(d.ChangedParents, name)
+		}
+	}
+	sort.Strings(d.ChangedParents)
+
+	// the same key listeners are compared by the order
+	olds := make(map[string][]*ListenerConfig)
+	for _, lc := range a.Listeners {
+		olds[lc.key()] = append(olds[lc.key()], lc)
+	}
+
+	for _, lc := range b.Listeners {
+		k := lc.key()
+		if len(olds[k]) == 0 {
+			d.Added = append(d.Added, lc)
+			continue
+		}
+
+		old := olds[k][0]
+		olds[k] = olds[k][1:]
+		if old.options() != lc.options() {
+			d.Changed = append(d.Changed, &ListenerChange{Old: old, New: lc})
+		}
+	}
+
+	for _, lc := range a.Listeners {
+		for _, left := range olds[lc.key()] {
+			if left == lc {
+				d.Removed = append(d.Removed, lc)
+			}
+		}
+	}
+	return d
+}
+
+// DiffManagers compare the wiring of the two managers, report the changes from a to b.
+// the Label is filled by the listener name for the listeners without label.
+func DiffManagers(a, b *Manager) *WiringDiff {
+	return DiffConfig(a.exportConfig(true), b.exportConfig(true))
+}
+
+// diffStrings returns the strings only in b (added) and only in a (removed), are sorted.
+func diffStrings(a, b []string) (added, removed []string) {
+	set := make(map[string]int)
+	for _, s := range a {
+		set[s] |= 1
+	}
+	for _, s := range b {
+		set[s] |= 2
+	}
+
+	for s, flag := range set {
+		if flag == 2 {
+			added = append(added, s)
+		} else if flag == 1 {
+			removed = append(removed, s)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return
+}
@@ -0,0 +1,195 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DispatchContext storage the state of once dispatch.
+// the state is separated from the Event, so the same Event instance can be fired concurrently.
+type DispatchContext struct {
+	event  Event
+	policy ErrorPolicy
+	errs   Errors
+	// mark the dispatch is aborted. 1: aborted
+	aborted int32
+	// mark has async listener called.
+	async bool
+	// the called listeners
+	visited []*ListenerItem
+	// retry the failed listener. see Retry()
+	retries int
+	backoff time.Duration
+	// the ctx of the fire. see Context()
+	ctx context.Context
+	// the trace of the called listeners. see WithTrace()
+	trace []TraceEntry
+	// the priority band barriers and current band. see SetBarriers()
+	barriers []int
+	curBand  int
+	inBand   bool
+	// wait the async listeners of the current band
+	pending sync.WaitGroup
+	// the event names of the dispatch chain. see Chain()
+	chain []string
+	// mark the event is a duplicate. see WithIdempotency()
+	duplicate bool
+}
+
+func newDispatchContext(e Event, policy ErrorPolicy) *DispatchContext {
+	return &DispatchContext{event: e, policy: policy}
+}
+
+// Event get the dispatched event
+func (dc *DispatchContext) Event() Event {
+	return dc.event
+}
+
+// Abort stop call the next listeners
+func (dc *DispatchContext) Abort() {
+	atomic.StoreInt32(&dc.aborted, 1)
+}
+
+// IsAborted check the dispatch is aborted
+func (dc *DispatchContext) IsAborted() bool {
+	return atomic.LoadInt32(&dc.aborted) == 1
+}
+
+// Context get the ctx of the fire, returns context.Background() if not setting.
+// the ctx carries the dispatch chain, the events fired with it will be appended to the chain.
+func (dc *DispatchContext) Context() context.Context {
+	ctx := dc.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if len(dc.chain) == 0 {
+		return ctx
+	}
+	return withChain(ctx, dc.chain)
+}
+
+// Chain get the event names of the dispatch chain, the last one is the current event.
+//
+// Usage:
+// 	em.On("order.paid", ContextListenerFunc(func(dc *DispatchContext) error {
+// 		// the chain: ["order.created", "order.paid", "mail.send"]
+// 		err, _ := em.FireContext(dc.Context(), "mail.send", nil)
+// 		return err
+// 	}))
+func (dc *DispatchContext) Chain() []string {
+	return dc.chain
+}
+
+// checkContext returns error if the ctx is done
+func (dc *DispatchContext) checkContext() error {
+	if dc.ctx == nil {
+		return nil
+	}
+
+	if err := dc.ctx.Err(); err != nil {
+		return fmt.Errorf("event: stop fire '%s': %w", dc.event.Name(), err)
+	}
+	return nil
+}
+
+// IsDuplicate check the event is dropped as a duplicate. see WithIdempotency()
+func (dc *DispatchContext) IsDuplicate() bool {
+	return dc.duplicate
+}
+
+// Visited get the called listeners, in the call order.
+func (dc *DispatchContext) Visited() []*ListenerItem {
+	return dc.visited
+}
+
+// Errors get the collected listener errors. only collected on the PolicyCollect.
+func (dc *DispatchContext) Errors() Errors {
+	return dc.errs
+}
+
+// ContextListener interface. the listener can implement it for access the DispatchContext.
+// the HandleContext() will be called instead of Handle() on dispatch.
+type ContextListener interface {
+	Listener
+	HandleContext(dc *DispatchContext) error
+}
+
+// ContextListenerFunc func definition.
+type ContextListenerFunc func(dc *DispatchContext) error
+
+// Handle event. implements the Listener interface
+func (fn ContextListenerFunc) Handle(e Event) error {
+	return fn(newDispatchContext(e, PolicyStop))
+}
+
+// HandleContext implements the ContextListener interface
+func (fn ContextListenerFunc) HandleContext(dc *DispatchContext) error {
+	return fn(dc)
+}
+
+// Dispatch fire the Event instance and return the DispatchContext.
+//
+// Usage:
+// 	em.On("app.run", ContextListenerFunc(func(dc *DispatchContext) error {
+// 		dc.Abort() // stop call next listeners, the event is not changed.
+// 		return nil
+// 	}))
+//
+// 	dc, err := em.Dispatch(e)
+// 	fmt.Println(dc.IsAborted(), len(dc.Visited()))
+func (em *Manager) Dispatch(e Event) (*DispatchContext, error) {
+	return em.dispatch(e, nil)
+}
+
+// callListener call the listener, will tag the call with pprof labels if enabled.
+// the returned error is wrapped as *ListenerError
+func (em *Manager) callListener(dc *DispatchContext, li *ListenerItem) (err error) {
+	if em.opts.PprofLabels {
+		labels := pprof.Labels("event", dc.event.Name(), "listener", li.Name())
+		pprof.Do(dc.Context(), labels, func(context.Context) {
+			err = dc.handle(li)
+		})
+	} else {
+		err = dc.handle(li)
+	}
+
+	if err != nil {
+		err = &ListenerError{Event: dc.event.Name(), Listener: li.Name(), Err: err}
+	}
+	return
+}
+
+// handle call the listener with the dispatch context
+func (dc *DispatchContext) handle(li *ListenerItem) error {
+	err := dc.call(li)
+	for i := 0; err != nil && i < dc.retries && !dc.IsAborted(); i++ {
+		if dc.backoff > 0 {
+			time.Sleep(dc.backoff)
+		}
+		err = dc.call(li)
+	}
+	return err
+}
+
+// call the listener
+func (dc *DispatchContext) call(li *ListenerItem) error {
+	if caps := li.Capabilities(); caps != CapAll {
+		return dc.guard(li, caps)
+	}
+
+	if cl, ok := li.Listener.(ContextListener); ok {
+		return cl.HandleContext(dc)
+	}
+
+	err := li.Listener.Handle(dc.event)
+	// the listener aborted by the Event.Abort()
+	if dc.event.IsAborted() {
+		dc.Abort()
+	}
+	return err
+}
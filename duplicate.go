@@ -0,0 +1,50 @@
+package event
+
+import "fmt"
+
+// findDuplicate find the registered listener item, which is same instance
+// or has same Label with the li on the event name.
+//
+// NOTICE: must be called with the lock held.
+func (em *Manager) findDuplicate(name string, li *ListenerItem) *ListenerItem {
+	lq, ok := em.listeners[name]
+	if !ok {
+		return nil
+	}
+
+	for _, item := range lq.Items() {
+		if li.Label != "" && item.Label == li.Label {
+			return item
+		}
+		if sameListener(item.Listener, li.Listener) {
+			return item
+		}
+	}
+	return nil
+}
+
+// checkDuplicate check the li is duplicate by the DuplicatePolicy.
+// returns true if the li should be skipped.
+//
+// NOTICE: must be called with the lock held.
+func (em *Manager) checkDuplicate(name string, li *ListenerItem) (bool, error) {
+	policy := em.opts.DuplicatePolicy
+	if policy == DuplicateAllow {
+		return false, nil
+	}
+
+	dup := em.findDuplicate(name, li)
+	if dup == nil {
+		return false, nil
+	}
+
+	switch policy {
+	case DuplicateIgnore:
+		return true, nil
+	case DuplicateWarn:
+		em.logf("event: the listener '%s' has been registered on the event '%s'", li.Name(), name)
+		return false, nil
+	default: // DuplicateError
+		return true, fmt.Errorf("%w: '%s' on the event '%s'", ErrDuplicateListener, li.Name(), name)
+	}
+}
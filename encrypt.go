@@ -0,0 +1,390 @@
+package event
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// EncryptedKey the key of the encrypted value, the encrypted data value is replaced by
+// a map: {"$enc": "base64 of the cipher text"}. see Encryption
+const EncryptedKey = "$enc"
+
+// EncryptionProvider interface for encrypt the event data. eg: AES-GCM, KMS envelope
+type EncryptionProvider interface {
+	// Encrypt the plain data
+	Encrypt(plain []byte) ([]byte, error)
+	// Decrypt the cipher data
+	Decrypt(data []byte) ([]byte, error)
+}
+
+// aesProvider the AES-GCM EncryptionProvider. the nonce is prepended to the cipher text.
+type aesProvider struct {
+	aead cipher.AEAD
+}
+
+// NewAESProvider create an AES-GCM EncryptionProvider, the key length should be 16, 24 or 32.
+func NewAESProvider(key []byte) (EncryptionProvider, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesProvider{aead: aead}, nil
+}
+
+// Encrypt the plain data. implements the EncryptionProvider interface
+func (p *aesProvider) Encrypt(plain []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return p.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// Decrypt the cipher data. implements the EncryptionProvider interface
+func (p *aesProvider) Decrypt(data []byte) ([]byte, error) {
+	size := p.aead.NonceSize()
+	if len(data) < size {
+		return nil, errors.New("event: the cipher data is too short")
+	}
+	return p.aead.Open(nil, data[:size], data[size:], nil)
+}
+
+// Encryption encrypt the event data at the serialization boundaries: the transports,
+// journal and the exported recordings. the listeners always receive the plain data.
+//
+// Usage:
+// 	p, err := event.NewAESProvider(key)
+// 	// encrypt the PII fields only. if no fields, the whole data will be encrypted
+// 	enc := event.NewEncryption(p, "email", "phone")
+// 	b.Codec = enc.Codec(event.DefaultCodec)
+// 	em := event.NewManager("app", event.WithEventStore(enc.EventStore(store)))
+type Encryption struct {
+	provider EncryptionProvider
+	// Fields the encrypted data keys. empty is encrypt the whole data
+	Fields []string
+}
+
+// NewEncryption create
+func NewEncryption(p EncryptionProvider, fields ...string) *Encryption {
+	if p == nil {
+		panic("event: the encryption provider cannot be nil")
+	}
+	return &Encryption{provider: p, Fields: fields}
+}
+
+// EncryptData returns the new data with the encrypted values. the given data will not be changed.
+func (enc *Encryption) EncryptData(data M) (M, error) {
+	if len(enc.Fields) == 0 {
+		if len(data) == 0 {
+			return data, nil
+		}
+
+		val, err := enc.encrypt(data)
+		if err != nil {
+			return nil, err
+		}
+		return M{EncryptedKey: val}, nil
+	}
+
+	newData := make(M, len(data))
+	for k, v := range data {
+		newData[k] = v
+	}
+
+	for _, field := range enc.Fields {
+		val, ok := data[field]
+		if !ok || val == nil {
+			continue
+		}
+
+		ev, err := enc.encrypt(val)
+		if err != nil {
+			return nil, fmt.Errorf("event: encrypt the data '%s' error: %w", field, err)
+		}
+		newData[field] = M{EncryptedKey: ev}
+	}
+	return newData, nil
+}
+
+// DecryptData returns the new data with the decrypted values. the given data will not be changed.
+func (enc *Encryption) DecryptData(data M) (M, error) {
+	// the whole data is encrypted
+	if s, ok := data[EncryptedKey].(string); ok && len(data) == 1 {
+		var plain M
+		if err := enc.decrypt(s, &plain); err != nil {
+			return nil, err
+		}
+		return plain, nil
+	}
+
+	newData := make(M, len(data))
+	for k, v := range data {
+		s, ok := encryptedOf(v)
+		if !ok {
+			newData[k] = v
+			continue
+		}
+
+		var val interface{}
+		if err := enc.decrypt(s, &val); err != nil {
+			return nil, fmt.Errorf("event: decrypt the data '%s' error: %w", k, err)
+		}
+		newData[k] = val
+	}
+	return newData, nil
+}
+
+func (enc *Encryption) encrypt(val interface{}) (string, error) {
+	bs, err := json.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	return enc.encryptBytes(bs)
+}
+
+func (enc *Encryption) decrypt(s string, ptr interface{}) error {
+	bs, err := enc.decryptBytes(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, ptr)
+}
+
+// encryptBytes encrypt the plain bytes, return the base64 of the cipher text
+func (enc *Encryption) encryptBytes(plain []byte) (string, error) {
+	bs, err := enc.provider.Encrypt(plain)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bs), nil
+}
+
+// decryptBytes decrypt the base64 of the cipher text
+func (enc *Encryption) decryptBytes(s string) ([]byte, error) {
+	bs, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return enc.provider.Decrypt(bs)
+}
+
+// encryptedOf get the cipher text from the encrypted value
+func encryptedOf(val interface{}) (s string, ok bool) {
+	if mp, isMap := val.(map[string]interface{}); isMap && len(mp) == 1 {
+		s, ok = mp[EncryptedKey].(string)
+	} else if mp, isM := val.(M); isM && len(mp) == 1 {
+		s, ok = mp[EncryptedKey].(string)
+	}
+	return
+}
+
+// Codec wrap the codec, the data is encrypted on encode and decrypted on decode.
+func (enc *Encryption) Codec(codec Codec) Codec {
+	if codec == nil {
+		codec = DefaultCodec
+	}
+	return &encryptCodec{codec: codec, enc: enc}
+}
+
+type encryptCodec struct {
+	codec Codec
+	enc   *Encryption
+}
+
+// Encode the event with the encrypted data
+func (c *encryptCodec) Encode(e Event) ([]byte, error) {
+	data, err := c.enc.EncryptData(e.Data())
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Encode(NewBasic(e.Name(), data))
+}
+
+// Decode the event and decrypt the data
+func (c *encryptCodec) Decode(bs []byte) (Event, error) {
+	e, err := c.codec.Decode(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.enc.DecryptData(e.Data())
+	if err != nil {
+		return nil, err
+	}
+	e.SetData(data)
+	return e, nil
+}
+
+// EventStore wrap the EventStore, the data is encrypted on append and decrypted on load.
+// the wrapper implements the Journal and SnapshotStore by the wrapped store,
+// the snapshot state is encrypted as a whole.
+//
+// Usage:
+// 	store := enc.EventStore(event.NewMemoryStore())
+// 	p := event.NewProjection("report", store.(event.Journal))
+func (enc *Encryption) EventStore(es EventStore) EventStore {
+	return &encryptStore{EventStore: es, enc: enc}
+}
+
+type encryptStore struct {
+	EventStore
+	enc *Encryption
+}
+
+// Append the events with the encrypted data. the given events data will not be changed,
+// the stream, version, position and time set by the store are synced back to them.
+func (s *encryptStore) Append(stream string, events ...*StoredEvent) error {
+	list := make([]*StoredEvent, len(events))
+	for i, se := range events {
+		data, err := s.enc.EncryptData(se.Data)
+		if err != nil {
+			return err
+		}
+
+		cp := *se
+		cp.Data = data
+		list[i] = &cp
+	}
+
+	if err := s.EventStore.Append(stream, list...); err != nil {
+		return err
+	}
+
+	for i, se := range events {
+		se.Stream, se.Version, se.Position, se.Time = list[i].Stream, list[i].Version, list[i].Position, list[i].Time
+	}
+	return nil
+}
+
+// ReadAll read events after the position with the decrypted data. implements the Journal
+func (s *encryptStore) ReadAll(position uint64, limit int) ([]*StoredEvent, error) {
+	j, ok := s.EventStore.(Journal)
+	if !ok {
+		return nil, fmt.Errorf("event: the event store is not support journal")
+	}
+	return s.decrypt(j.ReadAll(position, limit))
+}
+
+// SaveSnapshot save the snapshot with the encrypted state. implements the SnapshotStore
+func (s *encryptStore) SaveSnapshot(snap *Snapshot) error {
+	ss, ok := s.EventStore.(SnapshotStore)
+	if !ok {
+		return fmt.Errorf("event: the event store is not support snapshot")
+	}
+
+	val, err := s.enc.encryptBytes(snap.State)
+	if err != nil {
+		return err
+	}
+
+	state, err := json.Marshal(M{EncryptedKey: val})
+	if err != nil {
+		return err
+	}
+
+	cp := *snap
+	cp.State = state
+	return ss.SaveSnapshot(&cp)
+}
+
+// LoadSnapshot load the snapshot with the decrypted state. implements the SnapshotStore
+func (s *encryptStore) LoadSnapshot(stream string) (*Snapshot, error) {
+	ss, ok := s.EventStore.(SnapshotStore)
+	if !ok {
+		return nil, nil
+	}
+
+	snap, err := ss.LoadSnapshot(stream)
+	if err != nil || snap == nil {
+		return snap, err
+	}
+
+	var data M
+	if err = json.Unmarshal(snap.State, &data); err != nil {
+		return nil, err
+	}
+
+	val, ok := encryptedOf(data)
+	if !ok {
+		return nil, fmt.Errorf("event: the snapshot of the stream '%s' is not encrypted", stream)
+	}
+
+	cp := *snap
+	if cp.State, err = s.enc.decryptBytes(val); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Load all events of the stream with the decrypted data
+func (s *encryptStore) Load(stream string) ([]*StoredEvent, error) {
+	return s.decrypt(s.EventStore.Load(stream))
+}
+
+// LoadFrom load the events of the stream from the version with the decrypted data
+func (s *encryptStore) LoadFrom(stream string, version uint64) ([]*StoredEvent, error) {
+	return s.decrypt(s.EventStore.LoadFrom(stream, version))
+}
+
+// decrypt the loaded events, the stored events are copied for keep the stored data encrypted.
+func (s *encryptStore) decrypt(ses []*StoredEvent, err error) ([]*StoredEvent, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*StoredEvent, len(ses))
+	for i, se := range ses {
+		data, err := s.enc.DecryptData(se.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		cp := *se
+		cp.Data = data
+		list[i] = &cp
+	}
+	return list, nil
+}
+
+// WriteJSONLines write the recorded events as JSON lines with the encrypted data.
+// the output can be loaded by LoadRecording().
+func (enc *Encryption) WriteJSONLines(w io.Writer, events []*RecordedEvent) error {
+	encoder := json.NewEncoder(w)
+	for _, re := range events {
+		data, err := enc.EncryptData(re.Data)
+		if err != nil {
+			return err
+		}
+
+		if err = encoder.Encode(&RecordedEvent{Name: re.Name, Data: data, Time: re.Time}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRecording load the recorded events written by WriteJSONLines(), and decrypt the data.
+func (enc *Encryption) LoadRecording(r io.Reader) ([]*RecordedEvent, error) {
+	events, err := LoadRecording(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, re := range events {
+		if re.Data, err = enc.DecryptData(re.Data); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
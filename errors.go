@@ -0,0 +1,118 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors multi errors, returned on use the PolicyCollect
+type Errors []error
+
+// Error string
+func (es Errors) Error() string {
+	ss := make([]string, len(es))
+	for i, err := range es {
+		ss[i] = err.Error()
+	}
+	return strings.Join(ss, "; ")
+}
+
+// Unwrap get the errors, for errors.Is() and errors.As() check the each error
+func (es Errors) Unwrap() []error {
+	return es
+}
+
+// There are some errors of the event manager
+var (
+	// ErrInvalidName the event name is empty or invalid
+	ErrInvalidName = errors.New("event: invalid event name")
+	// ErrNilListener the listener cannot be nil
+	ErrNilListener = errors.New("event: the listener cannot be nil")
+	// ErrNilFactory the event factory cannot be nil
+	ErrNilFactory = errors.New("event: the event factory cannot be nil")
+	// ErrAborted the event is aborted by listener
+	ErrAborted = errors.New("event: the event is aborted")
+	// ErrClosed the manager has been closed
+	ErrClosed = errors.New("event: the manager is closed")
+	// ErrTimeout fire event timeout
+	ErrTimeout = errors.New("event: fire event timeout")
+	// ErrEventVetoed the event firing is blocked by interceptor
+	ErrEventVetoed = errors.New("event: the event is vetoed")
+	// ErrSealed the manager has been sealed, cannot change the wiring
+	ErrSealed = errors.New("event: the manager is sealed")
+	// ErrNoListener not found the listener of the event. eg: the unicast event, command
+	ErrNoListener = errors.New("event: not found the listener")
+	// ErrAmbiguousListener multi listeners for the unicast event
+	ErrAmbiguousListener = errors.New("event: multi listeners for the unicast event")
+	// ErrMaxListeners the listeners number of the event exceeds the max limit
+	ErrMaxListeners = errors.New("event: exceeds the max listeners")
+	// ErrDuplicateListener the listener has been registered on the event
+	ErrDuplicateListener = errors.New("event: the listener has been registered")
+	// ErrDuplicateEvent the event has been dispatched with the same idempotency key
+	ErrDuplicateEvent = errors.New("event: duplicate event")
+	// ErrStopped the manager is stopped. see Manager.Stop()
+	ErrStopped = errors.New("event: the manager is stopped")
+	// ErrInvalidState the lifecycle state transition is not allowed
+	ErrInvalidState = errors.New("event: invalid state transition")
+	// ErrFireLoop the event is fired recursively by the listeners, exceeds the max fire depth
+	ErrFireLoop = errors.New("event: recursive fire loop")
+	// ErrMaxDepth the dispatch chain exceeds the max dispatch depth. see DepthError
+	ErrMaxDepth = errors.New("event: exceeds the max dispatch depth")
+)
+
+// ListenerError the error returned by a listener, it's wrapped the original error.
+// the message is same as the original error.
+//
+// Usage:
+// 	var le *ListenerError
+// 	if errors.As(err, &le) {
+// 		fmt.Println(le.Event, le.Listener, le.Err)
+// 	}
+type ListenerError struct {
+	// Event the fired event name
+	Event string
+	// Listener the listener name. see ListenerItem.Name()
+	Listener string
+	// Err the original error
+	Err error
+}
+
+// Error string
+func (le *ListenerError) Error() string {
+	return le.Err.Error()
+}
+
+// Unwrap get the original error
+func (le *ListenerError) Unwrap() error {
+	return le.Err
+}
+
+// String get the error with the event and listener name
+func (le *ListenerError) String() string {
+	return fmt.Sprintf("event: the listener '%s' of '%s' error: %v", le.Listener, le.Event, le.Err)
+}
+
+// DepthError the dispatch chain exceeds the Options.MaxDispatchDepth, it's wrapped the ErrMaxDepth.
+//
+// Usage:
+// 	var de *DepthError
+// 	if errors.As(err, &de) {
+// 		fmt.Println(de.Chain)
+// 	}
+type DepthError struct {
+	// Chain the event names of the dispatch chain, the last one is the rejected event.
+	Chain []string
+	// Max the max dispatch depth
+	Max int
+}
+
+// Error string
+func (de *DepthError) Error() string {
+	return fmt.Sprintf("%s %d: %s", ErrMaxDepth.Error(), de.Max, strings.Join(de.Chain, " -> "))
+}
+
+// Unwrap get the ErrMaxDepth
+func (de *DepthError) Unwrap() error {
+	return ErrMaxDepth
+}
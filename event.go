@@ -3,6 +3,13 @@
 package event
 
 // Event interface
+//
+// The lifecycle of an event instance on fire:
+// 	- the aborted flag is reset to false before call listeners,
+// 	  check IsAborted() after fire to know a listener has stopped it.
+// 	- fire by name: the defined Event is cloned (see CloneOnFire), a new event is created otherwise.
+// 	- fire by instance: the given instance is passed to listeners, so it can be reused by next fire.
+// 	- the pooled event (see FireBatch, AcquireEvent) is reset on release, cannot be used after released.
 type Event interface {
 	Name() string
 	// Target() interface{}
@@ -15,6 +22,15 @@ type Event interface {
 	IsAborted() bool
 }
 
+// Cloner interface. the custom event can implement it for custom clone logic.
+// see Manager.Fire()
+type Cloner interface {
+	Clone() Event
+}
+
+// EventFactory func for create the custom event instance
+type EventFactory func() Event
+
 // BasicEvent a basic event struct define.
 type BasicEvent struct {
 	// event name
@@ -25,6 +41,8 @@ type BasicEvent struct {
 	target interface{}
 	// mark is aborted
 	aborted bool
+	// the called listeners of the last fire. see WithTrace()
+	trace []TraceEntry
 }
 
 // NewBasic new an basic event instance
@@ -118,6 +136,26 @@ func (e *BasicEvent) SetData(data M) Event {
 	return e
 }
 
+// reset the event for reuse
+func (e *BasicEvent) reset() {
+	e.name = ""
+	e.data = nil
+	e.target = nil
+	e.aborted = false
+	e.trace = nil
+}
+
+// Clone the event, the data map will be copied.
+func (e *BasicEvent) Clone() Event {
+	cp := *e
+	cp.trace = nil
+	cp.data = make(map[string]interface{}, len(e.data))
+	for k, v := range e.data {
+		cp.data[k] = v
+	}
+	return &cp
+}
+
 // SetTarget set event target
 func (e *BasicEvent) SetTarget(target interface{}) *BasicEvent {
 	e.target = target
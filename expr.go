@@ -0,0 +1,442 @@
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Expr the compiled filter expression, evaluated against the event.
+//
+// Syntax:
+// 	- vars: "name" is the event name, "data.amount", "data.user.name" is the value in event data
+// 	- literals: 100, 1.5, "eu", 'eu', true, false, nil
+// 	- compare: == != > >= < <=, and "in" list. eg: data.region in ["eu", "us"]
+// 	- logic: && || ! and the parentheses
+//
+// Usage:
+// 	x := event.MustCompileExpr(`data.amount > 100 && data.region == "eu"`)
+// 	em.Listen("order.*", listener, event.ListenOpts{Filter: x.Match})
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// CompileExpr compile the filter expression
+func CompileExpr(src string) (*Expr, error) {
+	p := &exprParser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.toks) {
+		return nil, p.errorf("unexpected token '%s'", p.toks[p.pos].val)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// MustCompileExpr compile the filter expression, will panic on error
+func MustCompileExpr(src string) *Expr {
+	x, err := CompileExpr(src)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// String get the source expression
+func (x *Expr) String() string {
+	return x.src
+}
+
+// Eval the expression against the event, returns the result value
+func (x *Expr) Eval(e Event) interface{} {
+	return x.root.eval(e)
+}
+
+// Match the event, returns the result is truthy. it can be used as the listener filter.
+func (x *Expr) Match(e Event) bool {
+	return truthy(x.root.eval(e))
+}
+
+// exprNode the node of expression
+type exprNode interface {
+	eval(e Event) interface{}
+}
+
+type (
+	literalNode struct {
+		val interface{}
+	}
+	pathNode struct {
+		path string
+	}
+	notNode struct {
+		node exprNode
+	}
+	logicNode struct {
+		op          string
+		left, right exprNode
+	}
+	compareNode struct {
+		op          string
+		left, right exprNode
+	}
+	inNode struct {
+		node exprNode
+		list []exprNode
+	}
+)
+
+func (n *literalNode) eval(Event) interface{} {
+	return n.val
+}
+
+func (n *pathNode) eval(e Event) interface{} {
+	switch {
+	case n.path == "name":
+		return e.Name()
+	case n.path == "data":
+		return e.Data()
+	}
+
+	val, _ := GetPath(e.Data(), n.path[5:])
+	return val
+}
+
+func (n *notNode) eval(e Event) interface{} {
+	return !truthy(n.node.eval(e))
+}
+
+func (n *logicNode) eval(e Event) interface{} {
+	left := truthy(n.left.eval(e))
+	if n.op == "&&" {
+		return left && truthy(n.right.eval(e))
+	}
+	return left || truthy(n.right.eval(e))
+}
+
+func (n *compareNode) eval(e Event) interface{} {
+	return compareValues(n.op, n.left.eval(e), n.right.eval(e))
+}
+
+func (n *inNode) eval(e Event) interface{} {
+	val := n.node.eval(e)
+	for _, item := range n.list {
+		if compareValues("==", val, item.eval(e)) {
+			return true
+		}
+	}
+	return false
+}
+
+// truthy check the value is true, non-zero number, non-empty string or not nil
+func truthy(val interface{}) bool {
+	switch typ := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return typ
+	case string:
+		return typ != ""
+	}
+
+	if f, ok := toFloat(val); ok {
+		return f != 0
+	}
+	return true
+}
+
+// toFloat convert the number value to float64
+func toFloat(val interface{}) (float64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// compareValues compare the numbers or strings. the mismatched types are only equal on "!="
+func compareValues(op string, left, right interface{}) bool {
+	var cmp int
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+
+	switch {
+	case lok && rok:
+		if lf < rf {
+			cmp = -1
+		} else if lf > rf {
+			cmp = 1
+		}
+	default:
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if lok && rok {
+			cmp = strings.Compare(ls, rs)
+			break
+		}
+
+		eq := reflect.DeepEqual(left, right)
+		switch op {
+		case "==":
+			return eq
+		case "!=":
+			return !eq
+		}
+		return false
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	}
+	return cmp <= 0 // "<="
+}
+
+// exprToken the token of expression
+type exprToken struct {
+	kind byte // 'n' number, 's' string, 'i' ident, 'o' operator
+	val  string
+	pos  int
+}
+
+// exprParser the recursive descent parser
+type exprParser struct {
+	src  string
+	toks []exprToken
+	pos  int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("event: invalid expression '%s': %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// lex split the source to tokens
+func (p *exprParser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return p.errorf("unterminated string at %d", i)
+			}
+
+			str := src[i : j+1]
+			if c == '\'' {
+				str = `"` + strings.Replace(str[1:len(str)-1], `"`, `\"`, -1) + `"`
+			}
+
+			val, err := strconv.Unquote(str)
+			if err != nil {
+				return p.errorf("invalid string at %d", i)
+			}
+			p.toks = append(p.toks, exprToken{'s', val, i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			p.toks = append(p.toks, exprToken{'n', src[i:j], i})
+			i = j
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || src[j] == '-' ||
+				src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			p.toks = append(p.toks, exprToken{'i', src[i:j], i})
+			i = j
+		default:
+			op := ""
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "&&", "||", "==", "!=", ">=", "<=":
+					op = two
+				}
+			}
+
+			if op == "" {
+				if !strings.ContainsRune("!<>()[],", rune(c)) {
+					return p.errorf("unexpected char '%c' at %d", c, i)
+				}
+				op = string(c)
+			}
+
+			p.toks = append(p.toks, exprToken{'o', op, i})
+			i += len(op)
+		}
+	}
+	return nil
+}
+
+// peek the current token, the zero token is returned at the end.
+func (p *exprParser) peek() exprToken {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return exprToken{}
+}
+
+// accept the operator token
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind == 'o' || tok.kind == 'i' {
+		for _, op := range ops {
+			if tok.val == op {
+				p.pos++
+				return op, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	for err == nil {
+		if _, ok := p.accept("||"); !ok {
+			break
+		}
+
+		var right exprNode
+		if right, err = p.parseAnd(); err == nil {
+			left = &logicNode{op: "||", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	for err == nil {
+		if _, ok := p.accept("&&"); !ok {
+			break
+		}
+
+		var right exprNode
+		if right, err = p.parseNot(); err == nil {
+			left = &logicNode{op: "&&", left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if _, ok := p.accept("!"); ok {
+		node, err := p.parseNot()
+		return &notNode{node: node}, err
+	}
+	return p.parseCompare()
+}
+
+func (p *exprParser) parseCompare() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("==", "!=", ">", ">=", "<", "<="); ok {
+		right, err := p.parsePrimary()
+		return &compareNode{op: op, left: left, right: right}, err
+	}
+
+	if _, ok := p.accept("in"); ok {
+		if _, ok = p.accept("["); !ok {
+			return nil, p.errorf("expect '[' after 'in'")
+		}
+
+		node := &inNode{node: left}
+		for {
+			item, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			node.list = append(node.list, item)
+
+			if _, ok = p.accept("]"); ok {
+				return node, nil
+			}
+			if _, ok = p.accept(","); !ok {
+				return nil, p.errorf("expect ',' or ']' in the list")
+			}
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case 0:
+		return nil, p.errorf("unexpected end")
+	case 'n':
+		p.pos++
+		f, err := strconv.ParseFloat(tok.val, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number '%s'", tok.val)
+		}
+		return &literalNode{val: f}, nil
+	case 's':
+		p.pos++
+		return &literalNode{val: tok.val}, nil
+	case 'i':
+		p.pos++
+		switch tok.val {
+		case "true", "false":
+			return &literalNode{val: tok.val == "true"}, nil
+		case "nil", "null":
+			return &literalNode{}, nil
+		case "name", "data":
+			return &pathNode{path: tok.val}, nil
+		}
+
+		if strings.HasPrefix(tok.val, "data.") && len(tok.val) > 5 {
+			return &pathNode{path: tok.val}, nil
+		}
+		return nil, p.errorf("unknown var '%s', must be 'name' or 'data.*'", tok.val)
+	}
+
+	if _, ok := p.accept("("); ok {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok = p.accept(")"); !ok {
+			return nil, p.errorf("expect ')'")
+		}
+		return node, nil
+	}
+	return nil, p.errorf("unexpected token '%s'", tok.val)
+}
@@ -0,0 +1,31 @@
+package expvars
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	em := event.NewManager("test")
+	em.On("order.created", event.ListenerFunc(func(e event.Event) error {
+		return nil
+	}))
+
+	Publish("", em)
+	v := expvar.Get("event.test")
+	assert.NotNil(t, v)
+
+	em.MustFire("order.created", nil)
+	stats := &event.Stats{}
+	assert.NoError(t, json.Unmarshal([]byte(v.String()), stats))
+	assert.Equal(t, uint64(1), stats.Fired)
+	assert.Equal(t, map[string]uint64{"order.created": 1}, stats.Events)
+
+	assert.Panics(t, func() {
+		Publish("event.test", em)
+	})
+}
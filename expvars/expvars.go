@@ -0,0 +1,34 @@
+// Package expvars publish the event manager stats by the expvar,
+// so it can be scraped from the /debug/vars with zero extra code.
+//
+// Usage:
+// 	import "github.com/gookit/event/expvars"
+//
+// 	expvars.Publish("events", em)
+// 	// GET /debug/vars
+// 	// {"events": {"fired": 10, "failed": 1, "events": {"order.created": 10}, ...}, ...}
+package expvars
+
+import (
+	"expvar"
+
+	"github.com/gookit/event"
+)
+
+// Func create the expvar.Func, it returns the latest stats of the manager.
+func Func(em *event.Manager) expvar.Func {
+	return func() interface{} {
+		return em.Stats()
+	}
+}
+
+// Publish the manager stats to expvar by the name.
+// if the name is empty, will use "event." + em.Name().
+//
+// NOTICE: it will panic if the name is already published, see expvar.Publish
+func Publish(name string, em *event.Manager) {
+	if name == "" {
+		name = "event." + em.Name()
+	}
+	expvar.Publish(name, Func(em))
+}
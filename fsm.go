@@ -0,0 +1,161 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FSM state event name prefixes.
+// when state changed, will fire "state.exited.OLD" and "state.entered.NEW"
+const (
+	StateExitedPrefix  = "state.exited."
+	StateEnteredPrefix = "state.entered."
+)
+
+// Transition a state transition definition for the StateMachine.
+type Transition struct {
+	// Event the trigger event name
+	Event string
+	// From allowed source states. empty for any state.
+	From []string
+	// To the target state
+	To string
+	// Guard check the event data, return false to reject the transition.
+	// it's called without the state machine lock, so it can read the state machine.
+	Guard func(e Event) bool
+}
+
+// allow check the transition can be applied.
+func (t *Transition) allow(state string, e Event) bool {
+	if len(t.From) > 0 {
+		var found bool
+		for _, s := range t.From {
+			if s == state {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return t.Guard == nil || t.Guard(e)
+}
+
+// StateMachine a simple finite state machine driven by events.
+//
+// Usage:
+// 	sm := NewStateMachine(em, "draft")
+// 	sm.AddTransition(Transition{Event: "doc.publish", From: []string{"draft"}, To: "published"})
+// 	em.On("state.entered.published", listener)
+//
+// 	// the transition is triggered on fire the event
+// 	em.Fire("doc.publish", nil)
+type StateMachine struct {
+	mu      sync.RWMutex
+	em      *Manager
+	current string
+	// transitions group by trigger event name
+	transitions map[string][]*Transition
+}
+
+// NewStateMachine create new state machine. if em is nil, will use the DefaultEM
+func NewStateMachine(em *Manager, initial string) *StateMachine {
+	if em == nil {
+		em = DefaultEM
+	}
+
+	return &StateMachine{
+		em:      em,
+		current: initial,
+		// transitions
+		transitions: make(map[string][]*Transition),
+	}
+}
+
+// AddTransition add a transition, will listen the trigger event on the manager.
+func (sm *StateMachine) AddTransition(t Transition) *StateMachine {
+	if t.To == "" {
+		panic("event: the transition target state cannot be empty")
+	}
+
+	sm.mu.Lock()
+	_, listened := sm.transitions[t.Event]
+	sm.transitions[t.Event] = append(sm.transitions[t.Event], &t)
+	sm.mu.Unlock()
+
+	if !listened {
+		sm.em.On(t.Event, ListenerFunc(sm.handle))
+	}
+	return sm
+}
+
+// Current get current state
+func (sm *StateMachine) Current() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.current
+}
+
+// Can check the event can trigger a transition on the current state
+func (sm *StateMachine) Can(name string, params M) bool {
+	_, t := sm.find(name, NewBasic(name, params))
+	return t != nil
+}
+
+// Trigger fire the event on the manager for trigger a transition.
+// will return error if no transition allowed.
+func (sm *StateMachine) Trigger(name string, params M) error {
+	if !sm.Can(name, params) {
+		return fmt.Errorf("event: cannot trigger '%s' on the state '%s'", name, sm.Current())
+	}
+
+	err, _ := sm.em.Fire(name, params)
+	return err
+}
+
+// find the transition of the event on the current state, returns the state and the transition.
+// the guards are called without lock.
+func (sm *StateMachine) find(name string, e Event) (string, *Transition) {
+	sm.mu.RLock()
+	state, ts := sm.current, sm.transitions[name]
+	sm.mu.RUnlock()
+
+	for _, t := range ts {
+		if t.allow(state, e) {
+			return state, t
+		}
+	}
+	return state, nil
+}
+
+// handle the trigger event. ignore it if no transition matched.
+func (sm *StateMachine) handle(e Event) error {
+	var from string
+	var t *Transition
+	for {
+		if from, t = sm.find(e.Name(), e); t == nil {
+			return nil
+		}
+
+		sm.mu.Lock()
+		// the state is changed by other transition on the guards called, find again.
+		if sm.current != from {
+			sm.mu.Unlock()
+			continue
+		}
+
+		sm.current = t.To
+		sm.mu.Unlock()
+		break
+	}
+
+	data := M{"from": from, "to": t.To, "event": e.Name()}
+	if err := sm.em.FireEvent(NewBasic(StateExitedPrefix+from, data)); err != nil {
+		return err
+	}
+
+	return sm.em.FireEvent(NewBasic(StateEnteredPrefix+t.To, data))
+}
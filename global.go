@@ -12,11 +12,41 @@ func On(name string, listener Listener, priority ...int) {
 	DefaultEM.On(name, listener, priority...)
 }
 
+// TryOn register a listener to the event, will return error instead of panic
+func TryOn(name string, listener Listener, priority ...int) error {
+	return DefaultEM.TryOn(name, listener, priority...)
+}
+
+// Listen register a listener to the event with options
+func Listen(name string, listener Listener, opts ListenOpts) {
+	DefaultEM.Listen(name, listener, opts)
+}
+
+// OnMany register a listener to multi event names
+func OnMany(names []string, listener Listener, priority ...int) {
+	DefaultEM.OnMany(names, listener, priority...)
+}
+
 // Fire fire listeners by name.
 func Fire(name string, params M) (error, Event) {
 	return DefaultEM.Fire(name, params)
 }
 
+// FireWith fire listeners by name and fire options. see Manager.FireWith()
+func FireWith(name string, params M, opts ...FireOption) (error, Event) {
+	return DefaultEM.FireWith(name, params, opts...)
+}
+
+// TryFire fire listeners by name, will return error instead of panic
+func TryFire(name string, params M) (error, Event) {
+	return DefaultEM.TryFire(name, params)
+}
+
+// FireStruct fire listeners by the struct payload. see Manager.FireStruct()
+func FireStruct(payload interface{}) (error, Event) {
+	return DefaultEM.FireStruct(payload)
+}
+
 // FireEvent fire listeners by Event instance.
 func FireEvent(e Event) error {
 	return DefaultEM.FireEvent(e)
@@ -27,7 +57,7 @@ func MustFire(name string, params M) Event {
 	return DefaultEM.MustFire(name, params)
 }
 
-// HasListeners has listeners for the event name.
+// HasListeners has matched listeners for the event name.
 func HasListeners(name string) bool {
 	return DefaultEM.HasListeners(name)
 }
@@ -41,6 +71,16 @@ func AddEvent(e Event) {
 	DefaultEM.AddEvent(e)
 }
 
+// AddEvents add multi event instances.
+func AddEvents(es ...Event) {
+	DefaultEM.AddEvents(es...)
+}
+
+// AddEventFactory add a factory for create the event instance by name
+func AddEventFactory(name string, factory EventFactory) {
+	DefaultEM.AddEventFactory(name, factory)
+}
+
 // GetEvent get event by name.
 func GetEvent(name string) (Event, bool) {
 	return DefaultEM.GetEvent(name)
@@ -0,0 +1,16 @@
+package goplugin
+
+import (
+	"testing"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	_, err := Open("not-exist.so")
+	assert.Error(t, err)
+
+	em := event.NewManager("test")
+	assert.Error(t, Load(em, "not-exist.so"))
+}
@@ -0,0 +1,54 @@
+// Package goplugin load the event plugins from the Go plugin files(.so).
+//
+// it's separated from the event package, because the std plugin package makes
+// the program dynamically linked.
+package goplugin
+
+import (
+	"fmt"
+	"plugin"
+
+	"github.com/gookit/event"
+)
+
+// Symbol the symbol name to lookup in the Go plugin file
+const Symbol = "EventPlugin"
+
+// Open the Go plugin file(.so) and lookup the Symbol.
+// the symbol must be a event.Plugin instance, or pointer to event.Plugin.
+//
+// in the plugin main package:
+// 	var EventPlugin event.Plugin = &MyPlugin{}
+func Open(file string) (event.Plugin, error) {
+	pg, err := plugin.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := pg.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p := sym.(type) {
+	case event.Plugin:
+		return p, nil
+	case *event.Plugin:
+		return *p, nil
+	}
+	return nil, fmt.Errorf("goplugin: the symbol '%s' in '%s' is not a Plugin", Symbol, file)
+}
+
+// Load open the Go plugin file and attach the plugin to the manager.
+//
+// Usage:
+// 	if err := goplugin.Load(em, "plugins/audit.so"); err != nil {
+// 		log.Fatal(err)
+// 	}
+func Load(em *event.Manager, file string) error {
+	p, err := Open(file)
+	if err != nil {
+		return err
+	}
+	return em.LoadPlugin(p)
+}
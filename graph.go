@@ -0,0 +1,222 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// There are the kinds of the graph nodes and edges
+const (
+	// node kinds
+	NodeEvent    = "event"
+	NodePattern  = "pattern"
+	NodeListener = "listener"
+	NodeManager  = "manager"
+	// edge kinds
+	EdgeListen     = "listen"
+	EdgeMatch      = "match"
+	EdgeParent     = "parent"
+	EdgeDeprecated = "deprecated"
+	EdgeBridge     = "bridge"
+)
+
+// GraphNode the node of the event flow graph
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+	// Declared the event is declared by AddEvent or AddEventFactory
+	Declared bool `json:"declared,omitempty"`
+}
+
+// GraphEdge the edge of the event flow graph
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+// Graph the event flow graph of the manager.
+//
+// the events are declared by AddEvent (the producers) and listened by the
+// listeners (the consumers), the events matched the patterns, the parents,
+// the deprecated reroutes and the bridges are exported as edges.
+type Graph struct {
+	Name  string       `json:"name"`
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// Graph export the event flow graph of the manager
+// Usage:
+// 	dot := em.Graph().DOT()
+// 	bs, err := json.Marshal(em.Graph())
+func (em *Manager) Graph() *Graph {
+	em.rLock()
+	defer em.rUnlock()
+
+	g := &Graph{Name: em.name, Nodes: []*GraphNode{}, Edges: []*GraphEdge{}}
+	nodes := make(map[string]*GraphNode)
+	addNode := func(id, kind, label string) *GraphNode {
+		if n, ok := nodes[id]; ok {
+			return n
+		}
+
+		n := &GraphNode{ID: id, Kind: kind, Label: label}
+		nodes[id] = n
+		g.Nodes = append(g.Nodes, n)
+		return n
+	}
+
+	addEvent := func(name string) string {
+		kind := NodeEvent
+		if isPattern(name) {
+			kind = NodePattern
+		}
+		return addNode("event:"+name, kind, name).ID
+	}
+
+	for _, name := range sortedKeys(em.events) {
+		addNode("event:"+name, NodeEvent, name).Declared = true
+	}
+	for _, name := range sortedKeys(em.factories) {
+		addNode("event:"+name, NodeEvent, name).Declared = true
+	}
+
+	names := make([]string, 0, len(em.listeners))
+	for name := range em.listeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		evID := addEvent(name)
+		for _, li := range em.listeners[name].Items() {
+			if b, ok := li.Listener.(*Bridge); ok {
+				mID := addNode("manager:"+b.to.name, NodeManager, b.to.name).ID
+				g.Edges = append(g.Edges, &GraphEdge{From: evID, To: mID, Kind: EdgeBridge})
+				continue
+			}
+
+			lID := addNode(fmt.Sprintf("listener:%s#%d", name, li.Seq), NodeListener, li.Name()).ID
+			g.Edges = append(g.Edges, &GraphEdge{
+				From:  evID,
+				To:    lID,
+				Kind:  EdgeListen,
+				Label: fmt.Sprintf("priority=%d", li.CurrentPriority()),
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(em.parents) {
+		evID := addEvent(name)
+		for _, parent := range em.parents[name] {
+			g.Edges = append(g.Edges, &GraphEdge{From: evID, To: addEvent(parent), Kind: EdgeParent})
+		}
+	}
+
+	for _, name := range sortedKeys(em.deprecations) {
+		d := em.deprecations[name]
+		if d.replacement != "" {
+			label := ""
+			if d.reroute {
+				label = "reroute"
+			}
+			g.Edges = append(g.Edges, &GraphEdge{From: addEvent(name), To: addEvent(d.replacement), Kind: EdgeDeprecated, Label: label})
+		}
+	}
+
+	// the events matched the patterns
+	for _, pn := range g.Nodes {
+		if pn.Kind != NodePattern {
+			continue
+		}
+
+		for _, en := range g.Nodes {
+			if en.Kind == NodeEvent && MatchName(pn.Label, en.Label) {
+				g.Edges = append(g.Edges, &GraphEdge{From: en.ID, To: pn.ID, Kind: EdgeMatch})
+			}
+		}
+	}
+	return g
+}
+
+// sortedKeys get the sorted keys of the maps
+func sortedKeys(mp interface{}) []string {
+	var keys []string
+	switch typ := mp.(type) {
+	case map[string]Event:
+		for k := range typ {
+			keys = append(keys, k)
+		}
+	case map[string]EventFactory:
+		for k := range typ {
+			keys = append(keys, k)
+		}
+	case map[string][]string:
+		for k := range typ {
+			keys = append(keys, k)
+		}
+	case map[string]*deprecation:
+		for k := range typ {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// JSON encode the graph as JSON
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
+
+// DOT render the graph as Graphviz DOT
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph " + dotQuote(g.Name) + " {\n")
+	sb.WriteString("  rankdir=LR;\n")
+
+	for _, n := range g.Nodes {
+		shape := "ellipse"
+		switch n.Kind {
+		case NodePattern:
+			shape = "diamond"
+		case NodeListener:
+			shape = "box"
+		case NodeManager:
+			shape = "box3d"
+		}
+
+		style := ""
+		if n.Declared {
+			style = ", style=bold"
+		}
+		sb.WriteString(fmt.Sprintf("  %s [label=%s, shape=%s%s];\n", dotQuote(n.ID), dotQuote(n.Label), shape, style))
+	}
+
+	for _, e := range g.Edges {
+		label := e.Kind
+		if e.Label != "" {
+			label += " " + e.Label
+		}
+
+		style := ""
+		if e.Kind != EdgeListen {
+			style = ", style=dashed"
+		}
+		sb.WriteString(fmt.Sprintf("  %s -> %s [label=%s%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(label), style))
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotQuote quote the DOT id
+func dotQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
@@ -0,0 +1,153 @@
+package event
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventUnhealthy the event name fired on a component becomes unhealthy. see ReportHealth()
+const EventUnhealthy = "manager.unhealthy"
+
+// HealthStatus the self-report of a component. eg: a listener or consumer
+type HealthStatus struct {
+	// Ready the component can handle the events
+	Ready bool `json:"ready"`
+	// Live the component is working, false means it should be restarted
+	Live bool `json:"live"`
+	// Message the optional detail. eg: the last error
+	Message string `json:"message,omitempty"`
+	// TTL the report is expired after the duration, the component is treated as not live.
+	// it's useful for the heartbeat reports. 0 is never expired.
+	TTL time.Duration `json:"ttl,omitempty"`
+	// Updated the report time, it's set by the manager.
+	Updated time.Time `json:"updated"`
+}
+
+// Healthy check the status is ready and live
+func (hs HealthStatus) Healthy() bool {
+	return hs.Ready && hs.Live
+}
+
+// expired check the status is expired at the time
+func (hs HealthStatus) expired(now time.Time) bool {
+	return hs.TTL > 0 && now.Sub(hs.Updated) > hs.TTL
+}
+
+// HealthReport the aggregated health of the components
+type HealthReport struct {
+	// Ready all components are ready
+	Ready bool `json:"ready"`
+	// Live all components are live
+	Live bool `json:"live"`
+	// Components the status by the component name
+	Components map[string]HealthStatus `json:"components"`
+}
+
+// Unhealthy get the unhealthy component names, is sorted.
+func (r *HealthReport) Unhealthy() []string {
+	var names []string
+	for name, hs := range r.Components {
+		if !hs.Healthy() {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// healthRegistry storage the component reports
+type healthRegistry struct {
+	sync.Mutex
+	items map[string]HealthStatus
+}
+
+// ReportHealth report the health status of the component. if the component becomes
+// unhealthy from healthy (or the first report is unhealthy), will fire the EventUnhealthy
+// with the data: component, ready, live, message.
+//
+// Usage:
+// 	em.ReportHealth("mailer", HealthStatus{Ready: true, Live: true})
+// 	em.ReportHealth("mailer", HealthStatus{Ready: false, Live: true, Message: err.Error()})
+//
+// 	// heartbeat
+// 	em.ReportHealth("consumer", HealthStatus{Ready: true, Live: true, TTL: time.Minute})
+func (em *Manager) ReportHealth(component string, status HealthStatus) {
+	status.Updated = time.Now()
+
+	h := &em.health
+	h.Lock()
+	if h.items == nil {
+		h.items = make(map[string]HealthStatus)
+	}
+
+	last, ok := h.items[component]
+	wasHealthy := !ok || (last.Healthy() && !last.expired(status.Updated))
+	h.items[component] = status
+	h.Unlock()
+
+	if wasHealthy && !status.Healthy() && em.HasListeners(EventUnhealthy) {
+		_, _ = em.Fire(EventUnhealthy, M{
+			"component": component,
+			"ready":     status.Ready,
+			"live":      status.Live,
+			"message":   status.Message,
+		})
+	}
+}
+
+// RemoveHealth remove the health status of the component
+func (em *Manager) RemoveHealth(component string) {
+	em.health.Lock()
+	delete(em.health.items, component)
+	em.health.Unlock()
+}
+
+// HealthReport get the aggregated health of the reported components.
+// the expired reports are treated as not live. it's healthy if no component is reported.
+func (em *Manager) HealthReport() *HealthReport {
+	now := time.Now()
+	r := &HealthReport{Ready: true, Live: true, Components: make(map[string]HealthStatus)}
+
+	em.health.Lock()
+	defer em.health.Unlock()
+
+	for name, hs := range em.health.items {
+		if hs.expired(now) {
+			hs.Live = false
+			hs.Message = "the report is expired"
+		}
+
+		r.Ready = r.Ready && hs.Ready
+		r.Live = r.Live && hs.Live
+		r.Components[name] = hs
+	}
+	return r
+}
+
+// HealthListener wrap the listener, report the component is not ready after the listener
+// fails continuously by the max failures, and report it's healthy after a success.
+//
+// Usage:
+// 	em.On("order.created", em.HealthListener("mailer", 3, mailer))
+func (em *Manager) HealthListener(component string, maxFailures int, listener Listener) Listener {
+	var failures int32
+	em.ReportHealth(component, HealthStatus{Ready: true, Live: true})
+
+	return ListenerFunc(func(e Event) error {
+		err := listener.Handle(e)
+		if err == nil {
+			if atomic.SwapInt32(&failures, 0) >= int32(maxFailures) {
+				em.ReportHealth(component, HealthStatus{Ready: true, Live: true})
+			}
+			return nil
+		}
+
+		if atomic.AddInt32(&failures, 1) >= int32(maxFailures) {
+			em.ReportHealth(component, HealthStatus{Ready: false, Live: true, Message: err.Error()})
+		}
+		return err
+	})
+}
@@ -0,0 +1,59 @@
+package httpevent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	em := event.NewManager("test")
+
+	var events []event.Event
+	em.On("http.request.*", event.ListenerFunc(func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	}))
+
+	h := Handler(em)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, em, event.FromContext(r.Context()))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, EventStart, events[0].Name())
+	assert.Equal(t, "POST", events[0].Get("method"))
+	assert.Equal(t, "/orders", events[0].Get("path"))
+	assert.Nil(t, events[0].Get("status"))
+
+	assert.Equal(t, EventEnd, events[1].Name())
+	assert.Equal(t, http.StatusCreated, events[1].Get("status"))
+	assert.Equal(t, 5, events[1].Get("bytes"))
+	assert.IsType(t, time.Duration(0), events[1].Get("duration"))
+
+	// skip and disable the start event
+	events = nil
+	mw := New(em)
+	mw.StartEvent = ""
+	mw.WithRequest = true
+	mw.Skip = func(r *http.Request) bool {
+		return r.URL.Path == "/health"
+	}
+	h = mw.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	assert.Len(t, events, 0)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Len(t, events, 1)
+	assert.Equal(t, http.StatusOK, events[0].Get("status"))
+	assert.NotNil(t, events[0].Get(RequestKey))
+}
@@ -0,0 +1,131 @@
+// Package httpevent provide the net/http middleware for fire the request lifecycle events.
+//
+// Events:
+// 	http.request.start  data: method, path, remote
+// 	http.request.end    data: method, path, remote, status, bytes, duration
+package httpevent
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// There are the default event names
+const (
+	EventStart = "http.request.start"
+	EventEnd   = "http.request.end"
+)
+
+// RequestKey the event data key of the *http.Request
+const RequestKey = "request"
+
+// Middleware fire the request lifecycle events to the manager.
+// the manager is stored to the request context, can be got by event.FromContext()
+//
+// Usage:
+// 	mw := httpevent.New(em)
+// 	http.ListenAndServe(":8080", mw.Wrap(mux))
+//
+// 	em.On(httpevent.EventEnd, event.ListenerFunc(func(e event.Event) error {
+// 		log.Printf("%s %s %d %s", e.Get("method"), e.Get("path"), e.Get("status"), e.Get("duration"))
+// 		return nil
+// 	}))
+type Middleware struct {
+	em *event.Manager
+	// StartEvent the event name of the request start. set empty to disable it.
+	StartEvent string
+	// EndEvent the event name of the request end. set empty to disable it.
+	EndEvent string
+	// Skip the request will not fire events if return true. eg: health check
+	Skip func(r *http.Request) bool
+	// WithRequest add the *http.Request to the event data by RequestKey
+	WithRequest bool
+}
+
+// New create the middleware
+func New(em *event.Manager) *Middleware {
+	return &Middleware{em: em, StartEvent: EventStart, EndEvent: EventEnd}
+}
+
+// Handler wrap the handler, it's same as the Wrap() for use as func(http.Handler) http.Handler
+func Handler(em *event.Manager) func(next http.Handler) http.Handler {
+	return New(em).Wrap
+}
+
+// Wrap the next handler
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(event.NewContext(r.Context(), m.em))
+		if m.Skip != nil && m.Skip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		m.fire(r, m.StartEvent, m.data(r))
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			data := m.data(r)
+			data["status"] = rw.status
+			data["bytes"] = rw.bytes
+			data["duration"] = time.Since(start)
+			m.fire(r, m.EndEvent, data)
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (m *Middleware) data(r *http.Request) event.M {
+	data := event.M{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"remote": r.RemoteAddr,
+	}
+
+	if m.WithRequest {
+		data[RequestKey] = r
+	}
+	return data
+}
+
+func (m *Middleware) fire(r *http.Request, name string, data event.M) {
+	if name != "" {
+		_, _ = m.em.FireContext(r.Context(), name, data)
+	}
+}
+
+// responseWriter record the status and the written bytes
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	wrote  bool
+}
+
+// WriteHeader record the status
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.status = status
+		w.wrote = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write record the bytes
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements the http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
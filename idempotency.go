@@ -0,0 +1,166 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IdempotencyKey the event data key for the idempotency key of the event. see WithIdempotency()
+const IdempotencyKey = "__idempotency_key"
+
+// Idempotent interface. the event can implement it for provide the idempotency key.
+type Idempotent interface {
+	IdempotencyKey() string
+}
+
+// IdempotencyStore storage the claimed idempotency keys.
+type IdempotencyStore interface {
+	// Claim the key for the window, returns false if the key has been claimed in the window.
+	Claim(key string, window time.Duration) (bool, error)
+	// Release the claimed key, so the event can be fired again. eg: the dispatch is failed.
+	Release(key string) error
+}
+
+// IdempotencyConfig config for the duplicate events suppression.
+//
+// the event has an idempotency key will be dispatched only once in the window,
+// the duplicates are dropped, counted by Stats().Duplicates and reported by the
+// meta-event MetaEventDuplicate. the event without key will be dispatched as usual.
+type IdempotencyConfig struct {
+	// Window the duration of suppress the duplicate events. default is 10 minutes
+	Window time.Duration
+	// Store for the claimed keys. default is NewMemoryIdempotencyStore()
+	Store IdempotencyStore
+	// KeyFunc get the idempotency key of the event. default is IdempotencyKeyOf
+	KeyFunc func(e Event) string
+	// KeepOnError keep the key claimed on the dispatch returns error.
+	// default is release it, so the retries of the failed event can be dispatched.
+	KeepOnError bool
+}
+
+// WithIdempotency enable suppress the duplicate events by the idempotency key.
+// Usage:
+// 	em := NewManager("app", WithIdempotency(IdempotencyConfig{Window: time.Hour}))
+// 	// the retries of the upstream will be dispatched only once
+// 	em.Fire("payment.captured", M{IdempotencyKey: paymentID, "amount": 100})
+func WithIdempotency(cfg IdempotencyConfig) Option {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Minute
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryIdempotencyStore()
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = IdempotencyKeyOf
+	}
+
+	return func(o *Options) {
+		o.Idempotency = &cfg
+	}
+}
+
+// IdempotencyKeyOf get the idempotency key of the event.
+// from the Idempotent interface, or the event data by the IdempotencyKey.
+func IdempotencyKeyOf(e Event) string {
+	if ie, ok := e.(Idempotent); ok {
+		return ie.IdempotencyKey()
+	}
+
+	key, _ := e.Get(IdempotencyKey).(string)
+	return key
+}
+
+// claimKey claim the idempotency key of the event, returns the claimed key.
+// dup is true if the event is a duplicate.
+func (em *Manager) claimKey(e Event) (key string, dup bool, err error) {
+	cfg := em.opts.Idempotency
+	if cfg == nil {
+		return
+	}
+
+	if key = cfg.KeyFunc(e); key == "" {
+		return
+	}
+
+	// the same key of different events are not duplicates
+	key = e.Name() + ":" + key
+	ok, err := cfg.Store.Claim(key, cfg.Window)
+	if err != nil {
+		return "", false, fmt.Errorf("event: claim the idempotency key of '%s' error: %w", e.Name(), err)
+	}
+
+	if !ok {
+		atomic.AddUint64(&em.stats.duplicates, 1)
+		em.emitMeta(MetaEventDuplicate, e.Name(), "", fmt.Errorf("%w '%s'", ErrDuplicateEvent, key))
+		return "", true, nil
+	}
+	return key, false, nil
+}
+
+// releaseKey release the claimed key on the dispatch is failed
+func (em *Manager) releaseKey(key string, err error) {
+	if key == "" || err == nil || em.opts.Idempotency.KeepOnError {
+		return
+	}
+
+	if rErr := em.opts.Idempotency.Store.Release(key); rErr != nil {
+		em.logf("event: release the idempotency key '%s' error: %v", key, rErr)
+	}
+}
+
+/*************************************************************
+ * Memory idempotency store
+ *************************************************************/
+
+// MemoryIdempotencyStore an in-memory IdempotencyStore, the expired keys are purged on claim.
+type MemoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]time.Time
+	// the next time for purge the expired keys
+	purgeAt time.Time
+}
+
+// NewMemoryIdempotencyStore create
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{keys: make(map[string]time.Time)}
+}
+
+// Claim the key for the window. implements the IdempotencyStore interface
+func (s *MemoryIdempotencyStore) Claim(key string, window time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.purgeAt) {
+		for k, expire := range s.keys {
+			if !now.Before(expire) {
+				delete(s.keys, k)
+			}
+		}
+		s.purgeAt = now.Add(window)
+	}
+
+	if expire, ok := s.keys[key]; ok && now.Before(expire) {
+		return false, nil
+	}
+
+	s.keys[key] = now.Add(window)
+	return true, nil
+}
+
+// Release the claimed key. implements the IdempotencyStore interface
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	delete(s.keys, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Len get the number of the claimed keys, include the expired keys not purged.
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
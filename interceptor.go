@@ -0,0 +1,51 @@
+package event
+
+// Interceptor func, called before dispatch the event to listeners.
+// return error for block the event entirely. eg: return ErrEventVetoed
+type Interceptor func(e Event) error
+
+// AddInterceptor add interceptors to the manager. interceptors will be called
+// by the added order, the first returned error will veto the event firing.
+//
+// Usage:
+// 	em.AddInterceptor(func(e Event) error {
+// 		if !isAllowed(e) {
+// 			return ErrEventVetoed
+// 		}
+// 		return nil
+// 	})
+func (em *Manager) AddInterceptor(fns ...Interceptor) {
+	em.mustNotSealed()
+	em.lock()
+	em.interceptors = append(em.interceptors, fns...)
+	em.unlock()
+}
+
+// intercept call interceptors for the event
+func (em *Manager) intercept(e Event) error {
+	em.rLock()
+	fns := em.interceptors
+	em.rUnlock()
+
+	for _, fn := range fns {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VetoNames create an interceptor for block the given event names. can be used as kill switch.
+func VetoNames(names ...string) Interceptor {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+
+	return func(e Event) error {
+		if set[e.Name()] {
+			return ErrEventVetoed
+		}
+		return nil
+	}
+}
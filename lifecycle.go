@@ -0,0 +1,111 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// State the lifecycle state of the manager
+type State int32
+
+// There are the lifecycle states.
+//
+// 	created -> running <-> paused
+// 	running|paused -> stopped -> running (restart)
+const (
+	// StateCreated the manager is created, not started. the fires are allowed.
+	StateCreated State = iota
+	// StateRunning the manager is started by Start()
+	StateRunning
+	// StatePaused all fires are dropped, see PauseAll(). the registrations are allowed.
+	StatePaused
+	// StateStopped the fires and registrations are rejected with ErrStopped. see Stop()
+	StateStopped
+)
+
+// String get the state name
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateRunning:
+		return "running"
+	case StatePaused:
+		return "paused"
+	case StateStopped:
+		return "stopped"
+	}
+	return fmt.Sprintf("State(%d)", int32(s))
+}
+
+// There are the lifecycle meta-events, fired on the Meta() manager.
+// the event data: manager, from, to
+const (
+	MetaManagerStarted = "manager.started"
+	MetaManagerPaused  = "manager.paused"
+	MetaManagerResumed = "manager.resumed"
+	MetaManagerStopped = "manager.stopped"
+)
+
+// State get the lifecycle state of the manager
+func (em *Manager) State() State {
+	return State(atomic.LoadInt32(&em.state))
+}
+
+// PauseAll pause the running manager, all fires will be dropped and counted by Stats().Paused.
+// use Pause() for pause the specified events.
+func (em *Manager) PauseAll() error {
+	return em.transition(MetaManagerPaused, StatePaused, StateRunning)
+}
+
+// ResumeAll resume the paused manager
+func (em *Manager) ResumeAll() error {
+	return em.transition(MetaManagerResumed, StateRunning, StatePaused)
+}
+
+// Stop the manager, will wait the pending async works done or the ctx is done.
+// the fires and registrations will be rejected with ErrStopped, until restart by Start().
+// the listeners are kept, use Shutdown() for close the listeners.
+func (em *Manager) Stop(ctx context.Context) error {
+	if err := em.transition(MetaManagerStopped, StateStopped, StateRunning, StatePaused); err != nil {
+		return err
+	}
+	return em.waitPending(ctx)
+}
+
+// Restart stop and start the manager. if the manager is stopped, only start it.
+// the closed manager cannot be restarted, will return ErrClosed.
+func (em *Manager) Restart(ctx context.Context) error {
+	if em.IsClosed() {
+		return ErrClosed
+	}
+
+	if em.State() != StateStopped {
+		if err := em.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	return em.Start(ctx)
+}
+
+// transition change the state to the target from the allowed states, and emit the meta-event.
+func (em *Manager) transition(meta string, to State, from ...State) error {
+	for _, s := range from {
+		if atomic.CompareAndSwapInt32(&em.state, int32(s), int32(to)) {
+			em.emitState(meta, s, to)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: cannot change the state from %s to %s", ErrInvalidState, em.State(), to)
+}
+
+// emitState fire the lifecycle meta-event
+func (em *Manager) emitState(name string, from, to State) {
+	meta, ok := em.meta.Load().(*Manager)
+	if !ok || !meta.HasListeners(name) {
+		return
+	}
+	_, _ = meta.Fire(name, M{"manager": em.name, "from": from.String(), "to": to.String()})
+}
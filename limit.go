@@ -0,0 +1,53 @@
+package event
+
+import "fmt"
+
+// SetMaxListeners setting the max listeners number of the event name, will override
+// the Options.MaxListeners. 0 is unlimited.
+//
+// Usage:
+// 	em := NewManager("app", WithMaxListeners(10, false))
+// 	em.SetMaxListeners("app.tick", 100)
+func (em *Manager) SetMaxListeners(name string, n int) {
+	name = em.goodName(name)
+
+	em.lock()
+	em.maxListeners[name] = n
+	delete(em.maxWarned, name)
+	em.unlock()
+}
+
+// MaxListeners get the max listeners number of the event name. 0 is unlimited.
+func (em *Manager) MaxListeners(name string) int {
+	em.rLock()
+	defer em.rUnlock()
+	return em.maxListenersOf(em.normalize(name))
+}
+
+func (em *Manager) maxListenersOf(name string) int {
+	if n, ok := em.maxListeners[name]; ok {
+		return n
+	}
+	return em.opts.MaxListeners
+}
+
+// checkMaxListeners check can add a new listener to the event name.
+// will return error on strict mode, otherwise log a one-time warning.
+//
+// NOTICE: must be called with the lock held.
+func (em *Manager) checkMaxListeners(name string) error {
+	max := em.maxListenersOf(name)
+	if max <= 0 || em.listenedNames[name] < max {
+		return nil
+	}
+
+	if em.opts.StrictMaxListeners {
+		return fmt.Errorf("%w: the event '%s' has %d listeners", ErrMaxListeners, name, max)
+	}
+
+	if !em.maxWarned[name] {
+		em.maxWarned[name] = true
+		em.logf("event: possible listener leak, the event '%s' has more than %d listeners", name, max)
+	}
+	return nil
+}
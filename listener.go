@@ -1,8 +1,9 @@
 package event
 
 import (
-	"fmt"
+	"reflect"
 	"sort"
+	"sync/atomic"
 )
 
 // Listener interface
@@ -28,20 +29,132 @@ type Subscriber interface {
 }
 
 // ListenerItem storage a event listener and it's priority value.
+//
+// NOTICE: the new fields will be added, please create it with the keyed fields.
+// eg: ListenerItem{Priority: High, Listener: listener}
 type ListenerItem struct {
 	Priority int
 	Listener Listener
+	// Label an optional name for the listener. useful for debug and remove.
+	Label string
+	// Once the listener will be removed after it's first call.
+	Once bool
+	// Async the listener will be called in a new goroutine. error will be ignored.
+	Async bool
+	// Filter the listener will be skipped if return false.
+	Filter func(e Event) bool
+	// Weight for select the listener on the Weighted delivery. default is 1
+	Weight int
+	// Alive check the listener owner is alive before call it. if return false,
+	// the listener will be skipped and removed.
+	Alive func() bool
+	// Observer the listener will receive the ReadOnlyEvent, cannot change the data or abort it.
+	Observer bool
+	// Caps the declared capabilities of the listener. 0 is all capabilities. see Capability
+	Caps Capability
+	// Seq the registration sequence number, it's set by the manager.
+	// listeners with same priority will be called by the registration order.
+	Seq uint64
+	// Factory the listener factory name, if the listener is created by factory.
+	Factory string
+	// mark the listener is imported by config
+	imported bool
+	// the filter expression on import by config
+	where string
+	// runtime state, used by the adaptive mode.
+	state listenerState
+}
+
+// claimOnce mark the once listener is called, returns false if it has been called.
+func (li *ListenerItem) claimOnce() bool {
+	return atomic.CompareAndSwapInt32(&li.state.called, 0, 1)
+}
+
+// ListenOpts options for register a listener. see Manager.Listen()
+type ListenOpts struct {
+	// Priority of the listener. default is Normal
+	Priority int
+	// Once remove the listener after it's first call
+	Once bool
+	// Async call the listener in a new goroutine
+	Async bool
+	// Label name for the listener
+	Label string
+	// Filter check the event before call the listener
+	Filter func(e Event) bool
+	// Weight for select the listener on the Weighted delivery
+	Weight int
+	// Alive check the listener owner is alive, the listener will be removed if return false
+	Alive func() bool
+	// Observer the listener will receive the ReadOnlyEvent
+	Observer bool
+	// Caps the declared capabilities of the listener
+	Caps Capability
+}
+
+// newListenerItem create a listener item by the options
+func newListenerItem(listener Listener, opts ListenOpts) *ListenerItem {
+	return &ListenerItem{
+		Priority: opts.Priority,
+		Listener: listener,
+		Label:    opts.Label,
+		Once:     opts.Once,
+		Async:    opts.Async,
+		Filter:   opts.Filter,
+		Weight:   opts.Weight,
+		Alive:    opts.Alive,
+		Observer: opts.Observer,
+		Caps:     opts.Caps,
+	}
+}
+
+// weight get the listener weight, at least 1
+func (li *ListenerItem) weight() int {
+	if li.Weight < 1 {
+		return 1
+	}
+	return li.Weight
 }
 
 /*************************************************************
  * Listener Queue
  *************************************************************/
 
+// Queue interface for storage the listeners of a listened name.
+// the custom implementation can be set by WithQueueFactory(). eg: rotate the
+// listeners with same priority for round-robin.
+//
+// NOTICE: the methods are called with the manager lock. the Items() result will
+// be called without lock, so the returned slice should not be modified after returned.
+type Queue interface {
+	// Len get the listeners count
+	Len() int
+	// IsEmpty check the queue is empty
+	IsEmpty() bool
+	// Add a listener item to the queue
+	Add(li *ListenerItem)
+	// Remove listener items by the listener
+	Remove(listener Listener)
+	// RemoveItem remove the listener item
+	RemoveItem(li *ListenerItem)
+	// Items get the listener items by the call order
+	Items() []*ListenerItem
+	// Clear all listener items
+	Clear()
+}
+
 // ListenerQueue storage sorted Listener instance.
+// the items are kept sorted on push, and the items slice is copy-on-write,
+// so the Items() result can be safe used without lock and copy.
 type ListenerQueue struct {
 	items []*ListenerItem
 }
 
+// NewListenerQueue create
+func NewListenerQueue() *ListenerQueue {
+	return &ListenerQueue{}
+}
+
 // Len get items length
 func (lq *ListenerQueue) Len() int {
 	return len(lq.items)
@@ -52,30 +165,47 @@ func (lq *ListenerQueue) IsEmpty() bool {
 	return len(lq.items) == 0
 }
 
-// Push get items length
+// Push insert the listener item by the priority
 func (lq *ListenerQueue) Push(li *ListenerItem) *ListenerQueue {
-	lq.items = append(lq.items, li)
+	// find the first item which should be after the new item
+	pos := sort.Search(len(lq.items), func(i int) bool {
+		return lessItem(li, lq.items[i])
+	})
+
+	items := make([]*ListenerItem, len(lq.items)+1)
+	copy(items, lq.items[:pos])
+	items[pos] = li
+	copy(items[pos+1:], lq.items[pos:])
+
+	lq.items = items
 	return lq
 }
 
+// Add a listener item. implements the Queue interface
+func (lq *ListenerQueue) Add(li *ListenerItem) {
+	lq.Push(li)
+}
+
 // Sort the queue items by ListenerItem's priority.
+// the items is sorted on push, only need to call it after changed the priority of items.
+// the sort is stable, listeners with same priority will keep the registration order.
 // Priority:
 // 	High > Low
 func (lq *ListenerQueue) Sort() *ListenerQueue {
-	// if lq.IsEmpty() {
-	// 	return lq
-	// }
 	ls := ByPriorityItems(lq.items)
 
 	// check items is sorted
 	if !sort.IsSorted(ls) {
-		sort.Sort(ls)
+		items := make(ByPriorityItems, len(lq.items))
+		copy(items, lq.items)
+		sort.Stable(items)
+		lq.items = items
 	}
 
 	return lq
 }
 
-// Items get all ListenerItem
+// Items get all ListenerItem, the returned slice should not be modified.
 func (lq *ListenerQueue) Items() []*ListenerItem {
 	return lq.items
 }
@@ -86,11 +216,9 @@ func (lq *ListenerQueue) Remove(listener Listener) {
 		return
 	}
 
-	ptrVal := fmt.Sprintf("%p", listener)
-
 	var newItems []*ListenerItem
 	for _, li := range lq.items {
-		if fmt.Sprintf("%p", li.Listener) == ptrVal {
+		if sameListener(li.Listener, listener) {
 			continue
 		}
 
@@ -100,9 +228,45 @@ func (lq *ListenerQueue) Remove(listener Listener) {
 	lq.items = newItems
 }
 
+// sameListener check the two listeners is same instance.
+// the pointer listeners are compared by the address, the func listeners
+// are compared by the code, so the method values of the different
+// receivers are same. use RemoveItem or Scope for remove them.
+func sameListener(a, b Listener) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+
+	switch va.Kind() {
+	case reflect.Ptr, reflect.Func, reflect.Chan, reflect.Map, reflect.Slice, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	if !va.Type().Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// RemoveItem remove the listener item from the queue
+func (lq *ListenerQueue) RemoveItem(item *ListenerItem) {
+	var newItems []*ListenerItem
+	for _, li := range lq.items {
+		if li != item {
+			newItems = append(newItems, li)
+		}
+	}
+
+	lq.items = newItems
+}
+
 // Clear clear all listeners
 func (lq *ListenerQueue) Clear() {
-	lq.items = lq.items[:0]
+	lq.items = nil
 }
 
 /*************************************************************
@@ -118,8 +282,17 @@ func (ls ByPriorityItems) Len() int {
 }
 
 // Less implements the sort.Interface.Less.
+// higher priority first, if priority is same, smaller Seq first.
 func (ls ByPriorityItems) Less(i, j int) bool {
-	return ls[i].Priority > ls[j].Priority
+	return lessItem(ls[i], ls[j])
+}
+
+// lessItem check the item a should be called before the item b
+func lessItem(a, b *ListenerItem) bool {
+	if pa, pb := a.EffectivePriority(), b.EffectivePriority(); pa != pb {
+		return pa > pb
+	}
+	return a.Seq < b.Seq
 }
 
 // Swap implements the sort.Interface.Swap.
@@ -0,0 +1,151 @@
+package listeners
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMailbox struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (b *testMailbox) send(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	b.mu.Lock()
+	b.msgs = append(b.msgs, string(msg))
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *testMailbox) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.msgs)
+}
+
+func TestMailer(t *testing.T) {
+	box := &testMailbox{}
+	em := event.NewManager("test")
+
+	m := NewMailer("localhost:25", "bot@example.com", "ops@example.com", "dev@example.com")
+	m.SendMail = box.send
+	em.On("alarm.*", m)
+
+	em.MustFire("alarm.fire", event.M{"room": 101})
+	assert.Equal(t, 1, box.count())
+	assert.Contains(t, box.msgs[0], "To: ops@example.com, dev@example.com\r\n")
+	assert.Contains(t, box.msgs[0], "Subject: [event] alarm.fire\r\n")
+	assert.Contains(t, box.msgs[0], "alarm.fire map[room:101]\n")
+
+	// digest
+	m.Digest = 10 * time.Millisecond
+	m.MaxBatch = 3
+	em.MustFire("alarm.fire", nil)
+	em.MustFire("alarm.smoke", nil)
+	assert.Equal(t, 2, m.Pending())
+	assert.Equal(t, 1, box.count())
+
+	for box.count() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 0, m.Pending())
+	assert.Contains(t, box.msgs[1], "Subject: [event] alarm.fire and 2 events\r\n")
+
+	// reach the max batch
+	m.Digest = time.Hour
+	for i := 0; i < 3; i++ {
+		em.MustFire("alarm.fire", nil)
+	}
+	assert.Equal(t, 3, box.count())
+	assert.NoError(t, m.Flush())
+	assert.Equal(t, 3, box.count())
+
+	// the subject from the event data
+	m.Digest = 0
+	m.Subject = template.Must(template.New("subject").Parse(`{{.Entry.Data.title}}`))
+	em.MustFire("alarm.fire", event.M{"title": "fire\r\nBcc: evil@example.com"})
+	assert.Contains(t, box.msgs[3], "Subject: fire Bcc: evil@example.com\r\n")
+	assert.NotContains(t, strings.SplitN(box.msgs[3], "\r\n\r\n", 2)[0], "\r\nBcc:")
+	em.MustFire("alarm.fire", event.M{"title": "火警"})
+	assert.Contains(t, box.msgs[4], "Subject: =?utf-8?q?=E7=81=AB=E8=AD=A6?=\r\n")
+}
+
+func TestNotifier(t *testing.T) {
+	var payloads []map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+		if payload["text"] == "*alarm.bad*" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	em := event.NewManager("test")
+	n := NewNotifier(srv.URL, Slack)
+	n.Limit = 2
+	em.On("alarm.*", n)
+
+	em.MustFire("alarm.fire", event.M{"room": 101})
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "*alarm.fire* room=101", payloads[0]["text"])
+
+	err := em.FireEvent(event.NewBasic("alarm.bad", nil))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "400")
+
+	// over the limit
+	em.MustFire("alarm.fire", nil)
+	em.MustFire("alarm.fire", nil)
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, 2, n.Suppressed())
+
+	// next window
+	n.Format = Teams
+	n.Interval = time.Millisecond
+	time.Sleep(2 * time.Millisecond)
+	em.MustFire("alarm.smoke", nil)
+	assert.Len(t, payloads, 3)
+	assert.Equal(t, "MessageCard", payloads[2]["@type"])
+	assert.Equal(t, "alarm.smoke", payloads[2]["summary"])
+	assert.Equal(t, "*alarm.smoke*\n(2 events suppressed)", payloads[2]["text"])
+	assert.Equal(t, 0, n.Suppressed())
+
+	n.Renderer = event.NewPathRenderer("{name} in room {data.room}")
+	em.MustFire("alarm.fire", event.M{"room": 101})
+	assert.Equal(t, "alarm.fire in room 101", payloads[3]["text"])
+}
+
+func TestExec(t *testing.T) {
+	em := event.NewManager("test")
+	em.On("deploy.done", NewExec("sh", "-c", `echo "$EVENT_NAME $EVENT_DATA_APP_NAME"; cat`))
+
+	e := event.NewBasic("deploy.done", event.M{"app-name": "shop"})
+	assert.NoError(t, em.FireEvent(e))
+	assert.Equal(t, 0, e.Get(ExecCodeKey))
+	assert.Equal(t, "deploy.done shop\n{\"name\":\"deploy.done\",\"data\":{\"app-name\":\"shop\"}}", e.Get(ExecOutputKey))
+
+	x := NewExec("sh", "-c", "echo failed; exit 3")
+	err := x.Handle(e)
+	assert.Error(t, err)
+	assert.Equal(t, 3, err.(*ExecError).Code)
+	assert.Equal(t, "listeners: the command exit with code 3: failed", err.Error())
+	assert.Equal(t, 3, e.Get(ExecCodeKey))
+
+	x = NewExec("sleep", "1")
+	x.Timeout = 10 * time.Millisecond
+	assert.Error(t, x.Handle(e))
+
+	assert.Error(t, NewExec("not-exist-command").Handle(e))
+}
@@ -0,0 +1,124 @@
+package listeners
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// There are the event data keys for the command result
+const (
+	ExecOutputKey = "__exec_output"
+	ExecCodeKey   = "__exec_code"
+)
+
+// ExecError the error of the command exit with non-zero code
+type ExecError struct {
+	Code   int
+	Output string
+}
+
+// Error string
+func (e *ExecError) Error() string {
+	return fmt.Sprintf("listeners: the command exit with code %d: %s", e.Code, strings.TrimSpace(e.Output))
+}
+
+// Exec the listener for run the external command.
+//
+// the event is encoded by the Codec and written to the command stdin, and
+// also provided as the env vars:
+// 	EVENT_NAME=order.created
+// 	EVENT_DATA_ID=23 // the data key "id"
+//
+// the output and exit code will be set to the event data ExecOutputKey and ExecCodeKey.
+//
+// Usage:
+// 	em.On("deploy.done", listeners.NewExec("./notify.sh", "--channel", "ops"))
+type Exec struct {
+	Name string
+	Args []string
+	// Dir the working dir of the command
+	Dir string
+	// Env the extra env vars. eg: "KEY=value"
+	Env []string
+	// Timeout for run the command. 0 is no timeout
+	Timeout time.Duration
+	// Codec for encode the event to stdin. default is event.DefaultCodec
+	Codec event.Codec
+	// EnvPrefix the prefix of the event env vars. default is "EVENT_"
+	EnvPrefix string
+}
+
+// NewExec create a command listener
+func NewExec(name string, args ...string) *Exec {
+	return &Exec{
+		Name:      name,
+		Args:      args,
+		Codec:     event.DefaultCodec,
+		EnvPrefix: "EVENT_",
+	}
+}
+
+// Handle the event, run the command
+func (x *Exec) Handle(e event.Event) error {
+	ctx := context.Background()
+	if x.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, x.Timeout)
+		defer cancel()
+	}
+
+	stdin, err := x.Codec.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	out := new(bytes.Buffer)
+	cmd := exec.CommandContext(ctx, x.Name, x.Args...)
+	cmd.Dir = x.Dir
+	cmd.Env = append(append(os.Environ(), x.Env...), x.envVars(e)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	err = cmd.Run()
+	e.Set(ExecOutputKey, out.String())
+
+	if ee, ok := err.(*exec.ExitError); ok {
+		code := ee.ExitCode()
+		e.Set(ExecCodeKey, code)
+		return &ExecError{Code: code, Output: out.String()}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	e.Set(ExecCodeKey, 0)
+	return nil
+}
+
+// envVars build the env vars of the event
+func (x *Exec) envVars(e event.Event) []string {
+	prefix := x.EnvPrefix
+	vars := []string{prefix + "NAME=" + e.Name()}
+
+	for k, v := range e.Data() {
+		if strings.HasPrefix(k, "__") {
+			continue
+		}
+
+		key := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(k))
+		vars = append(vars, fmt.Sprintf("%sDATA_%s=%v", prefix, key, v))
+	}
+
+	sort.Strings(vars[1:])
+	return vars
+}
@@ -0,0 +1,49 @@
+// Package listeners provide some built-in listeners. eg: mail, webhook notifier
+package listeners
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// Entry the snapshot of the handled event, it's used as the template data.
+//
+// NOTICE: the event instance may be reused after dispatch, so the listeners
+// that handle events later should keep the Entry instead of the event.
+type Entry struct {
+	Name string
+	Data event.M
+	Time time.Time
+}
+
+// NewEntry create entry from the event
+func NewEntry(e event.Event) *Entry {
+	data := make(event.M, len(e.Data()))
+	for k, v := range e.Data() {
+		data[k] = v
+	}
+	return &Entry{Name: e.Name(), Data: data, Time: time.Now()}
+}
+
+// TplData the template data, the Entry is the first entry of the Entries.
+type TplData struct {
+	*Entry
+	Entries []*Entry
+}
+
+// newTplData create the template data for the entries
+func newTplData(entries []*Entry) *TplData {
+	return &TplData{Entry: entries[0], Entries: entries}
+}
+
+// render the template by the data
+func render(tpl *template.Template, data interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
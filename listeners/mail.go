@@ -0,0 +1,154 @@
+package listeners
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// There are the default mail templates
+var (
+	DefaultMailSubject = template.Must(template.New("subject").Funcs(event.TemplateFuncs).Parse(
+		`[event] {{.Name}}{{if gt (len .Entries) 1}} and {{len .Entries}} events{{end}}`,
+	))
+	DefaultMailBody = template.Must(template.New("body").Funcs(event.TemplateFuncs).Parse(
+		`{{range .Entries}}{{.Time.Format "2006-01-02 15:04:05"}} {{.Name}} {{.Data}}
+{{end}}`,
+	))
+)
+
+// SendMailFunc func for send the mail. see smtp.SendMail
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Mailer the listener for send events by email.
+//
+// if the Digest is setting, the events will be batched and sent as one
+// mail per Digest interval, it's useful for throttle the noisy events.
+//
+// Usage:
+// 	m := listeners.NewMailer("smtp.example.com:587", "bot@example.com", "ops@example.com")
+// 	m.Auth = smtp.PlainAuth("", "bot@example.com", "password", "smtp.example.com")
+// 	m.Digest = 5 * time.Minute
+// 	em.On("alarm.*", m)
+type Mailer struct {
+	mu      sync.Mutex
+	pending []*Entry
+	timer   *time.Timer
+	// SMTP settings
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+	// Subject and Body templates, the data is *TplData
+	Subject *template.Template
+	Body    *template.Template
+	// Digest the interval for batch send events. 0 is send immediately
+	Digest time.Duration
+	// MaxBatch send the digest immediately when the pending events reach it. 0 is unlimited
+	MaxBatch int
+	// SendMail func. default is smtp.SendMail
+	SendMail SendMailFunc
+	// OnError callback on send the digest mail error. optional
+	OnError func(err error)
+}
+
+// NewMailer create a mail listener
+func NewMailer(addr, from string, to ...string) *Mailer {
+	return &Mailer{
+		Addr:     addr,
+		From:     from,
+		To:       to,
+		Subject:  DefaultMailSubject,
+		Body:     DefaultMailBody,
+		SendMail: smtp.SendMail,
+	}
+}
+
+// Handle the event, send the mail or add to the digest
+func (m *Mailer) Handle(e event.Event) error {
+	if m.Digest <= 0 {
+		return m.send([]*Entry{NewEntry(e)})
+	}
+
+	m.mu.Lock()
+	m.pending = append(m.pending, NewEntry(e))
+	full := m.MaxBatch > 0 && len(m.pending) >= m.MaxBatch
+	if !full && m.timer == nil {
+		m.timer = time.AfterFunc(m.Digest, func() {
+			if err := m.Flush(); err != nil && m.OnError != nil {
+				m.OnError(err)
+			}
+		})
+	}
+	m.mu.Unlock()
+
+	if full {
+		return m.Flush()
+	}
+	return nil
+}
+
+// Pending get the number of pending events
+func (m *Mailer) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// Flush send the pending events as digest mail
+func (m *Mailer) Flush() error {
+	m.mu.Lock()
+	entries := m.pending
+	m.pending = nil
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	m.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+	return m.send(entries)
+}
+
+func (m *Mailer) send(entries []*Entry) error {
+	data := newTplData(entries)
+	subject, err := render(m.Subject, data)
+	if err != nil {
+		return err
+	}
+
+	body, err := render(m.Body, data)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.From, strings.Join(m.To, ", "), encodeSubject(subject), body,
+	)
+
+	if err = m.SendMail(m.Addr, m.Auth, m.From, m.To, []byte(msg)); err != nil {
+		return fmt.Errorf("listeners: send mail error: %v", err)
+	}
+	return nil
+}
+
+// encodeSubject strip the line breaks for prevent the header injection,
+// and encode the non-ASCII subject by RFC 2047.
+func encodeSubject(subject string) string {
+	subject = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(subject)
+	for i := 0; i < len(subject); i++ {
+		if subject[i] >= 0x80 {
+			return mime.QEncoding.Encode("utf-8", subject)
+		}
+	}
+	return subject
+}
@@ -0,0 +1,152 @@
+package listeners
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// ChatFormat the payload format of the chat webhook
+type ChatFormat uint8
+
+// There are some chat formats
+const (
+	// Slack incoming webhook. payload: {"text": "..."}
+	Slack ChatFormat = iota
+	// Teams incoming webhook. payload is the MessageCard
+	Teams
+)
+
+// DefaultChatTemplate the default template for the chat message
+var DefaultChatTemplate = template.Must(template.New("chat").Funcs(event.TemplateFuncs).Parse(
+	`*{{.Name}}*{{range $k, $v := .Data}} {{$k}}={{$v}}{{end}}`,
+))
+
+// Notifier the listener for post events to the Slack/Teams incoming webhook.
+//
+// the messages are rate limited by the Limit per Interval, the over limit
+// events will be suppressed and reported on the next message.
+//
+// Usage:
+// 	n := listeners.NewNotifier("https://hooks.slack.com/services/...", listeners.Slack)
+// 	n.Limit, n.Interval = 10, time.Minute
+// 	em.On("alarm.*", n)
+type Notifier struct {
+	mu         sync.Mutex
+	window     time.Time
+	sent       int
+	suppressed int
+	// URL the incoming webhook url
+	URL    string
+	Format ChatFormat
+	// Template for render the message, the data is *TplData
+	Template *template.Template
+	// Renderer for render the message, will be used instead of the Template if setting
+	Renderer event.Renderer
+	// Client for post the message. default is http.DefaultClient
+	Client *http.Client
+	// Limit the max messages per Interval. 0 is unlimited
+	Limit    int
+	Interval time.Duration
+}
+
+// NewNotifier create a chat webhook notifier
+func NewNotifier(url string, format ChatFormat) *Notifier {
+	return &Notifier{
+		URL:      url,
+		Format:   format,
+		Template: DefaultChatTemplate,
+		Client:   http.DefaultClient,
+		Interval: time.Minute,
+	}
+}
+
+// Suppressed get the number of suppressed events in the current window
+func (n *Notifier) Suppressed() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.suppressed
+}
+
+// allow check the rate limit. returns the suppressed number before it.
+func (n *Notifier) allow() (ok bool, suppressed int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Limit <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	if now.Sub(n.window) >= n.Interval {
+		n.window = now
+		n.sent = 0
+	}
+
+	if n.sent >= n.Limit {
+		n.suppressed++
+		return false, 0
+	}
+
+	n.sent++
+	suppressed, n.suppressed = n.suppressed, 0
+	return true, suppressed
+}
+
+func (n *Notifier) render(e event.Event) (string, error) {
+	if n.Renderer != nil {
+		return n.Renderer.Render(e)
+	}
+	return render(n.Template, newTplData([]*Entry{NewEntry(e)}))
+}
+
+// Handle the event, post the message to the webhook
+func (n *Notifier) Handle(e event.Event) error {
+	ok, suppressed := n.allow()
+	if !ok {
+		return nil
+	}
+
+	text, err := n.render(e)
+	if err != nil {
+		return err
+	}
+
+	if suppressed > 0 {
+		text += fmt.Sprintf("\n(%d events suppressed)", suppressed)
+	}
+
+	var payload interface{}
+	if n.Format == Teams {
+		payload = map[string]string{
+			"@type":    "MessageCard",
+			"@context": "http://schema.org/extensions",
+			"summary":  e.Name(),
+			"text":     text,
+		}
+	} else {
+		payload = map[string]string{"text": text}
+	}
+
+	bs, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	res, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("listeners: post webhook error, status: %s", res.Status)
+	}
+	return nil
+}
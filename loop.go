@@ -0,0 +1,60 @@
+package event
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithMaxFireDepth setting the max depth of the recursive fire of each event name.
+// the listeners fire the event directly or transitively in it's dispatch will be counted,
+// the fire returns ErrFireLoop on exceeding the depth, so a loop cannot run forever.
+//
+// the depth is counted by the dispatch chain, so the listeners should fire the
+// events with the ctx of the dispatch. see DispatchContext.Context()
+// the concurrent fires of the same event name are not counted as recursion.
+//
+// Usage:
+// 	em := NewManager("app", WithMaxFireDepth(32))
+// 	em.On("order.created", ContextListenerFunc(func(dc *DispatchContext) error {
+// 		err, _ := em.FireContext(dc.Context(), "order.updated", nil)
+// 		return err
+// 	}))
+//
+// 	err, _ := em.Fire("order.created", nil)
+// 	if errors.Is(err, ErrFireLoop) {...}
+func WithMaxFireDepth(depth int) Option {
+	return func(o *Options) {
+		o.MaxFireDepth = depth
+	}
+}
+
+// FireDepth get the fire depth of the event name in the dispatch chain of the ctx.
+// the ctx should be got from DispatchContext.Context()
+func (em *Manager) FireDepth(ctx context.Context, name string) int {
+	return em.chainDepth(ChainFromContext(ctx), em.normalize(name))
+}
+
+// chainDepth count the event name in the dispatch chain
+func (em *Manager) chainDepth(chain []string, name string) (n int) {
+	for _, cn := range chain {
+		if em.normalize(cn) == name {
+			n++
+		}
+	}
+	return
+}
+
+// enterFire check the fire depth of the event in the dispatch chain,
+// returns ErrFireLoop on exceeding the max depth.
+func (em *Manager) enterFire(dc *DispatchContext) error {
+	if em.opts.MaxFireDepth <= 0 {
+		return nil
+	}
+
+	name := em.normalize(dc.event.Name())
+	if em.chainDepth(dc.chain, name) > em.opts.MaxFireDepth {
+		return fmt.Errorf("%w: the event '%s' is fired recursively, exceeds the max depth %d",
+			ErrFireLoop, name, em.opts.MaxFireDepth)
+	}
+	return nil
+}
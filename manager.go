@@ -1,15 +1,24 @@
 package event
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Wildcard event name
 const Wildcard = "*"
 
-// regex for check good event name.
-var goodNameReg = regexp.MustCompile(`^[a-zA-Z][\w-.*]*$`)
+// regex for check good event name. allow unicode letters and the separators ". - _ : /"
+// the char '*' is allowed for the pattern names. eg: "app.*", "*.created"
+var goodNameReg = regexp.MustCompile(`^[\pL*][\pL\pN_\-.*:/]*$`)
 
 // M is short name fo map[string]...
 type M map[string]interface{}
@@ -28,32 +37,141 @@ type ManagerFace interface {
 
 // Manager event manager definition. for manage events and listeners
 type Manager struct {
+	mu   sync.RWMutex
 	name string
-	// pool sync.Pool
+	// mark the manager is closed. 1: closed
+	closed int32
+	// mark the manager is sealed. 1: sealed
+	sealed int32
+	// the listener registration sequence number
+	seq  uint64
+	opts *Options
+	// pool for create BasicEvent, only used on the event is not returned to user.
+	pool *eventPool
+	// pools for the custom event types by event name. see RegisterEventPool()
+	pools map[string]*eventPool
 	// is an sample for new BasicEvent
 	sample *BasicEvent
 	// storage user custom Event instance. you can pre-define some Event instances.
 	events map[string]Event
-	// storage all event name and ListenerQueue map
-	listeners map[string]*ListenerQueue
-	// storage all event names by listened
+	// storage the event factories by event name. see AddEventFactory()
+	factories map[string]EventFactory
+	// storage all event name and listener Queue map
+	listeners map[string]Queue
+	// storage all listened names and the listeners count
 	listenedNames map[string]int
+	// match the listened pattern names. eg: "app.*", "*.created"
+	matcher Matcher
+	// storage the parent event names. see SetEventParents()
+	parents map[string][]string
+	// storage the delivery modes by event name. see SetDelivery()
+	deliveries map[string]*delivery
+	// storage the priority band barriers by event name. see SetBarriers()
+	barriers map[string][]int
+	// storage the deprecated event names. see Deprecate()
+	deprecations map[string]*deprecation
+	// storage the paused event names and the number. see Pause()
+	paused    map[string]bool
+	pausedNum int32
+	// storage the max listeners by event name, and the warned names. see SetMaxListeners()
+	maxListeners map[string]int
+	maxWarned    map[string]bool
+	// storage the fire options by event name or pattern. see SetProfile()
+	profiles   map[string][]FireOption
+	profileNum int32
+	// storage the redaction rules by event name or pattern. see SetRedaction()
+	redactions map[string][]RedactRule
+	redactNum  int32
+	// the runtime counters. see Stats()
+	stats stats
+	// the observers of the dispatched events. see Tail()
+	tails tails
+	// the recorders of the fired events. see Record()
+	recorders recorders
+	// the health reports of the components. see ReportHealth()
+	health healthRegistry
+	// the pending async dispatches and listeners. see Pressure()
+	pending int64
+	// mark the backpressure is active. 1: active
+	pressured int32
+	// the lifecycle state, and the inited listeners. see Start()
+	state  int32
+	inited map[interface{}]bool
+	initMu sync.Mutex
+	// the manager for the meta events. see Meta()
+	meta     atomic.Value
+	metaOnce sync.Once
+	isMeta   bool
+	// the debugger called before each listener. see SetDebugger()
+	debugger atomic.Value
+	// storage the loaded plugin listeners
+	plugins  map[string][]pluginEntry
+	pluginMu sync.Mutex
+	// interceptors called before dispatch event
+	interceptors []Interceptor
+	// access control for the Principal
+	acl *ACL
 }
 
 // NewManager create event manager
-func NewManager(name string) *Manager {
+// Usage:
+// 	em := NewManager("app")
+// 	em := NewManager("app", WithConcurrencySafe(), WithErrorPolicy(PolicyCollect))
+func NewManager(name string, opts ...Option) *Manager {
 	em := &Manager{
 		name:   name,
+		opts:   &Options{CloneOnFire: true},
 		sample: &BasicEvent{},
 		events: make(map[string]Event),
+		// factories
+		factories: make(map[string]EventFactory),
 		// listeners
-		listeners:     make(map[string]*ListenerQueue),
+		listeners:     make(map[string]Queue),
 		listenedNames: make(map[string]int),
+		parents:       make(map[string][]string),
+		deliveries:    make(map[string]*delivery),
+		barriers:      make(map[string][]int),
+		plugins:       make(map[string][]pluginEntry),
+		pools:         make(map[string]*eventPool),
+		// deprecations
+		deprecations: make(map[string]*deprecation),
+		paused:       make(map[string]bool),
+		profiles:     make(map[string][]FireOption),
+		redactions:   make(map[string][]RedactRule),
+		maxListeners: make(map[string]int),
+		maxWarned:    make(map[string]bool),
+	}
+	em.stats.since = time.Now().UnixNano()
+
+	for _, fn := range opts {
+		fn(em.opts)
+	}
+
+	em.matcher = em.opts.Matcher
+	if em.matcher == nil {
+		if em.opts.MatchMode == ModeExact {
+			em.matcher = &ExactMatcher{}
+		} else {
+			em.matcher = NewWildcardMatcher()
+		}
 	}
 
+	em.pool = newEventPool(func() Event {
+		return &BasicEvent{}
+	}, em.opts.PoolPrewarm)
 	return em
 }
 
+// Name get manager name
+func (em *Manager) Name() string {
+	return em.name
+}
+
+// Options get the manager options
+func (em *Manager) Options() Options {
+	return *em.opts
+}
+
 /*************************************************************
  * Listener Manage: - register listener
  *************************************************************/
@@ -64,6 +182,7 @@ func (em *Manager) AddListener(name string, listener Listener, priority ...int)
 }
 
 // On register a event handler/listener. can setting priority.
+// it's safe to call in a listener on dispatching, the new listener will be called from the next fire.
 // Usage:
 // 	On("evt0", listener)
 // 	On("evt0", listener, High)
@@ -73,7 +192,25 @@ func (em *Manager) On(name string, listener Listener, priority ...int) {
 		pv = priority[0]
 	}
 
-	em.addListenerItem(name, &ListenerItem{pv, listener})
+	em.addListenerItem(name, &ListenerItem{Priority: pv, Listener: listener})
+}
+
+// Listen register a event listener with options.
+// Usage:
+// 	Listen("evt0", listener, ListenOpts{Priority: High, Once: true})
+// 	Listen("evt0", listener, ListenOpts{Async: true, Label: "mailer"})
+func (em *Manager) Listen(name string, listener Listener, opts ListenOpts) {
+	em.addListenerItem(name, newListenerItem(listener, opts))
+}
+
+// OnMany register a listener to multi event names. can setting priority.
+// Usage:
+// 	OnMany([]string{"user.created", "user.updated"}, listener)
+// 	OnMany([]string{"user.created", "user.updated"}, listener, High)
+func (em *Manager) OnMany(names []string, listener Listener, priority ...int) {
+	for _, name := range names {
+		em.On(name, listener, priority...)
+	}
 }
 
 // AddSubscriber add events by subscriber interface.
@@ -89,27 +226,98 @@ func (em *Manager) AddSubscriber(sbr Subscriber) {
 		case ListenerItem:
 			em.addListenerItem(name, &lt)
 		default:
-			panic("event: the value must be an Listener or ListenerItem instance")
+			em.fail(fmt.Errorf("event: the value must be an Listener or ListenerItem instance"))
 		}
 	}
 }
 
+// TryOn register a event listener, will return error instead of panic
+// on the name is invalid or listener is nil.
+func (em *Manager) TryOn(name string, listener Listener, priority ...int) error {
+	pv := Normal
+	if len(priority) > 0 {
+		pv = priority[0]
+	}
+
+	return em.tryAddListenerItem(name, &ListenerItem{Priority: pv, Listener: listener})
+}
+
 func (em *Manager) addListenerItem(name string, li *ListenerItem) {
+	if err := em.tryAddListenerItem(name, li); err != nil {
+		em.fail(err)
+	}
+}
+
+func (em *Manager) tryAddListenerItem(name string, li *ListenerItem) (err error) {
+	_, err = em.addItem(name, li)
+	return
+}
+
+// addItem add the listener item, returns the final listened name.
+func (em *Manager) addItem(name string, li *ListenerItem) (string, error) {
 	if name != Wildcard {
-		name = goodName(name)
+		var err error
+		if name, err = em.checkName(name); err != nil {
+			return name, err
+		}
+		name = em.deprecated(name, true)
 	}
 
 	if li.Listener == nil {
-		panic("event: the event '" + name + "' listener cannot be empty")
+		return name, fmt.Errorf("%w: the event '%s'", ErrNilListener, name)
+	}
+
+	if em.IsClosed() {
+		return name, ErrClosed
+	}
+
+	if em.IsSealed() {
+		return name, ErrSealed
 	}
 
+	if em.State() == StateStopped {
+		return name, ErrStopped
+	}
+
+	if err := em.initOnAdd(li); err != nil {
+		return name, err
+	}
+
+	added, err := em.insertItem(name, li)
+	if added {
+		em.audit(AuditListen, name, li.Name(), nil)
+		em.emitMeta(MetaListenerAdded, name, li.Name(), nil)
+	}
+	return name, err
+}
+
+// insertItem insert the listener item to the queue, returns false if it's skipped.
+func (em *Manager) insertItem(name string, li *ListenerItem) (bool, error) {
+	em.lock()
+	defer em.unlock()
+
+	if dup, err := em.checkDuplicate(name, li); dup || err != nil {
+		return false, err
+	}
+
+	if err := em.checkMaxListeners(name); err != nil {
+		return false, err
+	}
+
+	em.seq++
+	li.Seq = em.seq
+
 	// exists, append it.
 	if lq, ok := em.listeners[name]; ok {
-		lq.Push(li)
+		lq.Add(li)
 	} else { // first add.
-		em.listenedNames[name] = 1
-		em.listeners[name] = (&ListenerQueue{}).Push(li)
+		lq = em.newQueue()
+		lq.Add(li)
+		em.listeners[name] = lq
+		em.matcher.Add(name)
 	}
+	em.listenedNames[name]++
+	return true, nil
 }
 
 /*************************************************************
@@ -130,37 +338,129 @@ func (em *Manager) Trigger(name string, params M) (error, Event) {
 	return em.Fire(name, params)
 }
 
-// Fire trigger event by name
-func (em *Manager) Fire(name string, params M) (err error, e Event) {
-	name = goodName(name)
+// Fire trigger event by name.
+// the matched listeners are snapshotted at the dispatch start, so the fire calls a consistent
+// set of listeners regardless of the concurrent registration and removal.
+func (em *Manager) Fire(name string, params M) (error, Event) {
+	name, err := em.fireName(name)
+	if err != nil {
+		return err, nil
+	}
+	return em.fire(name, params, em.fireOptions(name, nil))
+}
+
+// FireWith trigger event by name, can override the manager options by FireOption.
+// Usage:
+// 	FireWith("name", params, Async())
+// 	FireWith("name", params, Timeout(2*time.Second), Policy(PolicyCollect))
+func (em *Manager) FireWith(name string, params M, opts ...FireOption) (error, Event) {
+	name, err := em.fireName(name)
+	if err != nil {
+		return err, nil
+	}
+	return em.fire(name, params, em.fireOptions(name, opts))
+}
 
-	// not found listeners
-	if !em.HasListeners(name) {
+// FireContext trigger event by name with the ctx, will stop call the next listeners on the ctx is done.
+// the ContextListener can get the ctx by DispatchContext.Context()
+// Usage:
+// 	err, e := em.FireContext(r.Context(), "order.created", params)
+// 	if errors.Is(err, context.Canceled) {...}
+func (em *Manager) FireContext(ctx context.Context, name string, params M, opts ...FireOption) (error, Event) {
+	return em.FireWith(name, params, append([]FireOption{Context(ctx)}, opts...)...)
+}
+
+// TryFire trigger event by name, will return error instead of panic on the name is invalid.
+func (em *Manager) TryFire(name string, params M) (error, Event) {
+	name, err := em.checkName(name)
+	if err != nil {
+		return err, nil
+	}
+
+	return em.fire(name, params, em.fireOptions(name, nil))
+}
+
+// fire event by checked name
+func (em *Manager) fire(name string, params M, fo *fireOptions) (err error, e Event) {
+	name = em.deprecated(name, false)
+	// not found listeners, and no need to persist, record or audit it.
+	if !em.hasMatched(name) && !em.observed(name) {
+		em.stats.onUnheard(name)
+		em.emitMeta(MetaEventUnhandled, name, "", nil)
+		err = em.checkUnicast(name)
 		return
 	}
 
-	// call listeners use defined Event
-	if e, ok := em.events[name]; ok {
+	e = em.eventFor(name, params)
+	// call listeners handle event
+	err = em.fireEvent(e, fo)
+	return
+}
+
+// eventFor get the defined Event, or create by the event factory, or create a basic event instance.
+// the defined Event is a template, will be cloned and merged the params to it's data.
+// if the option CloneOnFire is disabled, will use the defined Event instance.
+func (em *Manager) eventFor(name string, params M) Event {
+	if e, ok := em.GetEvent(name); ok {
+		if em.opts.CloneOnFire {
+			e = cloneEvent(e)
+		}
+
+		if params != nil {
+			mergeData(e, params)
+		}
+		return e
+	}
+
+	if fn := em.eventFactory(name); fn != nil {
+		e := fn()
 		if params != nil {
 			e.SetData(params)
 		}
+		return e
+	}
 
-		err = em.FireEvent(e)
-		return err, e
+	return em.newBasicEvent(name, params)
+}
+
+// fireEvent fire the event with fire options
+func (em *Manager) fireEvent(e Event, fo *fireOptions) (err error) {
+	if fo == nil {
+		_, err = em.dispatch(e, nil)
+		return
 	}
 
-	// create a basic event instance
-	e = em.newBasicEvent(name, params)
-	// call listeners handle event
-	err = em.FireEvent(e)
+	if fo.async {
+		em.goAsync(func() {
+			_, _ = em.dispatch(e, fo)
+		})
+		return
+	}
+
+	if fo.timeout <= 0 {
+		_, err = em.dispatch(e, fo)
+		return
+	}
+
+	ch := make(chan error, 1)
+	go func() {
+		_, err := em.dispatch(e, fo)
+		ch <- err
+	}()
+
+	select {
+	case err = <-ch:
+	case <-time.After(fo.timeout):
+		err = ErrTimeout
+	}
 	return
 }
 
 // AsyncFire async fire event by 'go' keywords
 func (em *Manager) AsyncFire(e Event) {
-	go func(e Event) {
+	em.goAsync(func() {
 		_ = em.FireEvent(e)
-	}(e)
+	})
 }
 
 // AwaitFire async fire event by 'go' keywords, but will wait return result
@@ -183,7 +483,7 @@ func (em *Manager) FireBatch(es ...interface{}) (ers []error) {
 	var err error
 	for _, e := range es {
 		if name, ok := e.(string); ok {
-			err, _ = em.Fire(name, nil)
+			err = em.fireByName(name)
 		} else if evt, ok := e.(Event); ok {
 			err = em.FireEvent(evt)
 		} // ignore invalid param.
@@ -195,90 +495,549 @@ func (em *Manager) FireBatch(es ...interface{}) (ers []error) {
 	return
 }
 
-// FireEvent fire event by given Event instance
+// fireByName fire event by name, the event will be acquired from the pool.
+// it's used for the event instance will not be returned to user.
+func (em *Manager) fireByName(name string) error {
+	name, err := em.fireName(name)
+	if err != nil {
+		return err
+	}
+
+	name = em.deprecated(name, false)
+	if !em.hasMatched(name) && !em.observed(name) {
+		em.stats.onUnheard(name)
+		em.emitMeta(MetaEventUnhandled, name, "", nil)
+		return em.checkUnicast(name)
+	}
+
+	if em.HasEvent(name) {
+		return em.FireEvent(em.eventFor(name, nil))
+	}
+
+	// the profile maybe fire async, cannot use the pooled event.
+	if fo := em.fireOptions(name, nil); fo != nil {
+		return em.fireEvent(em.eventFor(name, nil), fo)
+	}
+
+	e := em.acquireEvent(name)
+	dc, err := em.dispatch(e, nil)
+
+	// the event maybe used by async listeners, cannot reuse it.
+	if !dc.async {
+		em.releaseEvent(e)
+	}
+	return err
+}
+
+// FireEvent fire event by given Event instance.
+// the instance is not copied, the aborted flag will be reset before call listeners.
 func (em *Manager) FireEvent(e Event) (err error) {
-	// ensure aborted is false.
-	e.Abort(false)
-	name := e.Name()
+	return em.fireEvent(e, em.fireOptions(em.normalize(e.Name()), nil))
+}
+
+// listenerGroup matched listeners by a listened name
+type listenerGroup struct {
+	name  string
+	items []*ListenerItem
+	// the Filter of the items has been checked
+	filtered bool
+}
+
+func (em *Manager) dispatch(e Event, fo *fireOptions) (dc *DispatchContext, err error) {
+	dc = newDispatchContext(e, em.opts.ErrorPolicy)
+	if fo != nil {
+		if fo.hasPolicy {
+			dc.policy = fo.policy
+		}
+		dc.retries, dc.backoff = fo.retries, fo.backoff
+		dc.ctx = fo.ctx
+	}
+	dc.barriers = em.Barriers(e.Name())
+
+	if em.IsClosed() {
+		return dc, ErrClosed
+	}
+
+	switch em.State() {
+	case StatePaused:
+		atomic.AddUint64(&em.stats.paused, 1)
+		return
+	case StateStopped:
+		return dc, ErrStopped
+	}
+
+	if err = em.pushChain(dc); err != nil {
+		return
+	}
+
+	if fo != nil && fo.sample > 0 && fo.sample < 1 && rand.Float64() >= fo.sample {
+		atomic.AddUint64(&em.stats.sampled, 1)
+		return
+	}
+
+	if em.IsPaused(e.Name()) {
+		atomic.AddUint64(&em.stats.paused, 1)
+		return
+	}
+
+	key, dup, err := em.claimKey(e)
+	if err != nil || dup {
+		dc.duplicate = dup
+		return
+	}
+	defer func() {
+		em.releaseKey(key, err)
+		em.stats.onFire(e.Name(), err)
+	}()
+
+	if err = em.enterFire(dc); err != nil {
+		return
+	}
+
+	// take the snapshot of the listeners at the dispatch start, the registration and
+	// removal after it, include by the interceptors and listeners, will not affect this fire.
+	name := em.deprecated(em.normalize(e.Name()), false)
+	gs := em.matchedGroups(name)
+
+	if err = em.intercept(e); err != nil {
+		em.audit(AuditFire, e.Name(), "", err)
+		return
+	}
+	em.audit(AuditFire, e.Name(), "", nil)
+	em.record(e)
+
+	if err = em.persist(e); err != nil {
+		return
+	}
+
+	// ensure aborted is false. the event maybe reused or aborted on last fire.
+	// only write on aborted, so the event can be safe fired concurrently.
+	if e.IsAborted() {
+		e.Abort(false)
+	}
+
+	if len(gs) == 0 {
+		em.stats.onUnheard(e.Name())
+		em.emitMeta(MetaEventUnhandled, e.Name(), "", nil)
+	}
+
+	if gs, err = em.deliver(e, name, gs); err != nil {
+		return
+	}
+
+	for _, g := range gs {
+		if err = em.callListeners(g, dc); err != nil || dc.IsAborted() {
+			break
+		}
+	}
+
+	// sync the aborted state to the event, the caller can check it after fire.
+	if dc.IsAborted() && !e.IsAborted() {
+		e.Abort(true)
+	}
+
+	em.setTrace(dc)
+
+	em.tap(e)
+
+	if dc.policy == PolicyCollect && len(dc.errs) > 0 {
+		err = dc.errs
+	}
+	return
+}
+
+// matchedGroups find matched listeners for the event name.
+// will return copied and sorted listeners, so can safe call them without lock.
+func (em *Manager) matchedGroups(name string) []*listenerGroup {
+	em.lock()
+	defer em.unlock()
+
+	names := em.matchedNames(name)
+	gs := make([]*listenerGroup, 0, len(names))
+	for _, n := range names {
+		gs = append(gs, newListenerGroup(n, em.listeners[n]))
+	}
+	return gs
+}
+
+// matchedNames find the listened names matched the event name, in the call order.
+// NOTICE: should call it with lock.
+func (em *Manager) matchedNames(name string) (ms []string) {
+	// the event name and it's parent event names
+	names := append([]string{name}, em.ancestors(name)...)
 
 	// find matched listeners
-	lq, ok := em.listeners[name]
-	if ok {
-		// sort by priority before call.
-		for _, li := range lq.Sort().Items() {
-			err = li.Listener.Handle(e)
-			if err != nil || e.IsAborted() {
-				return
-			}
+	for _, n := range names {
+		if _, ok := em.listeners[n]; ok {
+			ms = append(ms, n)
 		}
 	}
 
-	// has group listeners. "app.*" "app.db.*"
+	// has pattern listeners. "app.*" "app.*.error" "*.created"
 	// eg: "app.run" will trigger listeners on the "app.*"
-	pos := strings.LastIndexByte(name, '.')
-	if pos > 0 && pos < len(name) {
-		groupName := name[:pos+1] + Wildcard // "app.*"
-
-		if lq, ok := em.listeners[groupName]; ok {
-			for _, li := range lq.Sort().Items() {
-				err = li.Listener.Handle(e)
-				if err != nil || e.IsAborted() {
-					return
-				}
+	seen := make(map[string]bool, len(ms))
+	for _, n := range ms {
+		seen[n] = true
+	}
+
+	var wildcard bool
+	for _, n := range names {
+		for _, pattern := range em.matcher.Match(n) {
+			// the wildcard listeners always called at last
+			if pattern == Wildcard {
+				wildcard = true
+				continue
+			}
+
+			if _, ok := em.listeners[pattern]; ok && !seen[pattern] {
+				seen[pattern] = true
+				ms = append(ms, pattern)
 			}
 		}
 	}
 
 	// has wildcard event listeners
-	if lq, ok := em.listeners[Wildcard]; ok {
-		for _, li := range lq.Sort().Items() {
-			err = li.Listener.Handle(e)
-			if err != nil || e.IsAborted() {
+	if _, ok := em.listeners[Wildcard]; ok && wildcard {
+		ms = append(ms, Wildcard)
+	}
+	return
+}
+
+func newListenerGroup(name string, lq Queue) *listenerGroup {
+	// the items of ListenerQueue is copy-on-write, no need to copy it.
+	if _, ok := lq.(*ListenerQueue); ok {
+		return &listenerGroup{name: name, items: lq.Items()}
+	}
+
+	// the custom queue maybe returns it's internal slice, copy it for the listeners
+	// can register or remove listeners on dispatching.
+	items := lq.Items()
+	return &listenerGroup{name: name, items: append([]*ListenerItem(nil), items...)}
+}
+
+// newQueue create listener queue by the QueueFactory
+func (em *Manager) newQueue() Queue {
+	if em.opts.QueueFactory != nil {
+		return em.opts.QueueFactory()
+	}
+	return NewListenerQueue()
+}
+
+// callListeners call the listeners in the group.
+func (em *Manager) callListeners(g *listenerGroup, dc *DispatchContext) (err error) {
+	// the once and dead listeners, will be removed after called.
+	var removes []*ListenerItem
+
+	e := dc.event
+
+	adaptive := em.opts.Adaptive != nil
+	for _, li := range g.items {
+		if err = dc.checkContext(); err != nil {
+			if dc.policy == PolicyCollect {
+				dc.errs = append(dc.errs, err)
+			}
+			break
+		}
+
+		if li.Alive != nil && !li.Alive() {
+			removes = append(removes, li)
+			continue
+		}
+
+		if li.Filter != nil && !g.filtered && !li.Filter(e) {
+			continue
+		}
+
+		if adaptive && li.IsSuspended() {
+			continue
+		}
+
+		if action := em.debug(e, li); action == DebugSkip {
+			continue
+		} else if action == DebugAbort {
+			dc.Abort()
+			break
+		}
+
+		if li.Once {
+			// the once listener maybe in the snapshot of a reentrant or concurrent fire.
+			if !li.claimOnce() {
+				continue
+			}
+			removes = append(removes, li)
+		}
+
+		dc.enterBand(li.EffectivePriority())
+		dc.visited = append(dc.visited, li)
+		if li.Async {
+			em.traceCall(dc, g, li, time.Time{}, nil)
+			dc.async = true
+			if len(dc.barriers) > 0 {
+				dc.pending.Add(1)
+			}
+
+			li := li
+			em.goAsync(func() {
+				if len(dc.barriers) > 0 {
+					defer dc.pending.Done()
+				}
+				atomic.AddUint64(&em.stats.handled, 1)
+				err := em.callListener(dc, li)
+				em.audit(AuditHandle, e.Name(), li.Name(), err)
+				if err != nil {
+					em.emitMeta(MetaListenerError, e.Name(), li.Name(), err)
+				}
+				if adaptive {
+					em.adapt(g.name, li, err)
+				}
+			})
+			continue
+		}
+
+		atomic.AddUint64(&em.stats.handled, 1)
+		start := em.traceStart()
+		err = em.callListener(dc, li)
+		em.traceCall(dc, g, li, start, err)
+		em.audit(AuditHandle, e.Name(), li.Name(), err)
+		if err != nil {
+			em.emitMeta(MetaListenerError, e.Name(), li.Name(), err)
+		}
+		if adaptive {
+			em.adapt(g.name, li, err)
+		}
+
+		if err != nil {
+			if dc.policy == PolicyStop {
 				break
 			}
+
+			if dc.policy == PolicyCollect {
+				dc.errs = append(dc.errs, err)
+			}
+			err = nil
+		}
+
+		if dc.IsAborted() {
+			break
 		}
 	}
+
+	if len(removes) > 0 {
+		em.removeItems(g.name, removes)
+	}
 	return
 }
 
+// removeItems remove listener items from the listened name.
+func (em *Manager) removeItems(name string, items []*ListenerItem) {
+	em.lock()
+	lq, ok := em.listeners[name]
+	if !ok {
+		em.unlock()
+		return
+	}
+
+	for _, li := range items {
+		lq.RemoveItem(li)
+	}
+	em.syncQueue(name)
+	em.unlock()
+
+	// audit and emit after unlock
+	for _, li := range items {
+		em.audit(AuditRemove, name, li.Name(), nil)
+		em.emitMeta(MetaListenerRemoved, name, li.Name(), nil)
+	}
+}
+
+// syncQueue sync the listeners count after removed listeners,
+// and delete the listener queue from manager if it's empty.
+// NOTICE: should call it with lock.
+func (em *Manager) syncQueue(name string) {
+	lq, ok := em.listeners[name]
+	if ok && !lq.IsEmpty() {
+		em.listenedNames[name] = lq.Len()
+		return
+	}
+
+	delete(em.listeners, name)
+	delete(em.listenedNames, name)
+	em.matcher.Remove(name)
+}
+
 /*************************************************************
  * Event Manage
  *************************************************************/
 
 // AddEvent add a defined event instance to manager.
 func (em *Manager) AddEvent(e Event) {
-	name := goodName(e.Name())
+	if err := em.TryAddEvent(e); err != nil {
+		em.fail(err)
+	}
+}
+
+// TryAddEvent add a defined event instance to manager. will return error on name is invalid.
+func (em *Manager) TryAddEvent(e Event) error {
+	name, err := em.checkName(e.Name())
+	if err != nil {
+		return err
+	}
+
+	if em.IsSealed() {
+		return ErrSealed
+	}
+
+	em.lock()
 	em.events[name] = e
+	em.unlock()
+	return nil
+}
+
+// AddEvents add multi defined event instances to manager.
+func (em *Manager) AddEvents(es ...Event) {
+	for _, e := range es {
+		em.AddEvent(e)
+	}
+}
+
+// AddEventFactory add a factory for create the event instance by name.
+// the Fire(name, params) will create event by the factory instead of BasicEvent,
+// so that listeners can safe type-assert to the custom event type.
+//
+// Usage:
+// 	em.AddEventFactory("user.created", func() Event {
+// 		return &UserCreatedEvent{BasicEvent: *NewBasic("user.created", nil)}
+// 	})
+//
+// 	em.On("user.created", ListenerFunc(func(e Event) error {
+// 		uce := e.(*UserCreatedEvent)
+// 		...
+// 	}))
+func (em *Manager) AddEventFactory(name string, factory EventFactory) {
+	if err := em.TryAddEventFactory(name, factory); err != nil {
+		em.fail(err)
+	}
+}
+
+// TryAddEventFactory add a factory for create the event instance by name.
+// will return error on the name is invalid or the factory is nil.
+func (em *Manager) TryAddEventFactory(name string, factory EventFactory) error {
+	name, err := em.checkName(name)
+	if err != nil {
+		return err
+	}
+
+	if factory == nil {
+		return ErrNilFactory
+	}
+
+	if em.IsSealed() {
+		return ErrSealed
+	}
+
+	em.lock()
+	em.factories[name] = factory
+	em.unlock()
+	return nil
+}
+
+// HasEventFactory check the event factory exists
+func (em *Manager) HasEventFactory(name string) bool {
+	return em.eventFactory(em.normalize(name)) != nil
+}
+
+// eventFactory get the event factory by the checked name
+func (em *Manager) eventFactory(name string) EventFactory {
+	em.rLock()
+	defer em.rUnlock()
+	return em.factories[name]
 }
 
 // GetEvent get a defined event instance by name
 func (em *Manager) GetEvent(name string) (e Event, ok bool) {
+	name = em.normalize(name)
+	em.rLock()
 	e, ok = em.events[name]
+	em.rUnlock()
 	return
 }
 
 // HasEvent has event check
 func (em *Manager) HasEvent(name string) bool {
-	_, ok := em.events[name]
+	_, ok := em.GetEvent(name)
 	return ok
 }
 
-// RemoveEvent delete Event by name
-func (em *Manager) RemoveEvent(name string) {
-	if _, ok := em.events[name]; ok {
-		delete(em.events, name)
+// EventNames get the defined event names, is sorted.
+func (em *Manager) EventNames() []string {
+	em.rLock()
+	names := make([]string, 0, len(em.events))
+	for name := range em.events {
+		names = append(names, name)
 	}
+	em.rUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// RemoveEvent delete Event and event factory by name
+func (em *Manager) RemoveEvent(name string) {
+	em.mustNotSealed()
+	name = em.normalize(name)
+	em.lock()
+	delete(em.events, name)
+	delete(em.factories, name)
+	em.unlock()
 }
 
-// RemoveEvents remove all registered events
+// RemoveEvents remove all registered events and event factories
 func (em *Manager) RemoveEvents() {
+	em.mustNotSealed()
+	em.lock()
 	em.events = map[string]Event{}
+	em.factories = map[string]EventFactory{}
+	em.unlock()
 }
 
 /*************************************************************
  * Helper Methods
  *************************************************************/
 
+// cloneEvent clone the event. will use the Clone() method if the event
+// implements Cloner, otherwise shallow copy the struct and the data map.
+func cloneEvent(e Event) Event {
+	// NOTICE: the Clone() maybe promoted from embedded BasicEvent, should check the type.
+	if c, ok := e.(Cloner); ok {
+		if ce := c.Clone(); reflect.TypeOf(ce) == reflect.TypeOf(e) {
+			return ce
+		}
+	}
+
+	rv := reflect.ValueOf(e)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return e
+	}
+
+	cp := reflect.New(rv.Elem().Type())
+	cp.Elem().Set(rv.Elem())
+
+	ce := cp.Interface().(Event)
+	data := make(M, len(e.Data()))
+	for k, v := range e.Data() {
+		data[k] = v
+	}
+	ce.SetData(data)
+	return ce
+}
+
+// mergeData merge the params to the event data
+func mergeData(e Event, params M) {
+	for k, v := range params {
+		e.Set(k, v)
+	}
+}
+
 // newBasicEvent create new BasicEvent by clone em.sample
 func (em *Manager) newBasicEvent(name string, data M) *BasicEvent {
 	var cp = *em.sample
@@ -289,33 +1048,97 @@ func (em *Manager) newBasicEvent(name string, data M) *BasicEvent {
 	return &cp
 }
 
-// HasListeners has listeners for the event name.
+func (em *Manager) lock() {
+	if em.opts.ConcurrencySafe {
+		em.mu.Lock()
+	}
+}
+
+func (em *Manager) unlock() {
+	if em.opts.ConcurrencySafe {
+		em.mu.Unlock()
+	}
+}
+
+func (em *Manager) rLock() {
+	if em.opts.ConcurrencySafe {
+		em.mu.RLock()
+	}
+}
+
+func (em *Manager) rUnlock() {
+	if em.opts.ConcurrencySafe {
+		em.mu.RUnlock()
+	}
+}
+
+// HasListeners has matched listeners for the event name.
+// will check the listeners on the parent events, group name "app.*" and wildcard "*".
 func (em *Manager) HasListeners(name string) bool {
-	_, ok := em.listenedNames[name]
-	return ok
+	return em.hasMatched(em.normalize(name))
+}
+
+// observed check the event should be dispatched even if no listeners,
+// it will be persisted to the event store, recorded or audited.
+func (em *Manager) observed(name string) bool {
+	return em.opts.AuditLog != nil || atomic.LoadInt32(&em.recorders.num) > 0 || em.storeMatched(name)
+}
+
+// hasMatched check has matched listeners for the checked name
+func (em *Manager) hasMatched(name string) bool {
+	em.rLock()
+	defer em.rUnlock()
+	return len(em.matchedNames(name)) > 0
 }
 
-// Listeners get all listeners
-func (em *Manager) Listeners() map[string]*ListenerQueue {
+// MatchListeners get all matched listeners for the event name, in the call order.
+// Usage:
+// 	for _, li := range em.MatchListeners("app.run") {
+// 		fmt.Println(li.Name(), li.Priority)
+// 	}
+func (em *Manager) MatchListeners(name string) []*ListenerItem {
+	var items []*ListenerItem
+	for _, g := range em.matchedGroups(em.normalize(name)) {
+		items = append(items, g.items...)
+	}
+	return items
+}
+
+// Listeners get all listeners.
+//
+// NOTICE: the value type is changed from *ListenerQueue to Queue for support
+// the custom queue. the default queue can be got by lq.(*ListenerQueue)
+func (em *Manager) Listeners() map[string]Queue {
 	return em.listeners
 }
 
-// ListenersByName get listeners by given event name
-func (em *Manager) ListenersByName(name string) *ListenerQueue {
+// ListenersByName get listeners by given event name.
+// NOTICE: the return type is changed from *ListenerQueue to Queue, see Listeners()
+func (em *Manager) ListenersByName(name string) Queue {
+	name = em.normalize(name)
+	em.rLock()
+	defer em.rUnlock()
 	return em.listeners[name]
 }
 
 // ListenersCount get listeners number for the event name.
 func (em *Manager) ListenersCount(name string) int {
-	if lq, ok := em.listeners[name]; ok {
-		return lq.Len()
-	}
-	return 0
+	name = em.normalize(name)
+	em.rLock()
+	defer em.rUnlock()
+	return em.listenedNames[name]
 }
 
-// ListenedNames get listened event names
+// ListenedNames get listened event names and the listeners count of each name
 func (em *Manager) ListenedNames() map[string]int {
-	return em.listenedNames
+	em.rLock()
+	defer em.rUnlock()
+
+	mp := make(map[string]int, len(em.listenedNames))
+	for name, n := range em.listenedNames {
+		mp[name] = n
+	}
+	return mp
 }
 
 // RemoveListener remove a given listener, you can limit event name.
@@ -323,15 +1146,21 @@ func (em *Manager) ListenedNames() map[string]int {
 // 	RemoveListener("", listener)
 // 	RemoveListener("name", listener) // limit event name.
 func (em *Manager) RemoveListener(name string, listener Listener) {
+	em.mustNotSealed()
+	name = em.normalize(name)
+	if listener != nil {
+		// audit and emit after unlock
+		defer em.emitMeta(MetaListenerRemoved, name, listenerName(listener), nil)
+		defer em.audit(AuditRemove, name, listenerName(listener), nil)
+	}
+
+	em.lock()
+	defer em.unlock()
+
 	if name != "" {
 		if lq, ok := em.listeners[name]; ok {
 			lq.Remove(listener)
-
-			// delete from manager
-			if lq.IsEmpty() {
-				delete(em.listeners, name)
-				delete(em.listenedNames, name)
-			}
+			em.syncQueue(name)
 		}
 		return
 	}
@@ -339,50 +1168,216 @@ func (em *Manager) RemoveListener(name string, listener Listener) {
 	// name is empty. find all listener and remove matched.
 	for name, lq := range em.listeners {
 		lq.Remove(listener)
-
-		// delete from manager
-		if lq.IsEmpty() {
-			delete(em.listeners, name)
-			delete(em.listenedNames, name)
-		}
+		em.syncQueue(name)
 	}
 }
 
-// RemoveListeners remove listeners by given name
+// RemoveListeners remove listeners by given name.
+// it's safe to call in a listener on dispatching, the current fire will still call the removed listeners.
+// only remove the listeners registered on the name, the listeners on the
+// group name "app.*" and wildcard "*" need to be removed by the listened name.
+// Usage:
+// 	RemoveListeners("app.run")
+// 	RemoveListeners("app.*") // remove the group listeners
 func (em *Manager) RemoveListeners(name string) {
-	_, ok := em.listenedNames[name]
-	if ok {
-		em.listeners[name].Clear()
+	em.mustNotSealed()
+	name = em.normalize(name)
+	em.lock()
+	removed := em.removeQueue(name)
+	em.unlock()
+
+	if removed {
+		em.audit(AuditRemove, name, Wildcard, nil)
+	}
+	em.emitMeta(MetaListenerRemoved, name, "", nil)
+}
+
+// ClearAllListeners remove all listeners, the registered events will be kept.
+func (em *Manager) ClearAllListeners() {
+	em.mustNotSealed()
+	em.lock()
+	names := make([]string, 0, len(em.listeners))
+	for name := range em.listeners {
+		em.removeQueue(name)
+		names = append(names, name)
+	}
+	em.plugins = make(map[string][]pluginEntry)
+	em.unlock()
+
+	for _, name := range names {
+		em.audit(AuditRemove, name, Wildcard, nil)
+	}
+}
+
+// RemoveEventAndListeners remove the registered event, event factory and
+// all listeners by the event name. it's used for fully retire an event.
+func (em *Manager) RemoveEventAndListeners(name string) {
+	em.mustNotSealed()
+	name = em.normalize(name)
+	em.lock()
+	delete(em.events, name)
+	delete(em.factories, name)
+	delete(em.parents, name)
+	delete(em.deliveries, name)
+	delete(em.barriers, name)
+	removed := em.removeQueue(name)
+	em.unlock()
+
+	if removed {
+		em.audit(AuditRemove, name, Wildcard, nil)
+	}
+}
+
+// removeQueue clear and delete the listener queue by the listened name,
+// returns false if not exists. the caller should audit it after unlock.
+// NOTICE: should call it with lock.
+func (em *Manager) removeQueue(name string) bool {
+	lq, ok := em.listeners[name]
+	if !ok {
+		return false
+	}
+
+	lq.Clear()
+
+	// delete from manager
+	delete(em.listeners, name)
+	delete(em.listenedNames, name)
+	em.matcher.Remove(name)
+	return true
+}
 
-		// delete from manager
-		delete(em.listeners, name)
-		delete(em.listenedNames, name)
+// Close the manager, will clear all listeners and events.
+// the closed manager cannot register listeners or fire events.
+func (em *Manager) Close() error {
+	if !atomic.CompareAndSwapInt32(&em.closed, 0, 1) {
+		return nil
 	}
+
+	em.close()
+	return nil
+}
+
+// close clear the listeners and events, and close the observers.
+func (em *Manager) close() {
+	em.lock()
+	for name, lq := range em.listeners {
+		lq.Clear()
+		em.matcher.Remove(name)
+	}
+
+	em.events = make(map[string]Event)
+	em.factories = make(map[string]EventFactory)
+	em.listeners = make(map[string]Queue)
+	em.listenedNames = make(map[string]int)
+	em.plugins = make(map[string][]pluginEntry)
+	em.pools = make(map[string]*eventPool)
+	em.unlock()
+
+	em.closeTails()
+}
+
+// IsClosed check the manager is closed
+func (em *Manager) IsClosed() bool {
+	return atomic.LoadInt32(&em.closed) == 1
 }
 
 // Clear all data
 func (em *Manager) Clear() {
+	em.mustNotSealed()
+	em.lock()
+	defer em.unlock()
+
 	// clear all listeners
-	for _, lq := range em.listeners {
+	for name, lq := range em.listeners {
 		lq.Clear()
+		em.matcher.Remove(name)
 	}
 
 	// reset all
 	em.name = ""
 	em.events = make(map[string]Event)
-	em.listeners = make(map[string]*ListenerQueue)
+	em.factories = make(map[string]EventFactory)
+	em.listeners = make(map[string]Queue)
 	em.listenedNames = make(map[string]int)
+	em.parents = make(map[string][]string)
+	em.deliveries = make(map[string]*delivery)
+	em.barriers = make(map[string][]int)
+	em.plugins = make(map[string][]pluginEntry)
+	em.pools = make(map[string]*eventPool)
+	em.deprecations = make(map[string]*deprecation)
+	em.paused = make(map[string]bool)
+	atomic.StoreInt32(&em.pausedNum, 0)
+	em.profiles = make(map[string][]FireOption)
+	atomic.StoreInt32(&em.profileNum, 0)
+	em.redactions = make(map[string][]RedactRule)
+	atomic.StoreInt32(&em.redactNum, 0)
+	em.maxListeners = make(map[string]int)
+	em.maxWarned = make(map[string]bool)
+}
+
+// ValidateName check the event name is valid. returns error if invalid.
+func (em *Manager) ValidateName(name string) error {
+	_, err := em.checkName(name)
+	return err
 }
 
-func goodName(name string) string {
-	name = strings.TrimSpace(name)
+// normalize the event name by the NameNormalizer
+func (em *Manager) normalize(name string) string {
+	if em.opts.NameNormalizer != nil && name != Wildcard {
+		return em.opts.NameNormalizer(name)
+	}
+	return name
+}
+
+// checkName trim, normalize and check the event name
+func (em *Manager) checkName(name string) (string, error) {
+	name = em.normalize(strings.TrimSpace(name))
 	if name == "" {
-		panic("event: the event name cannot be empty")
+		return "", fmt.Errorf("%w: the name cannot be empty", ErrInvalidName)
+	}
+
+	if em.opts.LenientNames {
+		return name, nil
+	}
+
+	reg := goodNameReg
+	if em.opts.NamePattern != nil {
+		reg = em.opts.NamePattern
+	}
+
+	if !reg.MatchString(name) {
+		return "", fmt.Errorf("%w '%s', must match regex '%s'", ErrInvalidName, name, reg.String())
+	}
+	return name, nil
+}
+
+// fireName check the event name for fire, will panic on invalid if the NoPanic mode is disabled.
+func (em *Manager) fireName(name string) (string, error) {
+	name, err := em.checkName(name)
+	if err != nil && !em.opts.NoPanic {
+		panic(err)
 	}
+	return name, err
+}
 
-	if !goodNameReg.MatchString(name) {
-		panic(`event: the event name is invalid, must match regex '^[a-zA-Z][\w-.]*$'`)
+// fail report the error, will panic if the NoPanic mode is disabled.
+func (em *Manager) fail(err error) {
+	if !em.opts.NoPanic {
+		panic(err)
 	}
 
+	if em.opts.ErrorHandler != nil {
+		em.opts.ErrorHandler(err)
+	} else {
+		em.logf("%v", err)
+	}
+}
+
+// goodName check the event name, will panic on invalid
+func (em *Manager) goodName(name string) string {
+	name, err := em.checkName(name)
+	if err != nil {
+		panic(err)
+	}
 	return name
 }
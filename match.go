@@ -0,0 +1,91 @@
+package event
+
+import "strings"
+
+// MatchName check the event name is matched the pattern, same as the listeners dispatch.
+// the pattern Wildcard "*" matches all names. otherwise the name is matched by segments,
+// the char '*' in a segment will match any chars(include empty), except the separator '.'.
+//
+// Usage:
+// 	MatchName("app.*", "app.run") // true
+// 	MatchName("app.*", "app.db.run") // false
+// 	MatchName("*.created", "user.created") // true
+// 	MatchName("app.*.error", "app.db.error") // true
+func MatchName(pattern, name string) bool {
+	// fast path
+	if pattern == Wildcard || pattern == name {
+		return true
+	}
+	return matchSegments(pattern, name)
+}
+
+// matchGlob check the string is matched the glob pattern.
+// the char '*' in pattern will match any chars(include empty).
+func matchGlob(pattern, name string) bool {
+	if pattern == name {
+		return true
+	}
+
+	px, nx := 0, 0
+	// the last '*' position in pattern and the matched position in name
+	starPx, starNx := -1, 0
+	for nx < len(name) {
+		if px < len(pattern) {
+			switch c := pattern[px]; c {
+			case '*':
+				starPx, starNx = px, nx
+				px++
+				continue
+			default:
+				if c == name[nx] {
+					px++
+					nx++
+					continue
+				}
+			}
+		}
+
+		// mismatch, backtrack to the last '*'
+		if starPx >= 0 {
+			px = starPx + 1
+			starNx++
+			nx = starNx
+			continue
+		}
+		return false
+	}
+
+	// skip the tail '*'
+	for px < len(pattern) && pattern[px] == '*' {
+		px++
+	}
+	return px == len(pattern)
+}
+
+// isPattern check the listened name is a pattern. eg: "app.*", "*.created"
+func isPattern(name string) bool {
+	return name != Wildcard && strings.IndexByte(name, '*') >= 0
+}
+
+// matchSegments check the event name is matched the pattern by segments.
+// the segments are split by '.', the pattern and name must have same number
+// of segments, and the char '*' in a segment will not match the separator '.'.
+//
+// Usage:
+// 	matchSegments("app.*", "app.run") // true
+// 	matchSegments("app.*", "app.db.run") // false
+// 	matchSegments("app.*.error", "app.db.error") // true
+// 	matchSegments("*.created", "user.created") // true
+func matchSegments(pattern, name string) bool {
+	if strings.Count(pattern, ".") != strings.Count(name, ".") {
+		return false
+	}
+
+	ps, ns := strings.Split(pattern, "."), strings.Split(name, ".")
+	for i, p := range ps {
+		if !matchGlob(p, ns[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,323 @@
+package event
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher interface. match the listened pattern names by the fired event name.
+// the listeners on the exact event name are always matched by the manager.
+//
+// NOTICE: the methods are called with the manager lock, the matcher instance
+// should not be shared by multi managers.
+type Matcher interface {
+	// Add a listened name, called on the first listener added to the name.
+	Add(name string)
+	// Remove a listened name, called on the all listeners removed from the name.
+	Remove(name string)
+	// Match find the listened names matched the event name.
+	Match(name string) []string
+}
+
+/*************************************************************
+ * Exact matcher
+ *************************************************************/
+
+// ExactMatcher only match listeners by the exact event name. see ModeExact
+type ExactMatcher struct{}
+
+// Add a listened name
+func (m *ExactMatcher) Add(string) {}
+
+// Remove a listened name
+func (m *ExactMatcher) Remove(string) {}
+
+// Match find the listened names matched the event name
+func (m *ExactMatcher) Match(string) []string {
+	return nil
+}
+
+/*************************************************************
+ * Wildcard matcher
+ *************************************************************/
+
+// WildcardMatcher match by the pattern names, the char '*' match
+// any chars in a segment. it's default matcher. see ModeWildcard
+//
+// Usage:
+// 	"app.*" match "app.run", not match "app.db.run"
+// 	"app.*.error" match "app.db.error"
+// 	"*.created" match "user.created"
+// 	"*" match all events
+type WildcardMatcher struct {
+	// sorted pattern names
+	patterns []string
+	wildcard bool
+}
+
+// NewWildcardMatcher create
+func NewWildcardMatcher() *WildcardMatcher {
+	return &WildcardMatcher{}
+}
+
+// Add a listened name
+func (m *WildcardMatcher) Add(name string) {
+	if name == Wildcard {
+		m.wildcard = true
+	} else if isPattern(name) {
+		m.patterns = insertSorted(m.patterns, name)
+	}
+}
+
+// Remove a listened name
+func (m *WildcardMatcher) Remove(name string) {
+	if name == Wildcard {
+		m.wildcard = false
+	} else {
+		m.patterns = removeSorted(m.patterns, name)
+	}
+}
+
+// Match find the listened names matched the event name
+func (m *WildcardMatcher) Match(name string) (ms []string) {
+	for _, pattern := range m.patterns {
+		if matchSegments(pattern, name) {
+			ms = append(ms, pattern)
+		}
+	}
+
+	if m.wildcard {
+		ms = append(ms, Wildcard)
+	}
+	return
+}
+
+/*************************************************************
+ * Regex matcher
+ *************************************************************/
+
+// regexMetaChars the listened name contains any of the chars is a regex.
+const regexMetaChars = `*+?()[]{}|^$\`
+
+// RegexMatcher match by the regex names. the listened name contains
+// any of the chars `*+?()[]{}|^$\` is a regex, it will be full matched.
+//
+// NOTICE: should use it with WithLenientNames() or a custom name pattern,
+// the default name regex does not allow most of the regex chars.
+//
+// Usage:
+// 	em := NewManager("app", WithMatcher(NewRegexMatcher()), WithLenientNames())
+// 	em.On(`app\.(db|cache)\.error`, listener)
+// 	em.On("app.*", listener) // match "app.run", "app.db.run"
+type RegexMatcher struct {
+	// sorted regex names
+	names    []string
+	regs     map[string]*regexp.Regexp
+	wildcard bool
+}
+
+// NewRegexMatcher create
+func NewRegexMatcher() *RegexMatcher {
+	return &RegexMatcher{regs: make(map[string]*regexp.Regexp)}
+}
+
+// Add a listened name. the invalid regex name will be ignored.
+func (m *RegexMatcher) Add(name string) {
+	if name == Wildcard {
+		m.wildcard = true
+		return
+	}
+
+	if !strings.ContainsAny(name, regexMetaChars) {
+		return
+	}
+
+	reg, err := regexp.Compile("^(?:" + name + ")$")
+	if err != nil {
+		return
+	}
+
+	m.regs[name] = reg
+	m.names = insertSorted(m.names, name)
+}
+
+// Remove a listened name
+func (m *RegexMatcher) Remove(name string) {
+	if name == Wildcard {
+		m.wildcard = false
+		return
+	}
+
+	delete(m.regs, name)
+	m.names = removeSorted(m.names, name)
+}
+
+// Match find the listened names matched the event name
+func (m *RegexMatcher) Match(name string) (ms []string) {
+	for _, n := range m.names {
+		if m.regs[n].MatchString(name) {
+			ms = append(ms, n)
+		}
+	}
+
+	if m.wildcard {
+		ms = append(ms, Wildcard)
+	}
+	return
+}
+
+/*************************************************************
+ * Trie matcher
+ *************************************************************/
+
+// trieNode a segment node of the TrieMatcher
+type trieNode struct {
+	// children by the exact segment
+	exact map[string]*trieNode
+	// children by the pattern segment. eg: "*", "user_*"
+	globs map[string]*trieNode
+	// the pattern name end at the node
+	pattern string
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{
+		exact: make(map[string]*trieNode),
+		globs: make(map[string]*trieNode),
+	}
+}
+
+func (n *trieNode) isEmpty() bool {
+	return n.pattern == "" && len(n.exact) == 0 && len(n.globs) == 0
+}
+
+// TrieMatcher has same match rules as the WildcardMatcher, but the pattern
+// names are stored in a segment trie. it's faster on there are many patterns.
+type TrieMatcher struct {
+	root     *trieNode
+	wildcard bool
+}
+
+// NewTrieMatcher create
+func NewTrieMatcher() *TrieMatcher {
+	return &TrieMatcher{root: newTrieNode()}
+}
+
+// Add a listened name
+func (m *TrieMatcher) Add(name string) {
+	if name == Wildcard {
+		m.wildcard = true
+		return
+	}
+
+	if !isPattern(name) {
+		return
+	}
+
+	node := m.root
+	for _, seg := range strings.Split(name, ".") {
+		children := node.exact
+		if strings.IndexByte(seg, '*') >= 0 {
+			children = node.globs
+		}
+
+		child, ok := children[seg]
+		if !ok {
+			child = newTrieNode()
+			children[seg] = child
+		}
+		node = child
+	}
+	node.pattern = name
+}
+
+// Remove a listened name
+func (m *TrieMatcher) Remove(name string) {
+	if name == Wildcard {
+		m.wildcard = false
+		return
+	}
+
+	if isPattern(name) {
+		m.remove(m.root, strings.Split(name, "."))
+	}
+}
+
+// remove the pattern and prune the empty nodes
+func (m *TrieMatcher) remove(node *trieNode, segs []string) {
+	if len(segs) == 0 {
+		node.pattern = ""
+		return
+	}
+
+	children := node.exact
+	if strings.IndexByte(segs[0], '*') >= 0 {
+		children = node.globs
+	}
+
+	if child, ok := children[segs[0]]; ok {
+		m.remove(child, segs[1:])
+		if child.isEmpty() {
+			delete(children, segs[0])
+		}
+	}
+}
+
+// Match find the listened names matched the event name
+func (m *TrieMatcher) Match(name string) (ms []string) {
+	ms = m.match(m.root, strings.Split(name, "."), ms)
+	// same order as the WildcardMatcher
+	sort.Strings(ms)
+
+	if m.wildcard {
+		ms = append(ms, Wildcard)
+	}
+	return
+}
+
+func (m *TrieMatcher) match(node *trieNode, segs []string, ms []string) []string {
+	if len(segs) == 0 {
+		if node.pattern != "" {
+			ms = append(ms, node.pattern)
+		}
+		return ms
+	}
+
+	if child, ok := node.exact[segs[0]]; ok {
+		ms = m.match(child, segs[1:], ms)
+	}
+
+	for seg, child := range node.globs {
+		if matchGlob(seg, segs[0]) {
+			ms = m.match(child, segs[1:], ms)
+		}
+	}
+	return ms
+}
+
+/*************************************************************
+ * helper func
+ *************************************************************/
+
+// insertSorted insert the string to the sorted slice, skip exists.
+func insertSorted(ss []string, s string) []string {
+	pos := sort.SearchStrings(ss, s)
+	if pos < len(ss) && ss[pos] == s {
+		return ss
+	}
+
+	ss = append(ss, "")
+	copy(ss[pos+1:], ss[pos:])
+	ss[pos] = s
+	return ss
+}
+
+// removeSorted remove the string from the sorted slice
+func removeSorted(ss []string, s string) []string {
+	pos := sort.SearchStrings(ss, s)
+	if pos < len(ss) && ss[pos] == s {
+		ss = append(ss[:pos], ss[pos+1:]...)
+	}
+	return ss
+}
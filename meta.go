@@ -0,0 +1,48 @@
+package event
+
+// There are the meta events about the manager itself. see Manager.Meta()
+// the event data: event, listener, error
+const (
+	MetaListenerAdded   = "listener.added"
+	MetaListenerRemoved = "listener.removed"
+	MetaListenerError   = "listener.error"
+	MetaEventUnhandled  = "event.unhandled"
+	MetaEventDuplicate  = "event.duplicate"
+)
+
+// Meta get the isolated manager for the meta events of the manager itself.
+// the meta events are fired to the meta manager instead of the manager, so the
+// listeners of them cannot trigger the meta events recursively.
+// the meta manager has no meta events itself.
+//
+// Usage:
+// 	em.Meta().On(MetaListenerError, ListenerFunc(func(e Event) error {
+// 		log.Printf("listener %s of %s error: %v", e.Get("listener"), e.Get("event"), e.Get("error"))
+// 		return nil
+// 	}))
+func (em *Manager) Meta() *Manager {
+	if em.isMeta {
+		return em
+	}
+
+	em.metaOnce.Do(func() {
+		meta := NewManager(em.name+".meta", WithConcurrencySafe())
+		meta.isMeta = true
+		em.meta.Store(meta)
+	})
+	return em.meta.Load().(*Manager)
+}
+
+// emitMeta fire the meta event to the meta manager, if it has listeners.
+func (em *Manager) emitMeta(name, event, listener string, err error) {
+	meta, ok := em.meta.Load().(*Manager)
+	if !ok || !meta.HasListeners(name) {
+		return
+	}
+
+	data := M{"event": event, "listener": listener}
+	if err != nil {
+		data["error"] = err
+	}
+	_, _ = meta.Fire(name, data)
+}
@@ -0,0 +1,125 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	topic   string
+	qos     byte
+	payload []byte
+}
+
+type testClient struct {
+	published []*message
+	handlers  map[string]func(topic string, payload []byte)
+}
+
+func (c *testClient) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	c.published = append(c.published, &message{topic, qos, payload})
+	return nil
+}
+
+func (c *testClient) Subscribe(topic string, qos byte, handler func(topic string, payload []byte)) error {
+	c.handlers[topic] = handler
+	return nil
+}
+
+func (c *testClient) Unsubscribe(topics ...string) error {
+	for _, topic := range topics {
+		delete(c.handlers, topic)
+	}
+	return nil
+}
+
+func TestNameToTopic(t *testing.T) {
+	assert.Equal(t, "app/db/error", NameToTopic("app.db.error"))
+	assert.Equal(t, "app/+", NameToTopic("app.*"))
+	assert.Equal(t, "app/+/error", NameToTopic("app.*.error"))
+	assert.Equal(t, "#", NameToTopic("*"))
+
+	assert.Equal(t, "app.db.error", TopicToName("app/db/error"))
+	assert.Equal(t, "app.*", TopicToName("app/+"))
+	assert.Equal(t, "app", TopicToName("app/#"))
+	assert.Equal(t, "*", TopicToName("#"))
+}
+
+func TestBridge(t *testing.T) {
+	em := event.NewManager("test")
+	client := &testClient{handlers: map[string]func(string, []byte){}}
+
+	b := New(em, client)
+	b.TopicPrefix = "gw1/"
+	b.QoS = 1
+	b.SetQoS("alarm.*", 2)
+	b.Publish("sensor.*", "alarm.*")
+	assert.NoError(t, b.Subscribe("cmd.*"))
+	assert.NotNil(t, client.handlers["gw1/cmd/+"])
+
+	em.MustFire("sensor.temp", event.M{"val": 23})
+	em.MustFire("alarm.fire", nil)
+	assert.Len(t, client.published, 2)
+	assert.Equal(t, "gw1/sensor/temp", client.published[0].topic)
+	assert.Equal(t, byte(1), client.published[0].qos)
+	assert.Equal(t, byte(2), client.published[1].qos)
+
+	// the exact name first, then the first registered pattern
+	b.SetQoS("*", 0).SetQoS("alarm.smoke", 1)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, byte(2), b.QoSOf("alarm.fire"))
+		assert.Equal(t, byte(1), b.QoSOf("alarm.smoke"))
+		assert.Equal(t, byte(0), b.QoSOf("sensor.temp"))
+	}
+	b.SetQoS("alarm.*", 1)
+	assert.Equal(t, byte(1), b.QoSOf("alarm.fire"))
+	b.SetQoS("alarm.*", 2)
+
+	e, err := event.DefaultCodec.Decode(client.published[0].payload)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(23), e.Get("val"))
+
+	// receive messages
+	var got []event.Event
+	em.On("cmd.*", event.ListenerFunc(func(e event.Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	client.handlers["gw1/cmd/+"]("gw1/cmd/reboot", []byte("now"))
+	client.handlers["gw1/cmd/+"]("gw1/cmd/reboot", []byte(`{"name":"cmd.reset","data":{"id":1}}`))
+	assert.Len(t, got, 2)
+	assert.Equal(t, "cmd.reboot", got[0].Name())
+	assert.Equal(t, "now", got[0].Get("payload"))
+	assert.Equal(t, "cmd.reset", got[1].Name())
+
+	// the received events are not published again
+	assert.NoError(t, b.Handle("gw1/sensor/temp", []byte("1")))
+	assert.Len(t, client.published, 2)
+
+	assert.NoError(t, b.Close())
+	assert.Len(t, client.handlers, 0)
+	em.MustFire("sensor.temp", nil)
+	assert.Len(t, client.published, 2)
+}
+
+func TestBridge_Close_multi(t *testing.T) {
+	em := event.NewManager("test")
+	c1 := &testClient{handlers: map[string]func(string, []byte){}}
+	c2 := &testClient{handlers: map[string]func(string, []byte){}}
+
+	b1, b2 := New(em, c1), New(em, c2)
+	b1.Publish("sensor.*")
+	b2.Publish("sensor.*")
+
+	em.MustFire("sensor.temp", nil)
+	assert.Len(t, c1.published, 1)
+	assert.Len(t, c2.published, 1)
+
+	// close b1 will not remove the publisher of b2
+	assert.NoError(t, b1.Close())
+	em.MustFire("sensor.temp", nil)
+	assert.Len(t, c1.published, 1)
+	assert.Len(t, c2.published, 2)
+}
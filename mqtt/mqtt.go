@@ -0,0 +1,236 @@
+// Package mqtt bridge the events between the event manager and MQTT topics.
+//
+// the package has no client dependency, the Client can be wrapped from the
+// paho.mqtt.golang client. the event name "app.db.error" is mapped to the
+// topic "app/db/error", the pattern segment "*" is mapped to "+", and the
+// wildcard "*" is mapped to "#".
+package mqtt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gookit/event"
+)
+
+// OriginKey the event data key for mark the event is from MQTT.
+// the events has the key will not be published again, for avoid loop.
+const OriginKey = "__mqtt_topic"
+
+// Client the MQTT client interface.
+//
+// Usage by paho:
+// 	func (c *pahoClient) Publish(topic string, qos byte, retained bool, payload []byte) error {
+// 		t := c.Client.Publish(topic, qos, retained, payload)
+// 		t.Wait()
+// 		return t.Error()
+// 	}
+type Client interface {
+	Publish(topic string, qos byte, retained bool, payload []byte) error
+	Subscribe(topic string, qos byte, handler func(topic string, payload []byte)) error
+	Unsubscribe(topics ...string) error
+}
+
+// NameToTopic convert the event name or pattern to MQTT topic (filter)
+// Usage:
+// 	NameToTopic("app.db.error") // "app/db/error"
+// 	NameToTopic("app.*") // "app/+"
+// 	NameToTopic("*") // "#"
+func NameToTopic(name string) string {
+	if name == event.Wildcard {
+		return "#"
+	}
+
+	segs := strings.Split(name, ".")
+	for i, seg := range segs {
+		if seg == event.Wildcard {
+			segs[i] = "+"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// TopicToName convert the MQTT topic (filter) to event name or pattern
+// Usage:
+// 	TopicToName("app/db/error") // "app.db.error"
+// 	TopicToName("app/+") // "app.*"
+// 	TopicToName("#") // "*"
+func TopicToName(topic string) string {
+	if topic == "#" {
+		return event.Wildcard
+	}
+
+	segs := strings.Split(strings.TrimSuffix(topic, "/#"), "/")
+	for i, seg := range segs {
+		if seg == "+" {
+			segs[i] = event.Wildcard
+		}
+	}
+	return strings.Join(segs, ".")
+}
+
+// Bridge the events between the Manager and MQTT
+//
+// Usage:
+// 	b := mqtt.New(em, client)
+// 	b.TopicPrefix = "fleet/gw1/"
+// 	b.SetQoS("alarm.*", 2)
+//
+// 	// publish local "sensor.*" events to MQTT
+// 	b.Publish("sensor.*", "alarm.*")
+// 	// fire the events from MQTT topics "cmd/+"
+// 	err := b.Subscribe("cmd.*")
+type Bridge struct {
+	mu     sync.RWMutex
+	em     *event.Manager
+	client Client
+	// Codec for encode and decode events. default is event.DefaultCodec
+	Codec event.Codec
+	// QoS the default QoS level
+	QoS byte
+	// Retained the retained flag on publish
+	Retained bool
+	// StampKeys set the idempotency key to the published events without key, so the subscribers
+	// can dedupe the QoS 1 duplicate messages by the event.WithIdempotency()
+	StampKeys bool
+	// TopicPrefix for all topics. eg: "app/"
+	TopicPrefix string
+	// OnError callback on handle the message error. optional
+	OnError func(err error)
+	// qos levels by the event name patterns, in the registered order
+	qos []qosLevel
+	// the subscribed topics
+	topics []string
+	// scope of the publish listeners
+	scope *event.Scope
+}
+
+type qosLevel struct {
+	pattern string
+	qos     byte
+}
+
+// New create a MQTT bridge
+func New(em *event.Manager, client Client) *Bridge {
+	return &Bridge{em: em, client: client, Codec: event.DefaultCodec, scope: em.NewScope()}
+}
+
+// SetQoS setting the QoS level for the event names matched the pattern
+func (b *Bridge) SetQoS(pattern string, qos byte) *Bridge {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, lv := range b.qos {
+		if lv.pattern == pattern {
+			b.qos[i].qos = qos
+			return b
+		}
+	}
+	b.qos = append(b.qos, qosLevel{pattern: pattern, qos: qos})
+	return b
+}
+
+// QoSOf get the QoS level of the event name.
+// the exact name is preferred, then the first registered pattern matched the name.
+func (b *Bridge) QoSOf(name string) byte {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, lv := range b.qos {
+		if lv.pattern == name {
+			return lv.qos
+		}
+	}
+	for _, lv := range b.qos {
+		if event.MatchName(lv.pattern, name) {
+			return lv.qos
+		}
+	}
+	return b.QoS
+}
+
+// Topic get the MQTT topic of the event name
+func (b *Bridge) Topic(name string) string {
+	return b.TopicPrefix + NameToTopic(name)
+}
+
+// Publish the local events matched the patterns to MQTT.
+// the events from MQTT will not be published.
+func (b *Bridge) Publish(patterns ...string) {
+	b.mu.RLock()
+	scope := b.scope
+	b.mu.RUnlock()
+
+	for _, pattern := range patterns {
+		scope.On(pattern, event.ListenerFunc(b.publish))
+	}
+}
+
+func (b *Bridge) publish(e event.Event) error {
+	if e.Get(OriginKey) != nil {
+		return nil
+	}
+
+	if b.StampKeys {
+		event.StampKey(e)
+	}
+
+	bs, err := b.Codec.Encode(e)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(b.Topic(e.Name()), b.QoSOf(e.Name()), b.Retained, bs)
+}
+
+// Subscribe the MQTT topics by the event name patterns, and fire the received events.
+func (b *Bridge) Subscribe(patterns ...string) error {
+	for _, pattern := range patterns {
+		topic := b.Topic(pattern)
+		if err := b.client.Subscribe(topic, b.QoSOf(pattern), b.handle); err != nil {
+			return fmt.Errorf("mqtt: subscribe the topic '%s' error: %v", topic, err)
+		}
+
+		b.mu.Lock()
+		b.topics = append(b.topics, topic)
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// handle the MQTT message
+func (b *Bridge) handle(topic string, payload []byte) {
+	if err := b.Handle(topic, payload); err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// Handle the MQTT message, fire the decoded event.
+// if decode failed, the event name is converted from the topic and the payload
+// will be set to the event data "payload".
+func (b *Bridge) Handle(topic string, payload []byte) error {
+	e, err := b.Codec.Decode(payload)
+	if err != nil {
+		name := TopicToName(strings.TrimPrefix(topic, b.TopicPrefix))
+		e = event.NewBasic(name, event.M{"payload": string(payload)})
+	}
+
+	e.Set(OriginKey, topic)
+	return b.em.FireEvent(e)
+}
+
+// Close unsubscribe the topics and stop publish events
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	topics, scope := b.topics, b.scope
+	b.topics, b.scope = nil, b.em.NewScope()
+	b.mu.Unlock()
+
+	scope.Close()
+
+	if len(topics) > 0 {
+		return b.client.Unsubscribe(topics...)
+	}
+	return nil
+}
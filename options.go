@@ -0,0 +1,347 @@
+package event
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MatchMode how to match listeners by the fired event name
+type MatchMode uint8
+
+// There are some match modes
+const (
+	// ModeWildcard match exact name, group name "app.*" and wildcard "*". it's default mode.
+	ModeWildcard MatchMode = iota
+	// ModeExact only match listeners by the exact event name
+	ModeExact
+)
+
+// ErrorPolicy how to handle the error returned by listener
+type ErrorPolicy uint8
+
+// There are some error policies
+const (
+	// PolicyStop stop call next listeners and return the error. it's default policy.
+	PolicyStop ErrorPolicy = iota
+	// PolicyCollect continue call next listeners, all errors will be returned as Errors
+	PolicyCollect
+	// PolicyIgnore continue call next listeners and ignore the errors
+	PolicyIgnore
+)
+
+// DuplicatePolicy how to handle the duplicate listener registration.
+// the listener is duplicate if it's same instance or has same Label with a registered listener.
+type DuplicatePolicy uint8
+
+// There are some duplicate policies
+const (
+	// DuplicateAllow add the duplicate listener. it's default policy.
+	DuplicateAllow DuplicatePolicy = iota
+	// DuplicateIgnore skip the duplicate listener
+	DuplicateIgnore
+	// DuplicateWarn add the duplicate listener and log a warning
+	DuplicateWarn
+	// DuplicateError skip the duplicate listener and return ErrDuplicateListener
+	DuplicateError
+)
+
+// Options for the event manager
+type Options struct {
+	// ConcurrencySafe use lock for manage listeners and events
+	ConcurrencySafe bool
+	// MatchMode for match listeners. default is ModeWildcard
+	MatchMode MatchMode
+	// QueueFactory custom func for create the listener queue. default is NewListenerQueue
+	QueueFactory func() Queue
+	// Matcher custom matcher for match the listened pattern names.
+	// if setting, the MatchMode will be ignored.
+	Matcher Matcher
+	// ErrorPolicy for handle listener error. default is PolicyStop
+	ErrorPolicy ErrorPolicy
+	// DisablePool disable use sync.Pool for create BasicEvent
+	DisablePool bool
+	// PoolPrewarm the number of events pre-created for each event pool
+	PoolPrewarm int
+	// CloneOnFire clone the defined Event on fire by name, so the defined Event
+	// is a template and will not be changed by listeners. default is true.
+	CloneOnFire bool
+	// NamePattern custom regex for check event name. default is goodNameReg
+	//
+	// NOTICE: the pattern should allow the char '*' for support group listen. eg "app.*"
+	NamePattern *regexp.Regexp
+	// LenientNames skip check event name by regex, only check name is not empty.
+	LenientNames bool
+	// NameNormalizer normalize the event name on register, fire and lookup.
+	NameNormalizer func(name string) string
+	// Adaptive config for demote or suspend failing listeners. nil is disabled
+	Adaptive *AdaptiveConfig
+	// Idempotency config for suppress the duplicate events. nil is disabled
+	Idempotency *IdempotencyConfig
+	// Logger for the warnings. eg: deprecated event names. default is the std log
+	Logger Logger
+	// AuditLog record the registrations and fires. nil is disabled
+	AuditLog *AuditLog
+	// EventStore persist the fired events before dispatch. nil is disabled
+	EventStore EventStore
+	// StorePatterns limit the persisted event names. empty is all events
+	StorePatterns []string
+	// MaxListeners the max listeners number of each event name. 0 is unlimited.
+	// exceeding it will log a warning, see SetMaxListeners() for setting by name.
+	MaxListeners int
+	// StrictMaxListeners return ErrMaxListeners instead of log warning on exceeding MaxListeners
+	StrictMaxListeners bool
+	// DuplicatePolicy for handle the duplicate listener registration. default is DuplicateAllow
+	DuplicatePolicy DuplicatePolicy
+	// NoPanic report the errors by the ErrorHandler instead of panic, on register listeners,
+	// add events, event factories and pools, and fire with invalid name. the Fire methods will return the error.
+	//
+	// NOTICE: the configuration methods still panic on invalid args. eg: SetProfile()
+	NoPanic bool
+	// ErrorHandler handle the errors on the NoPanic mode. default is log it by the Logger
+	ErrorHandler func(err error)
+	// MaxPending the threshold of the pending async dispatches and listeners. 0 is disabled.
+	// will fire the EventBackpressure on exceeding it. see Manager.Pressure()
+	MaxPending int
+	// MaxFireDepth the max depth of the recursive fire of each event name. 0 is unlimited.
+	// the fire returns ErrFireLoop on exceeding it. see WithMaxFireDepth()
+	MaxFireDepth int
+	// MaxDispatchDepth the max length of the dispatch chain. 0 is unlimited.
+	// the chain is passed by the ctx, see DispatchContext.Chain() and WithMaxDispatchDepth()
+	MaxDispatchDepth int
+	// CloseTimeout the max wait time of each listener Drain and Close on Shutdown(). 0 is unlimited
+	CloseTimeout time.Duration
+	// Trace record the called listeners to the event on fire. see Tracer
+	Trace bool
+	// PprofLabels tag the listener calls with pprof labels "event" and "listener",
+	// so the CPU profiles can attribute time to the listeners.
+	PprofLabels bool
+}
+
+// Option func for config the Manager
+type Option func(o *Options)
+
+// WithConcurrencySafe enable lock for the manager
+func WithConcurrencySafe() Option {
+	return func(o *Options) {
+		o.ConcurrencySafe = true
+	}
+}
+
+// WithMatchMode setting the listener match mode
+func WithMatchMode(mode MatchMode) Option {
+	return func(o *Options) {
+		o.MatchMode = mode
+	}
+}
+
+// WithMatcher setting custom matcher for match the listened pattern names.
+// Usage:
+// 	WithMatcher(NewTrieMatcher())
+// 	WithMatcher(NewRegexMatcher())
+func WithMatcher(m Matcher) Option {
+	return func(o *Options) {
+		o.Matcher = m
+	}
+}
+
+// WithQueueFactory setting custom func for create the listener queue
+func WithQueueFactory(fn func() Queue) Option {
+	return func(o *Options) {
+		o.QueueFactory = fn
+	}
+}
+
+// WithErrorPolicy setting the listener error policy
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *Options) {
+		o.ErrorPolicy = policy
+	}
+}
+
+// WithPoolDisabled disable the BasicEvent pool
+func WithPoolDisabled() Option {
+	return func(o *Options) {
+		o.DisablePool = true
+	}
+}
+
+// WithPoolPrewarm pre-create n events for each event pool
+func WithPoolPrewarm(n int) Option {
+	return func(o *Options) {
+		o.PoolPrewarm = n
+	}
+}
+
+// WithCloneOnFire setting whether clone the defined Event on fire by name.
+// if disabled, the defined Event instance will be shared by all fires.
+func WithCloneOnFire(enable bool) Option {
+	return func(o *Options) {
+		o.CloneOnFire = enable
+	}
+}
+
+// WithNamePattern setting custom regex pattern for check event name.
+// Usage:
+// 	WithNamePattern(`^[a-zA-Z][\w-.*:/]*$`)
+func WithNamePattern(pattern string) Option {
+	reg := regexp.MustCompile(pattern)
+	return func(o *Options) {
+		o.NamePattern = reg
+	}
+}
+
+// WithLenientNames disable check event name by regex
+func WithLenientNames() Option {
+	return func(o *Options) {
+		o.LenientNames = true
+	}
+}
+
+// WithLogger setting the logger for the warnings
+func WithLogger(logger Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+// WithMaxListeners setting the max listeners number of each event name.
+// if strict is true, add listener will return error on exceeding it, otherwise only log a warning.
+func WithMaxListeners(n int, strict bool) Option {
+	return func(o *Options) {
+		o.MaxListeners = n
+		o.StrictMaxListeners = strict
+	}
+}
+
+// WithDuplicatePolicy setting the duplicate listener registration policy
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(o *Options) {
+		o.DuplicatePolicy = policy
+	}
+}
+
+// WithNoPanic enable the NoPanic mode, the errors will be reported by the handler
+// instead of panic. if the handler is not given, will log the errors by the Logger.
+func WithNoPanic(handler ...func(err error)) Option {
+	return func(o *Options) {
+		o.NoPanic = true
+		if len(handler) > 0 {
+			o.ErrorHandler = handler[0]
+		}
+	}
+}
+
+// WithMaxDispatchDepth setting the max length of the dispatch chain.
+// the fire returns *DepthError on exceeding it.
+func WithMaxDispatchDepth(depth int) Option {
+	return func(o *Options) {
+		o.MaxDispatchDepth = depth
+	}
+}
+
+// WithPprofLabels enable tag the listener calls with pprof labels
+func WithPprofLabels() Option {
+	return func(o *Options) {
+		o.PprofLabels = true
+	}
+}
+
+// WithNameNormalizer setting custom func for normalize event names
+func WithNameNormalizer(fn func(name string) string) Option {
+	return func(o *Options) {
+		o.NameNormalizer = fn
+	}
+}
+
+// WithNormalizeNames normalize event names by the NormalizeName
+func WithNormalizeNames() Option {
+	return WithNameNormalizer(NormalizeName)
+}
+
+// NormalizeName normalize the event name: trim space, to lower case and
+// collapse the repeated separator '.'
+// eg: " User..Created. " -> "user.created"
+func NormalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.Contains(name, "..") && !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	nodes := strings.Split(name, ".")
+	parts := nodes[:0]
+	for _, node := range nodes {
+		if node != "" {
+			parts = append(parts, node)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// fireOptions the options for once fire. see Manager.FireWith()
+type fireOptions struct {
+	async   bool
+	timeout time.Duration
+	// override the manager ErrorPolicy
+	policy    ErrorPolicy
+	hasPolicy bool
+	// retry the failed listener
+	retries int
+	backoff time.Duration
+	// the sample rate, 0 is fire all
+	sample float64
+	// stop call the next listeners on the ctx is done
+	ctx context.Context
+}
+
+// FireOption func for config once fire
+type FireOption func(fo *fireOptions)
+
+// Async fire the event in a new goroutine, will not wait the result.
+func Async() FireOption {
+	return func(fo *fireOptions) {
+		fo.async = true
+	}
+}
+
+// Timeout wait the listeners result until timeout, will return ErrTimeout on timeout.
+//
+// NOTICE: the listeners will continue run in background after timeout.
+func Timeout(timeout time.Duration) FireOption {
+	return func(fo *fireOptions) {
+		fo.timeout = timeout
+	}
+}
+
+// Policy override the manager error policy for once fire
+func Policy(policy ErrorPolicy) FireOption {
+	return func(fo *fireOptions) {
+		fo.policy = policy
+		fo.hasPolicy = true
+	}
+}
+
+// Retry the failed listener with the max times, wait the backoff before each retry.
+func Retry(times int, backoff time.Duration) FireOption {
+	return func(fo *fireOptions) {
+		fo.retries = times
+		fo.backoff = backoff
+	}
+}
+
+// Sample fire the event by the sample rate in (0, 1]. eg: 0.1 will fire 10% of the events,
+// the others are dropped, see Stats().Sampled
+func Sample(rate float64) FireOption {
+	return func(fo *fireOptions) {
+		fo.sample = rate
+	}
+}
+
+// Context check the ctx between the listener calls, stop call the next listeners on the ctx
+// is done, and return the error wrapped the ctx.Err().
+func Context(ctx context.Context) FireOption {
+	return func(fo *fireOptions) {
+		fo.ctx = ctx
+	}
+}
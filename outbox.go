@@ -0,0 +1,239 @@
+package event
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Outbox write events to the outbox table within the caller's transaction,
+// then the OutboxRelay publish them after commit. it's for avoid the dual-write
+// problem of update the database and publish events.
+//
+// Usage:
+// 	ob := NewOutbox(db, "")
+// 	err := ob.Init()
+//
+// 	tx, _ := db.Begin()
+// 	// ... update business tables by tx
+// 	err = ob.Write(tx, NewBasic("order.created", M{"id": 23}))
+// 	err = tx.Commit()
+//
+// 	relay := NewOutboxRelay(ob, em.FireEvent)
+// 	relay.Start()
+// 	defer relay.Stop()
+type Outbox struct {
+	db *sql.DB
+	// Table name. default is "event_outbox"
+	Table string
+	// Placeholder func for generate the n-th(starts from 1) bind var. default is "?"
+	Placeholder func(n int) string
+}
+
+// outboxSeq for generate the outbox record ID
+var outboxSeq uint64
+
+// NewOutbox create
+func NewOutbox(db *sql.DB, table string) *Outbox {
+	if table == "" {
+		table = "event_outbox"
+	}
+
+	return &Outbox{db: db, Table: table}
+}
+
+// Init create the outbox table if not exists
+func (ob *Outbox) Init() error {
+	_, err := ob.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	data TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	published_at TIMESTAMP NULL
+)`, ob.Table))
+	return err
+}
+
+// Write the event to the outbox within the transaction
+func (ob *Outbox) Write(tx *sql.Tx, e Event) error {
+	data, err := json.Marshal(e.Data())
+	if err != nil {
+		return fmt.Errorf("event: encode the outbox event '%s' error: %w", e.Name(), err)
+	}
+
+	id := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&outboxSeq, 1))
+	query := fmt.Sprintf("INSERT INTO %s (id, name, data, created_at) VALUES (%s)", ob.Table, ob.bindVars(4))
+
+	_, err = tx.Exec(query, id, e.Name(), string(data), time.Now())
+	return err
+}
+
+// outboxRecord an unpublished outbox record
+type outboxRecord struct {
+	id    string
+	event *BasicEvent
+}
+
+// pending read the unpublished records, ordered by the created time.
+func (ob *Outbox) pending(limit int) ([]*outboxRecord, error) {
+	query := fmt.Sprintf(
+		"SELECT id, name, data FROM %s WHERE published_at IS NULL ORDER BY created_at, id LIMIT %d",
+		ob.Table, limit,
+	)
+
+	rows, err := ob.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rs []*outboxRecord
+	for rows.Next() {
+		var id, name, data string
+		if err := rows.Scan(&id, &name, &data); err != nil {
+			return nil, err
+		}
+
+		var m M
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			return nil, err
+		}
+		rs = append(rs, &outboxRecord{id: id, event: NewBasic(name, m)})
+	}
+	return rs, rows.Err()
+}
+
+// markPublished mark the record is published
+func (ob *Outbox) markPublished(id string) error {
+	query := fmt.Sprintf("UPDATE %s SET published_at = %s WHERE id = %s", ob.Table, ob.bindVar(1), ob.bindVar(2))
+	_, err := ob.db.Exec(query, time.Now(), id)
+	return err
+}
+
+// Purge delete the published records before the time
+func (ob *Outbox) Purge(before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE published_at IS NOT NULL AND published_at < %s", ob.Table, ob.bindVar(1))
+	ret, err := ob.db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+	return ret.RowsAffected()
+}
+
+func (ob *Outbox) bindVar(n int) string {
+	if ob.Placeholder != nil {
+		return ob.Placeholder(n)
+	}
+	return "?"
+}
+
+func (ob *Outbox) bindVars(num int) string {
+	ss := make([]string, num)
+	for i := range ss {
+		ss[i] = ob.bindVar(i + 1)
+	}
+	return strings.Join(ss, ", ")
+}
+
+// OutboxRelay poll the outbox and publish the unpublished events.
+// the event is marked published after publish success, so the event
+// maybe published more than once on failure. listeners should be idempotent.
+type OutboxRelay struct {
+	outbox  *Outbox
+	publish func(e Event) error
+	// Interval for poll the outbox. default is 1s
+	Interval time.Duration
+	// BatchSize the max number of records read at once. default is 100
+	BatchSize int
+	// OnError callback on relay error. optional
+	OnError func(err error)
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewOutboxRelay create. the publish func can be Manager.FireEvent or a transport.
+func NewOutboxRelay(ob *Outbox, publish func(e Event) error) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    ob,
+		publish:   publish,
+		Interval:  time.Second,
+		BatchSize: 100,
+	}
+}
+
+// RelayOnce publish the unpublished events once. return the published number.
+// will stop on the first publish error, for keep the events order.
+func (r *OutboxRelay) RelayOnce() (n int, err error) {
+	size := r.BatchSize
+	if size <= 0 {
+		size = 100
+	}
+
+	rs, err := r.outbox.pending(size)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, rec := range rs {
+		if err = r.publish(rec.event); err != nil {
+			return n, fmt.Errorf("event: relay the outbox event '%s' error: %w", rec.event.Name(), err)
+		}
+
+		if err = r.outbox.markPublished(rec.id); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return
+}
+
+// Start poll and relay the outbox in background
+func (r *OutboxRelay) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := r.RelayOnce(); err != nil && r.OnError != nil {
+					r.OnError(err)
+				}
+			}
+		}
+	}(r.stop, r.done)
+}
+
+// Stop the background relay, will wait the running relay done.
+func (r *OutboxRelay) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop == nil {
+		return
+	}
+
+	close(r.stop)
+	<-r.done
+	r.stop, r.done = nil, nil
+}
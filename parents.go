@@ -0,0 +1,75 @@
+package event
+
+// SetEventParents setting the parent events for the event name.
+// listeners on the parent events will also receive the child event,
+// the event name is not changed on call the parent listeners.
+//
+// Usage:
+// 	em.SetEventParents("order.shipped", "order.changed")
+// 	// the listener will receive "order.shipped" event
+// 	em.On("order.changed", listener)
+func (em *Manager) SetEventParents(name string, parents ...string) {
+	if err := em.TrySetEventParents(name, parents...); err != nil {
+		panic(err)
+	}
+}
+
+// TrySetEventParents setting the parent events for the event name.
+// will return error instead of panic on the name is invalid or the manager is sealed.
+func (em *Manager) TrySetEventParents(name string, parents ...string) (err error) {
+	if em.IsSealed() {
+		return ErrSealed
+	}
+
+	if name, err = em.checkName(name); err != nil {
+		return err
+	}
+
+	for i, parent := range parents {
+		if parents[i], err = em.checkName(parent); err != nil {
+			return err
+		}
+	}
+
+	em.lock()
+	if len(parents) == 0 {
+		delete(em.parents, name)
+	} else {
+		em.parents[name] = parents
+	}
+	em.unlock()
+	return nil
+}
+
+// EventParents get the direct parent event names of the event
+func (em *Manager) EventParents(name string) []string {
+	name = em.normalize(name)
+
+	em.rLock()
+	defer em.rUnlock()
+	return em.parents[name]
+}
+
+// ancestors get all parent event names of the event, in breadth-first order.
+// will skip the repeated names for avoid circular parents.
+// NOTICE: should call it with lock.
+func (em *Manager) ancestors(name string) (names []string) {
+	if len(em.parents) == 0 {
+		return
+	}
+
+	seen := map[string]bool{name: true}
+	queue := em.parents[name]
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		if seen[parent] {
+			continue
+		}
+
+		seen[parent] = true
+		names = append(names, parent)
+		queue = append(queue, em.parents[parent]...)
+	}
+	return
+}
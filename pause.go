@@ -0,0 +1,96 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// Pause the event names or patterns. the paused events will be dropped on fire,
+// the listeners will not be called. it's allowed on the manager is sealed.
+// Usage:
+// 	em.Pause("order.*")
+// 	em.Fire("order.created", nil) // dropped
+// 	em.Resume("order.*")
+func (em *Manager) Pause(names ...string) {
+	em.lock()
+	for _, name := range names {
+		em.paused[em.normalize(name)] = true
+	}
+	atomic.StoreInt32(&em.pausedNum, int32(len(em.paused)))
+	em.unlock()
+}
+
+// Resume the paused event names or patterns
+func (em *Manager) Resume(names ...string) {
+	em.lock()
+	for _, name := range names {
+		delete(em.paused, em.normalize(name))
+	}
+	atomic.StoreInt32(&em.pausedNum, int32(len(em.paused)))
+	em.unlock()
+}
+
+// PausedNames get the paused event names or patterns, is sorted.
+func (em *Manager) PausedNames() []string {
+	em.rLock()
+	names := make([]string, 0, len(em.paused))
+	for name := range em.paused {
+		names = append(names, name)
+	}
+	em.rUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// IsPaused check the event name is paused, or matched a paused pattern
+func (em *Manager) IsPaused(name string) bool {
+	if atomic.LoadInt32(&em.pausedNum) == 0 {
+		return false
+	}
+
+	em.rLock()
+	defer em.rUnlock()
+
+	for pattern := range em.paused {
+		if MatchName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPriority change the priority of the listeners by the listened name and the listener name.
+// the listener name is the label, or the func/type name. see ListenerItem.Name()
+func (em *Manager) SetPriority(name, listener string, priority int) error {
+	if em.IsSealed() {
+		return ErrSealed
+	}
+
+	em.lock()
+	defer em.unlock()
+
+	lq, ok := em.listeners[em.normalize(name)]
+	if !ok {
+		return fmt.Errorf("%w of '%s'", ErrNoListener, name)
+	}
+
+	var found bool
+	for _, li := range lq.Items() {
+		if li.Name() == listener {
+			// the item is shared by the running dispatches, store the priority atomically.
+			lq.RemoveItem(li)
+			atomic.StoreInt64(&li.state.shift, int64(priority-li.Priority))
+			// reset the demoted priority by the adaptive mode
+			atomic.StoreInt32(&li.state.demoted, 0)
+			lq.Add(li)
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("%w '%s' of '%s'", ErrNoListener, listener, name)
+	}
+	return nil
+}
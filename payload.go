@@ -0,0 +1,164 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// PayloadKey the data key for storage the struct payload. see FireStruct()
+const PayloadKey = "payload"
+
+// Namer interface. the struct payload can implement it for custom event name.
+type Namer interface {
+	Name() string
+}
+
+// StructName get the event name of the struct payload.
+// will use the Name() method if payload implements Namer,
+// otherwise use the type name. eg: "mypkg.UserCreated"
+func StructName(payload interface{}) string {
+	if nm, ok := payload.(Namer); ok {
+		return nm.Name()
+	}
+
+	rt := reflect.TypeOf(payload)
+	if rt == nil {
+		panic("event: the struct payload cannot be nil")
+	}
+
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.String()
+}
+
+// Payload get the struct payload from the event data
+func Payload(e Event) interface{} {
+	return e.Get(PayloadKey)
+}
+
+// FireStruct fire event by the struct payload, the event name is derived from
+// the payload type. see StructName()
+//
+// Usage:
+// 	em.FireStruct(UserCreated{ID: 5}) // fire event "mypkg.UserCreated"
+//
+// 	em.On("mypkg.UserCreated", ListenerFunc(func(e Event) error {
+// 		uc := Payload(e).(UserCreated)
+// 		...
+// 	}))
+func (em *Manager) FireStruct(payload interface{}) (error, Event) {
+	if payload == nil {
+		return fmt.Errorf("event: the struct payload cannot be nil"), nil
+	}
+
+	return em.Fire(StructName(payload), M{PayloadKey: payload})
+}
+
+var (
+	eventType = reflect.TypeOf((*Event)(nil)).Elem()
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// typedListener a listener bind the payload to typed handler argument.
+type typedListener struct {
+	fn reflect.Value
+	// the payload type
+	typ reflect.Type
+	// the first argument is Event
+	withEvent bool
+}
+
+// TypedListener create listener by a typed handler func, the payload will be
+// asserted (or decoded from event data) to the handler argument.
+// Supported func signatures:
+// 	func(e Event, payload T) error
+// 	func(payload T) error
+//
+// Usage:
+// 	em.On("mypkg.UserCreated", TypedListener(func(e Event, uc UserCreated) error {
+// 		fmt.Println(uc.ID)
+// 		return nil
+// 	}))
+func TypedListener(fn interface{}) Listener {
+	rv := reflect.ValueOf(fn)
+	rt := rv.Type()
+	if rt.Kind() != reflect.Func {
+		panic("event: the typed listener must be a func")
+	}
+
+	if rt.NumOut() != 1 || rt.Out(0) != errorType {
+		panic("event: the typed listener must return an error")
+	}
+
+	tl := &typedListener{fn: rv}
+	switch rt.NumIn() {
+	case 1:
+		tl.typ = rt.In(0)
+	case 2:
+		if rt.In(0) != eventType {
+			panic("event: the first argument of the typed listener must be Event")
+		}
+		tl.typ = rt.In(1)
+		tl.withEvent = true
+	default:
+		panic("event: the typed listener must have one or two arguments")
+	}
+	return tl
+}
+
+// Handle event. implements the Listener interface
+func (tl *typedListener) Handle(e Event) error {
+	arg, err := bindPayload(e, tl.typ)
+	if err != nil {
+		return err
+	}
+
+	var rets []reflect.Value
+	if tl.withEvent {
+		rets = tl.fn.Call([]reflect.Value{reflect.ValueOf(&e).Elem(), arg})
+	} else {
+		rets = tl.fn.Call([]reflect.Value{arg})
+	}
+
+	if err, ok := rets[0].Interface().(error); ok {
+		return err
+	}
+	return nil
+}
+
+// bindPayload bind the event payload to the given type.
+func bindPayload(e Event, typ reflect.Type) (reflect.Value, error) {
+	if v := Payload(e); v != nil {
+		rv := reflect.ValueOf(v)
+		switch {
+		case rv.Type().AssignableTo(typ):
+			return rv, nil
+		case typ.Kind() == reflect.Ptr && rv.Type().AssignableTo(typ.Elem()):
+			ptr := reflect.New(typ.Elem())
+			ptr.Elem().Set(rv)
+			return ptr, nil
+		case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Type().AssignableTo(typ):
+			return rv.Elem(), nil
+		}
+
+		return reflect.Value{}, fmt.Errorf("event: cannot bind payload type %s to %s", rv.Type(), typ)
+	}
+
+	// decode from the event data
+	ptr := reflect.New(typ)
+	if err := convertData(e.Data(), ptr.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("event: cannot bind event data to %s: %v", typ, err)
+	}
+	return ptr.Elem(), nil
+}
+
+// convertData convert the map data to the struct pointer by JSON
+func convertData(data map[string]interface{}, ptr interface{}) error {
+	bs, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, ptr)
+}
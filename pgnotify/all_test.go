@@ -0,0 +1,88 @@
+package pgnotify
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type testListener struct {
+	channels []string
+	ch       chan *Notification
+}
+
+func (l *testListener) Listen(channel string) error {
+	l.channels = append(l.channels, channel)
+	return nil
+}
+
+func (l *testListener) Notifications() <-chan *Notification {
+	return l.ch
+}
+
+type testExecer struct {
+	args [][]interface{}
+}
+
+func (e *testExecer) Exec(query string, args ...interface{}) (sql.Result, error) {
+	e.args = append(e.args, args)
+	return nil, nil
+}
+
+func TestSource(t *testing.T) {
+	em := event.NewManager("test")
+	fired := make(chan event.Event, 10)
+	em.On("*", event.ListenerFunc(func(e event.Event) error {
+		fired <- e
+		return nil
+	}))
+
+	ln := &testListener{ch: make(chan *Notification)}
+	src := New(em, ln).Channel("orders").Channel("users", "user.changed")
+	assert.NoError(t, src.Start())
+	assert.Len(t, ln.channels, 2)
+
+	ln.ch <- &Notification{Channel: "orders", Payload: `{"name":"order.created","data":{"id":23}}`}
+	e := <-fired
+	assert.Equal(t, "order.created", e.Name())
+	assert.Equal(t, float64(23), e.Get("id"))
+	assert.Equal(t, "orders", e.Get(OriginKey))
+
+	ln.ch <- &Notification{Channel: "users", Payload: "23"}
+	e = <-fired
+	assert.Equal(t, "user.changed", e.Name())
+	assert.Equal(t, "23", e.Get("payload"))
+
+	ln.ch <- &Notification{Channel: "orders", Payload: "raw"}
+	e = <-fired
+	assert.Equal(t, "orders", e.Name())
+
+	// not listened
+	assert.NoError(t, src.Handle(&Notification{Channel: "other", Payload: "raw"}))
+	src.Stop()
+	assert.Len(t, fired, 0)
+}
+
+func TestSource_Publish(t *testing.T) {
+	em := event.NewManager("test")
+	db := &testExecer{}
+	src := New(em, &testListener{ch: make(chan *Notification)}).Channel("orders")
+	src.Publish(db, "orders", "order.*")
+
+	em.MustFire("order.created", event.M{"id": 23})
+	assert.Len(t, db.args, 1)
+	assert.Equal(t, "orders", db.args[0][0])
+
+	var env Envelope
+	assert.NoError(t, json.Unmarshal([]byte(db.args[0][1].(string)), &env))
+	assert.Equal(t, "order.created", env.Name)
+
+	// self published notification is skipped
+	assert.NoError(t, src.Handle(&Notification{Channel: "orders", Payload: db.args[0][1].(string)}))
+	// the remote event is not published again
+	assert.NoError(t, src.Handle(&Notification{Channel: "orders", Payload: `{"name":"order.paid"}`}))
+	assert.Len(t, db.args, 1)
+}
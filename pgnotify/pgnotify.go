@@ -0,0 +1,227 @@
+// Package pgnotify convert the postgres NOTIFY payloads to events, and
+// publish the local events by NOTIFY.
+//
+// the package has no driver dependency, the notification Listener can be
+// wrapped from the lib/pq pq.Listener or the pgx connection.
+package pgnotify
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// OriginKey the event data key for mark the event is from the notification.
+// the events has the key will not be published again, for avoid loop.
+const OriginKey = "__pg_origin"
+
+// Notification a postgres notification
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// Listener the postgres notification listener.
+//
+// Usage by lib/pq:
+// 	type pqListener struct {
+// 		*pq.Listener
+// 		ch chan *pgnotify.Notification
+// 	}
+// 	// convert the pq.Notification in a goroutine
+// 	for n := range l.Listener.Notify {
+// 		if n != nil {
+// 			l.ch <- &pgnotify.Notification{Channel: n.Channel, Payload: n.Extra}
+// 		}
+// 	}
+type Listener interface {
+	// Listen start listen the channel
+	Listen(channel string) error
+	// Notifications get the notification channel
+	Notifications() <-chan *Notification
+}
+
+// Execer for exec the NOTIFY statement. *sql.DB and *sql.Tx are implemented it.
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Envelope the JSON payload format of the notification.
+// if the payload is not an envelope, the event name is mapped by the channel,
+// and the raw payload will be set to the event data "payload".
+type Envelope struct {
+	Name   string  `json:"name"`
+	Data   event.M `json:"data"`
+	Origin string  `json:"origin,omitempty"`
+}
+
+// Source fire events by the postgres notifications
+//
+// Usage:
+// 	src := pgnotify.New(em, listener)
+// 	src.Channel("orders")                  // use the envelope name or "orders" as event name
+// 	src.Channel("users", "user.changed")   // fire "user.changed" event
+// 	err := src.Start()
+// 	defer src.Stop()
+//
+// 	// publish the local "order.*" events to the channel "orders"
+// 	src.Publish(db, "orders", "order.*")
+type Source struct {
+	mu sync.Mutex
+	em *event.Manager
+	ln Listener
+	// channel -> event name
+	channels map[string]string
+	// the source ID for skip the self published notifications
+	origin string
+	stop   chan struct{}
+	done   chan struct{}
+	// OnError callback on handle notification error. optional
+	OnError func(err error)
+}
+
+// New create a notification source
+func New(em *event.Manager, ln Listener) *Source {
+	return &Source{
+		em:       em,
+		ln:       ln,
+		channels: make(map[string]string),
+		origin:   strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// Channel add a channel to listen. the eventName is optional.
+func (s *Source) Channel(channel string, eventName ...string) *Source {
+	name := ""
+	if len(eventName) > 0 {
+		name = eventName[0]
+	}
+
+	s.mu.Lock()
+	s.channels[channel] = name
+	s.mu.Unlock()
+	return s
+}
+
+// Start listen the channels and fire events in background
+func (s *Source) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return nil
+	}
+
+	for channel := range s.channels {
+		if err := s.ln.Listen(channel); err != nil {
+			return fmt.Errorf("pgnotify: listen the channel '%s' error: %v", channel, err)
+		}
+	}
+
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.loop(s.stop, s.done)
+	return nil
+}
+
+// Stop the background loop
+func (s *Source) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+func (s *Source) loop(stop, done chan struct{}) {
+	defer close(done)
+	ch := s.ln.Notifications()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case n, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			if n == nil { // the connection is re-established
+				continue
+			}
+
+			if err := s.Handle(n); err != nil && s.OnError != nil {
+				s.OnError(err)
+			}
+		}
+	}
+}
+
+// Handle the notification, fire the event to the manager.
+func (s *Source) Handle(n *Notification) error {
+	s.mu.Lock()
+	name, ok := s.channels[n.Channel]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	var env Envelope
+	if err := json.Unmarshal([]byte(n.Payload), &env); err != nil || env.Name == "" {
+		env = Envelope{Data: event.M{"payload": n.Payload}}
+	}
+
+	// self published
+	if env.Origin == s.origin {
+		return nil
+	}
+
+	if name == "" {
+		name = env.Name
+	}
+	if name == "" {
+		name = n.Channel
+	}
+
+	if env.Data == nil {
+		env.Data = event.M{}
+	}
+	env.Data[OriginKey] = n.Channel
+
+	err, _ := s.em.TryFire(name, env.Data)
+	return err
+}
+
+// Publish the local events matched the patterns to the channel by NOTIFY.
+// the events from the notifications will not be published.
+func (s *Source) Publish(db Execer, channel string, patterns ...string) {
+	pub := event.ListenerFunc(func(e event.Event) error {
+		if e.Get(OriginKey) != nil {
+			return nil
+		}
+
+		return Notify(db, channel, &Envelope{Name: e.Name(), Data: e.Data(), Origin: s.origin})
+	})
+
+	for _, pattern := range patterns {
+		s.em.On(pattern, pub)
+	}
+}
+
+// Notify send the envelope to the channel by NOTIFY
+func Notify(db Execer, channel string, env *Envelope) error {
+	bs, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("SELECT pg_notify($1, $2)", channel, string(bs))
+	return err
+}
@@ -0,0 +1,117 @@
+package event
+
+import "fmt"
+
+// There are the default pipeline stages. see Manager.Pipeline()
+const (
+	StageValidate = "validate"
+	StageEnrich   = "enrich"
+	StageHandle   = "handle"
+	StageNotify   = "notify"
+)
+
+// DefaultStages the default stages of the pipeline
+var DefaultStages = []string{StageValidate, StageEnrich, StageHandle, StageNotify}
+
+// the priority gap between the pipeline stages
+const stageGap = 1000
+
+// Pipeline the staged listeners of an event name. the listeners are registered into
+// named stages instead of the numeric priorities, the stages are called in order, and
+// all listeners in a stage must be finished (including the async listeners) before the next stage.
+//
+// NOTICE: should not mix the pipeline and the numeric priorities on the same event name.
+//
+// Usage:
+// 	p := em.Pipeline("order.created") // use the DefaultStages
+// 	p.On(StageValidate, checkStock)
+// 	p.On(StageNotify, sendMail)
+// 	p.Listen(StageEnrich, loadUser, ListenOpts{Async: true})
+type Pipeline struct {
+	em     *Manager
+	name   string
+	stages []string
+}
+
+// Pipeline create the staged pipeline of the event name, the stages are called in the given order.
+// if the stages is empty, will use the DefaultStages.
+func (em *Manager) Pipeline(name string, stages ...string) *Pipeline {
+	if len(stages) == 0 {
+		stages = DefaultStages
+	}
+
+	p := &Pipeline{em: em, name: em.goodName(name), stages: append([]string(nil), stages...)}
+	priorities := make([]int, len(p.stages))
+	for i := range p.stages {
+		priorities[i] = p.priority(i)
+	}
+
+	em.SetBarriers(p.name, priorities...)
+	return p
+}
+
+// Name get the event name of the pipeline
+func (p *Pipeline) Name() string {
+	return p.name
+}
+
+// Stages get the stage names of the pipeline
+func (p *Pipeline) Stages() []string {
+	return p.stages
+}
+
+// priority of the stage index, the first stage has the highest priority.
+func (p *Pipeline) priority(idx int) int {
+	return (len(p.stages) - idx) * stageGap
+}
+
+// stageIndex get the index of the stage name, returns -1 if not found.
+func (p *Pipeline) stageIndex(stage string) int {
+	for i, s := range p.stages {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// On register a listener to the stage
+func (p *Pipeline) On(stage string, listener Listener) {
+	p.Listen(stage, listener, ListenOpts{})
+}
+
+// Listen register a listener with options to the stage. the opts.Priority is ignored.
+func (p *Pipeline) Listen(stage string, listener Listener, opts ListenOpts) {
+	if err := p.TryListen(stage, listener, opts); err != nil {
+		p.em.fail(err)
+	}
+}
+
+// TryListen register a listener with options to the stage, will return error instead of panic.
+func (p *Pipeline) TryListen(stage string, listener Listener, opts ListenOpts) error {
+	idx := p.stageIndex(stage)
+	if idx < 0 {
+		return fmt.Errorf("event: the stage '%s' is not defined in the pipeline '%s'", stage, p.name)
+	}
+
+	// the priority is decided by the stage
+	opts.Priority = p.priority(idx)
+	return p.em.tryAddListenerItem(p.name, newListenerItem(listener, opts))
+}
+
+// Listeners get the listeners of the stage
+func (p *Pipeline) Listeners(stage string) []*ListenerItem {
+	idx := p.stageIndex(stage)
+	lq := p.em.ListenersByName(p.name)
+	if idx < 0 || lq == nil {
+		return nil
+	}
+
+	var items []*ListenerItem
+	for _, li := range lq.Items() {
+		if li.Priority == p.priority(idx) {
+			items = append(items, li)
+		}
+	}
+	return items
+}
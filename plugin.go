@@ -0,0 +1,173 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Plugin interface. a plugin provide multi event listeners, like the Subscriber.
+type Plugin interface {
+	// Name of the plugin, must be unique.
+	Name() string
+	Subscriber
+}
+
+// plugins storage the plugins registered by RegisterPlugin()
+var plugins = struct {
+	sync.RWMutex
+	m map[string]Plugin
+}{m: make(map[string]Plugin)}
+
+// RegisterPlugin register a plugin to the global. usually called on the plugin package init().
+// the registered plugins can be attached to manager by Manager.LoadPlugins()
+func RegisterPlugin(p Plugin) {
+	if p == nil || p.Name() == "" {
+		panic("event: cannot register empty or unnamed plugin")
+	}
+
+	plugins.Lock()
+	plugins.m[p.Name()] = p
+	plugins.Unlock()
+}
+
+// RegisteredPlugins get all registered plugins, sorted by name.
+func RegisteredPlugins() []Plugin {
+	plugins.RLock()
+	defer plugins.RUnlock()
+
+	ps := make([]Plugin, 0, len(plugins.m))
+	for _, p := range plugins.m {
+		ps = append(ps, p)
+	}
+
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].Name() < ps[j].Name()
+	})
+	return ps
+}
+
+// pluginListener wrap the plugin listener, recover the panic as error.
+type pluginListener struct {
+	plugin   string
+	listener Listener
+}
+
+// Handle event. implements the Listener interface
+func (pl *pluginListener) Handle(e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event: plugin '%s' listener panic on '%s': %v", pl.plugin, e.Name(), r)
+		}
+	}()
+
+	return pl.listener.Handle(e)
+}
+
+// pluginEntry a listener registered by plugin
+type pluginEntry struct {
+	name string
+	item *ListenerItem
+}
+
+// LoadPlugin attach the plugin listeners to the manager.
+// the listeners panic will be recovered and returned as error.
+// if the manager has ACL, the listen access will be checked by the plugin name.
+func (em *Manager) LoadPlugin(p Plugin) error {
+	name := p.Name()
+	if name == "" {
+		return fmt.Errorf("event: the plugin name cannot be empty")
+	}
+
+	// serialize the loading, so the same plugin will not be loaded twice
+	em.pluginMu.Lock()
+	defer em.pluginMu.Unlock()
+
+	if em.HasPlugin(name) {
+		return fmt.Errorf("event: the plugin '%s' has been loaded", name)
+	}
+
+	var entries []pluginEntry
+	for evtName, listener := range p.SubscribedEvents() {
+		var li ListenerItem
+		switch lt := listener.(type) {
+		case Listener:
+			li = ListenerItem{Priority: Normal, Listener: lt}
+		case ListenerItem:
+			li = lt
+		default:
+			return fmt.Errorf("event: invalid listener of the plugin '%s' for event '%s'", name, evtName)
+		}
+
+		if li.Label == "" {
+			li.Label = name
+		}
+		li.Listener = &pluginListener{plugin: name, listener: li.Listener}
+		entries = append(entries, pluginEntry{name: evtName, item: &li})
+	}
+
+	// plugin listeners are checked by the ACL, the identity is plugin name.
+	for _, ent := range entries {
+		if err := em.checkAccess(name, ActionListen, ent.name); err != nil {
+			return err
+		}
+	}
+
+	for i, ent := range entries {
+		if err := em.tryAddListenerItem(ent.name, ent.item); err != nil {
+			em.removeEntries(entries[:i])
+			return err
+		}
+	}
+
+	em.lock()
+	em.plugins[name] = entries
+	em.unlock()
+	return nil
+}
+
+// LoadPlugins attach all registered plugins to the manager
+func (em *Manager) LoadPlugins() error {
+	for _, p := range RegisteredPlugins() {
+		if em.HasPlugin(p.Name()) {
+			continue
+		}
+
+		if err := em.LoadPlugin(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasPlugin check the plugin is loaded
+func (em *Manager) HasPlugin(name string) bool {
+	em.rLock()
+	defer em.rUnlock()
+
+	_, ok := em.plugins[name]
+	return ok
+}
+
+// UnloadPlugin remove all listeners of the plugin
+func (em *Manager) UnloadPlugin(name string) {
+	em.mustNotSealed()
+	em.pluginMu.Lock()
+	defer em.pluginMu.Unlock()
+
+	em.lock()
+	entries, ok := em.plugins[name]
+	delete(em.plugins, name)
+	em.unlock()
+
+	if ok {
+		em.removeEntries(entries)
+	}
+}
+
+func (em *Manager) removeEntries(entries []pluginEntry) {
+	for _, ent := range entries {
+		em.removeItems(em.normalize(strings.TrimSpace(ent.name)), []*ListenerItem{ent.item})
+	}
+}
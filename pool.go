@@ -0,0 +1,194 @@
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Resetter interface. the pooled custom event can implement it for reset
+// the event state before put back to the pool.
+type Resetter interface {
+	Reset()
+}
+
+// PoolStats the usage statistics of an event pool
+type PoolStats struct {
+	// Gets the number of events taken from the pool
+	Gets uint64
+	// Puts the number of events put back to the pool
+	Puts uint64
+	// News the number of events created by the pool. includes the pre-warmed.
+	News uint64
+}
+
+// Reused the number of events reused from the pool
+func (s PoolStats) Reused() uint64 {
+	if s.Gets > s.News {
+		return s.Gets - s.News
+	}
+	return 0
+}
+
+// eventPool a sync.Pool with usage counters.
+// the idle events in the sync.Pool will be freed by the GC on memory pressure.
+type eventPool struct {
+	pool sync.Pool
+	// create new event
+	newFn EventFactory
+	// counters
+	gets, puts, news uint64
+}
+
+func newEventPool(newFn EventFactory, prewarm int) *eventPool {
+	p := &eventPool{newFn: newFn}
+	p.pool.New = func() interface{} {
+		atomic.AddUint64(&p.news, 1)
+		return newFn()
+	}
+
+	for i := 0; i < prewarm; i++ {
+		atomic.AddUint64(&p.news, 1)
+		p.pool.Put(newFn())
+	}
+	return p
+}
+
+func (p *eventPool) get() Event {
+	atomic.AddUint64(&p.gets, 1)
+	return p.pool.Get().(Event)
+}
+
+func (p *eventPool) put(e Event) {
+	atomic.AddUint64(&p.puts, 1)
+	p.pool.Put(e)
+}
+
+func (p *eventPool) stats() PoolStats {
+	return PoolStats{
+		Gets: atomic.LoadUint64(&p.gets),
+		Puts: atomic.LoadUint64(&p.puts),
+		News: atomic.LoadUint64(&p.news),
+	}
+}
+
+// RegisterEventPool register a pool for the custom event type by event name.
+// the FireBatch() and AcquireEvent() will take the event from the pool.
+//
+// NOTICE: the factory should create the event with given name. if the event
+// implements Resetter, Reset() will be called before put back to the pool.
+//
+// Usage:
+// 	em.RegisterEventPool("user.created", func() Event {
+// 		return &UserCreatedEvent{BasicEvent: *NewBasic("user.created", nil)}
+// 	})
+func (em *Manager) RegisterEventPool(name string, factory EventFactory) {
+	if err := em.TryRegisterEventPool(name, factory); err != nil {
+		em.fail(err)
+	}
+}
+
+// TryRegisterEventPool register a pool for the custom event type by event name.
+// will return error on the name is invalid or the factory is nil.
+func (em *Manager) TryRegisterEventPool(name string, factory EventFactory) error {
+	name, err := em.checkName(name)
+	if err != nil {
+		return err
+	}
+
+	if factory == nil {
+		return ErrNilFactory
+	}
+
+	em.lock()
+	em.pools[name] = newEventPool(factory, em.opts.PoolPrewarm)
+	em.unlock()
+	return nil
+}
+
+// PoolStats get the usage statistics of the event pool.
+// if not give name, return stats of the BasicEvent pool.
+func (em *Manager) PoolStats(name ...string) PoolStats {
+	if len(name) == 0 {
+		return em.pool.stats()
+	}
+
+	if p := em.eventPool(em.normalize(name[0])); p != nil {
+		return p.stats()
+	}
+	return PoolStats{}
+}
+
+// AcquireEvent get an event from the pool, should call ReleaseEvent() after used.
+// will get from the registered pool by the name, otherwise get a BasicEvent.
+func (em *Manager) AcquireEvent(name string) Event {
+	return em.acquireEvent(em.goodName(name))
+}
+
+// ReleaseEvent reset and put the event back to the pool.
+// the event cannot be used after released.
+func (em *Manager) ReleaseEvent(e Event) {
+	em.releaseEvent(e)
+}
+
+// eventPool get the registered pool by the event name
+func (em *Manager) eventPool(name string) *eventPool {
+	em.rLock()
+	defer em.rUnlock()
+	return em.pools[name]
+}
+
+// acquireEvent get an event from the pool by the checked name.
+// if no pool registered for the name, will create by the event factory or take a BasicEvent.
+func (em *Manager) acquireEvent(name string) Event {
+	p := em.eventPool(name)
+	if p != nil {
+		var e Event
+		if em.opts.DisablePool {
+			e = p.newFn()
+		} else {
+			e = p.get()
+		}
+
+		// NOTICE: don't use the SetData() return value, it maybe the embedded BasicEvent.
+		e.SetData(make(M))
+		return e
+	}
+
+	if fn := em.eventFactory(name); fn != nil {
+		e := fn()
+		e.SetData(make(M))
+		return e
+	}
+
+	if em.opts.DisablePool {
+		return em.newBasicEvent(name, nil)
+	}
+
+	e := em.pool.get().(*BasicEvent)
+	e.SetName(name)
+	e.SetData(make(M))
+	return e
+}
+
+// releaseEvent reset and put the event back to the pool
+func (em *Manager) releaseEvent(e Event) {
+	if em.opts.DisablePool {
+		return
+	}
+
+	if p := em.eventPool(em.normalize(e.Name())); p != nil {
+		if r, ok := e.(Resetter); ok {
+			r.Reset()
+		}
+
+		e.Abort(false)
+		p.put(e)
+		return
+	}
+
+	// only the BasicEvent can be put back to the default pool
+	if be, ok := e.(*BasicEvent); ok {
+		be.reset()
+		em.pool.put(be)
+	}
+}
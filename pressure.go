@@ -0,0 +1,69 @@
+package event
+
+import "sync/atomic"
+
+// EventBackpressure the event name fired on the pending async works exceeds the
+// Options.MaxPending, and fired again on the pending is reduced to half of it.
+// the event data: pending, threshold, active
+const EventBackpressure = "manager.backpressure"
+
+// WithBackpressure setting the threshold of the pending async dispatches and listeners
+// Usage:
+// 	em := NewManager("app", WithBackpressure(1000))
+// 	em.On(EventBackpressure, ListenerFunc(func(e Event) error {
+// 		limiter.Throttle(e.Get("active").(bool))
+// 		return nil
+// 	}))
+func WithBackpressure(maxPending int) Option {
+	return func(o *Options) {
+		o.MaxPending = maxPending
+	}
+}
+
+// Pending get the number of the pending async dispatches and listeners
+func (em *Manager) Pending() int64 {
+	return atomic.LoadInt64(&em.pending)
+}
+
+// Pressure get the pressure gauge, it's the pending number divided by the Options.MaxPending.
+// the value > 1 means overloaded, the producers should shed load. returns 0 if not setting the MaxPending.
+func (em *Manager) Pressure() float64 {
+	if em.opts.MaxPending <= 0 {
+		return 0
+	}
+	return float64(em.Pending()) / float64(em.opts.MaxPending)
+}
+
+// goAsync run the fn in a new goroutine, and count it to the pending
+func (em *Manager) goAsync(fn func()) {
+	em.addPending(1)
+	go func() {
+		defer em.addPending(-1)
+		fn()
+	}()
+}
+
+// addPending update the pending number, fire the EventBackpressure on the pressure is changed.
+func (em *Manager) addPending(delta int64) {
+	n := atomic.AddInt64(&em.pending, delta)
+	max := int64(em.opts.MaxPending)
+	if max <= 0 {
+		return
+	}
+
+	if n > max && atomic.CompareAndSwapInt32(&em.pressured, 0, 1) {
+		em.firePressure(n, true)
+	} else if n <= max/2 && atomic.CompareAndSwapInt32(&em.pressured, 1, 0) {
+		em.firePressure(n, false)
+	}
+}
+
+func (em *Manager) firePressure(n int64, active bool) {
+	if em.HasListeners(EventBackpressure) {
+		_, _ = em.Fire(EventBackpressure, M{
+			"pending":   n,
+			"threshold": em.opts.MaxPending,
+			"active":    active,
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package event
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// SetProfile attach the fire options to the event name or pattern, every fire of the
+// matched event will inherit them. the options given on FireWith() will override the profile.
+// if the exact name has profile, the pattern profiles are ignored. otherwise the matched
+// patterns are all applied, the longer pattern is applied later.
+//
+// Usage:
+// 	em.SetProfile("order.*", Policy(PolicyCollect), Retry(3, time.Second))
+// 	em.SetProfile("metrics.tick", Async(), Sample(0.1))
+// 	em.Fire("order.created", nil) // will collect errors and retry the failed listeners
+func (em *Manager) SetProfile(name string, opts ...FireOption) {
+	name = em.goodName(name)
+
+	em.mustNotSealed()
+	em.lock()
+	if len(opts) == 0 {
+		delete(em.profiles, name)
+	} else {
+		em.profiles[name] = opts
+	}
+	atomic.StoreInt32(&em.profileNum, int32(len(em.profiles)))
+	em.unlock()
+}
+
+// RemoveProfile remove the profile of the event name or pattern
+func (em *Manager) RemoveProfile(name string) {
+	em.SetProfile(name)
+}
+
+// HasProfile check the event name or pattern has profile
+func (em *Manager) HasProfile(name string) bool {
+	em.rLock()
+	_, ok := em.profiles[em.normalize(name)]
+	em.rUnlock()
+	return ok
+}
+
+// profileFor get the matched profile options of the event name
+func (em *Manager) profileFor(name string) []FireOption {
+	if atomic.LoadInt32(&em.profileNum) == 0 {
+		return nil
+	}
+
+	em.rLock()
+	defer em.rUnlock()
+
+	if opts, ok := em.profiles[name]; ok {
+		return opts
+	}
+
+	var patterns []string
+	for pattern := range em.profiles {
+		if pattern != name && MatchName(pattern, name) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	// the longer pattern is more specific, apply it later to override others.
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) < len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	var opts []FireOption
+	for _, pattern := range patterns {
+		opts = append(opts, em.profiles[pattern]...)
+	}
+	return opts
+}
+
+// fireOptions build the fire options by the profile and the given options.
+// will return nil if both are empty.
+func (em *Manager) fireOptions(name string, opts []FireOption) *fireOptions {
+	profile := em.profileFor(name)
+	if len(profile) == 0 && len(opts) == 0 {
+		return nil
+	}
+
+	fo := &fireOptions{}
+	for _, fn := range profile {
+		fn(fo)
+	}
+	for _, fn := range opts {
+		fn(fo)
+	}
+	return fo
+}
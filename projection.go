@@ -0,0 +1,181 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Journal interface, the EventStore can implement it for read all events by
+// the global position. it's required by the Projection.
+type Journal interface {
+	// ReadAll read events after the position, the limit is max number of events.
+	// the StoredEvent.Position will be set.
+	ReadAll(position uint64, limit int) ([]*StoredEvent, error)
+}
+
+// CheckpointStore interface for storage the projection positions
+type CheckpointStore interface {
+	// LoadCheckpoint get the position of the projection. return 0 if not exists.
+	LoadCheckpoint(name string) (uint64, error)
+	// SaveCheckpoint save the position of the projection
+	SaveCheckpoint(name string, position uint64) error
+}
+
+// MemoryCheckpoints an in-memory CheckpointStore
+type MemoryCheckpoints struct {
+	mu        sync.RWMutex
+	positions map[string]uint64
+}
+
+// NewMemoryCheckpoints create
+func NewMemoryCheckpoints() *MemoryCheckpoints {
+	return &MemoryCheckpoints{positions: make(map[string]uint64)}
+}
+
+// LoadCheckpoint get the position of the projection
+func (c *MemoryCheckpoints) LoadCheckpoint(name string) (uint64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.positions[name], nil
+}
+
+// SaveCheckpoint save the position of the projection
+func (c *MemoryCheckpoints) SaveCheckpoint(name string, position uint64) error {
+	c.mu.Lock()
+	c.positions[name] = position
+	c.mu.Unlock()
+	return nil
+}
+
+// ProjectionProgress the progress info of the projection run
+type ProjectionProgress struct {
+	// Projection name
+	Projection string
+	// Position the last handled event position
+	Position uint64
+	// Processed the number of handled events in the run
+	Processed int
+}
+
+// Projection replay the journaled events through the listeners for build read models.
+// the position is saved after each batch, so the projection can be resumed after
+// error or downtime.
+//
+// Usage:
+// 	p := NewProjection("order-summary", store)
+// 	p.On("order.*", ListenerFunc(updateOrderSummary))
+// 	p.OnProgress(func(pp ProjectionProgress) {
+// 		log.Println(pp.Position, pp.Processed)
+// 	})
+//
+// 	// catch up from the last position
+// 	err := p.Run()
+// 	// rebuild the read model from the beginning
+// 	err := p.Rebuild()
+type Projection struct {
+	name    string
+	journal Journal
+	em      *Manager
+	cps     CheckpointStore
+	// BatchSize the number of events read from the journal at once. default is 100
+	BatchSize int
+	// on progress callback
+	progress func(pp ProjectionProgress)
+}
+
+// NewProjection create a projection, the default checkpoint store is memory.
+func NewProjection(name string, journal Journal) *Projection {
+	return &Projection{
+		name:      name,
+		journal:   journal,
+		em:        NewManager("projection:" + name),
+		cps:       NewMemoryCheckpoints(),
+		BatchSize: 100,
+	}
+}
+
+// Name get the projection name
+func (p *Projection) Name() string {
+	return p.name
+}
+
+// On register a listener for handle the matched events
+func (p *Projection) On(pattern string, listener Listener, priority ...int) *Projection {
+	p.em.On(pattern, listener, priority...)
+	return p
+}
+
+// WithCheckpoints setting the checkpoint store
+func (p *Projection) WithCheckpoints(cps CheckpointStore) *Projection {
+	p.cps = cps
+	return p
+}
+
+// OnProgress setting the progress callback, it's called after each batch.
+func (p *Projection) OnProgress(fn func(pp ProjectionProgress)) *Projection {
+	p.progress = fn
+	return p
+}
+
+// Position get the saved position of the projection
+func (p *Projection) Position() (uint64, error) {
+	return p.cps.LoadCheckpoint(p.name)
+}
+
+// Run handle the events after the saved position, until all events handled.
+// on the listener return error, will save the position of the last handled event and return the error.
+func (p *Projection) Run() error {
+	pos, err := p.cps.LoadCheckpoint(p.name)
+	if err != nil {
+		return err
+	}
+
+	size := p.BatchSize
+	if size <= 0 {
+		size = 100
+	}
+
+	var processed int
+	for {
+		ses, err := p.journal.ReadAll(pos, size)
+		if err != nil {
+			return err
+		}
+
+		var herr error
+		for _, se := range ses {
+			if err := p.em.FireEvent(se.ToEvent()); err != nil {
+				herr = fmt.Errorf("event: projection '%s' handle the event at %d error: %w", p.name, se.Position, err)
+				break
+			}
+
+			pos = se.Position
+			processed++
+		}
+
+		if err := p.cps.SaveCheckpoint(p.name, pos); err != nil {
+			return err
+		}
+
+		if p.progress != nil {
+			p.progress(ProjectionProgress{Projection: p.name, Position: pos, Processed: processed})
+		}
+
+		if herr != nil {
+			return herr
+		}
+
+		if len(ses) < size {
+			return nil
+		}
+	}
+}
+
+// Rebuild reset the position and handle all events from the beginning.
+// NOTICE: should clear the read models before rebuild.
+func (p *Projection) Rebuild() error {
+	if err := p.cps.SaveCheckpoint(p.name, 0); err != nil {
+		return err
+	}
+	return p.Run()
+}
@@ -0,0 +1,226 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueryHandler func for handle the query and return result.
+type QueryHandler func(q Event) (interface{}, error)
+
+// QueryCache the cache config for a query
+type QueryCache struct {
+	// TTL the cache expire time. 0 is never expire
+	TTL time.Duration
+	// Key func for derive the cache key from the query params.
+	// default is the JSON string of the params.
+	Key func(params M) string
+}
+
+// cacheEntry a cached query result
+type cacheEntry struct {
+	val interface{}
+	// expire time, zero is never expire
+	expire time.Time
+}
+
+// the min entries number for sweep the expired entries
+const minSweepSize = 64
+
+// queryCache the cache of a query
+type queryCache struct {
+	cfg     *QueryCache
+	entries map[string]cacheEntry
+	// sweep the expired entries on the entries number reach it
+	sweepAt int
+}
+
+// expired check the entry is expired
+func (ent cacheEntry) expired(now time.Time) bool {
+	return !ent.expire.IsZero() && !now.Before(ent.expire)
+}
+
+// set the entry, and sweep the expired entries if the entries number reach the sweepAt.
+// NOTICE: should call it with lock.
+func (qc *queryCache) set(key string, ent cacheEntry) {
+	qc.entries[key] = ent
+	if qc.cfg.TTL <= 0 || len(qc.entries) < qc.sweepAt {
+		return
+	}
+
+	now := time.Now()
+	for k, e := range qc.entries {
+		if e.expired(now) {
+			delete(qc.entries, k)
+		}
+	}
+
+	qc.sweepAt = 2 * len(qc.entries)
+	if qc.sweepAt < minSweepSize {
+		qc.sweepAt = minSweepSize
+	}
+}
+
+// QueryBus a query bus built on the Manager.
+// like the CommandBus, each query must have exactly one handler, and the
+// query result can be cached by the params.
+//
+// Usage:
+// 	bus := NewQueryBus(em)
+// 	bus.RegisterHandler("user.get", func(q Event) (interface{}, error) {
+// 		return findUser(q.Get("id").(int))
+// 	}, &QueryCache{TTL: time.Minute})
+//
+// 	ret, err := bus.Query("user.get", M{"id": 23})
+// 	// clear the cached results on the user changed
+// 	bus.Invalidate("user.get")
+type QueryBus struct {
+	mu     sync.Mutex
+	bus    *CommandBus
+	caches map[string]*queryCache
+}
+
+// NewQueryBus create a query bus. if em is nil, will create a new Manager.
+func NewQueryBus(em *Manager) *QueryBus {
+	if em == nil {
+		em = NewManager("query-bus")
+	}
+
+	return &QueryBus{
+		bus:    NewCommandBus(em),
+		caches: make(map[string]*queryCache),
+	}
+}
+
+// Manager get the event manager
+func (b *QueryBus) Manager() *Manager {
+	return b.bus.em
+}
+
+// RegisterHandler register the handler for the query, can with a cache config.
+// will panic on the query has handler.
+func (b *QueryBus) RegisterHandler(name string, handler QueryHandler, cache ...*QueryCache) {
+	if handler == nil {
+		panic("event: the query handler cannot be nil")
+	}
+
+	b.bus.RegisterHandler(name, CommandHandler(handler))
+	if len(cache) > 0 && cache[0] != nil {
+		b.mu.Lock()
+		b.caches[b.bus.em.normalize(name)] = &queryCache{
+			cfg:     cache[0],
+			entries: make(map[string]cacheEntry),
+			sweepAt: minSweepSize,
+		}
+		b.mu.Unlock()
+	}
+}
+
+// HasHandler check the query has handler
+func (b *QueryBus) HasHandler(name string) bool {
+	return b.bus.HasHandler(name)
+}
+
+// Query by name, return the handler result or the cached result.
+// will return ErrNoListener if the query has no handler.
+func (b *QueryBus) Query(name string, params M) (interface{}, error) {
+	name, err := b.bus.em.checkName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	qc := b.caches[name]
+	b.mu.Unlock()
+	if qc == nil {
+		return b.bus.Dispatch(name, params)
+	}
+
+	// derive the key from a copy of the params, the dispatch will not change it.
+	params = copyMap(params)
+	key, err := qc.key(params)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	ent, ok := qc.entries[key]
+	if ok && ent.expired(time.Now()) {
+		delete(qc.entries, key)
+		ok = false
+	}
+	b.mu.Unlock()
+	if ok {
+		return ent.val, nil
+	}
+
+	ret, err := b.bus.Dispatch(name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ent = cacheEntry{val: ret}
+	if qc.cfg.TTL > 0 {
+		ent.expire = time.Now().Add(qc.cfg.TTL)
+	}
+
+	b.mu.Lock()
+	qc.set(key, ent)
+	b.mu.Unlock()
+	return ret, nil
+}
+
+// QueryTo query by name, and bind the result to the ptr.
+func (b *QueryBus) QueryTo(name string, params M, ptr interface{}) error {
+	ret, err := b.Query(name, params)
+	if err != nil {
+		return err
+	}
+	return bindResult(ret, ptr)
+}
+
+// Ask the struct query, the query name is derived from the struct type. see StructName()
+// the handler can get the query by Payload(q).
+func (b *QueryBus) Ask(query interface{}) (interface{}, error) {
+	if query == nil {
+		return nil, fmt.Errorf("event: the query cannot be nil")
+	}
+
+	return b.Query(StructName(query), M{PayloadKey: query})
+}
+
+// Invalidate clear the cached results of the queries.
+// if not give names, will clear all cached results.
+func (b *QueryBus) Invalidate(names ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(names) == 0 {
+		for _, qc := range b.caches {
+			qc.entries = make(map[string]cacheEntry)
+		}
+		return
+	}
+
+	for _, name := range names {
+		if qc, ok := b.caches[b.bus.em.normalize(name)]; ok {
+			qc.entries = make(map[string]cacheEntry)
+		}
+	}
+}
+
+// key derive the cache key from the params
+func (qc *queryCache) key(params M) (string, error) {
+	if qc.cfg.Key != nil {
+		return qc.cfg.Key(params), nil
+	}
+
+	// the map keys are sorted by json.Marshal
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("event: cannot derive the query cache key: %v", err)
+	}
+	return string(bs), nil
+}
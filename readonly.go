@@ -0,0 +1,57 @@
+package event
+
+// ReadOnlyEvent wrap the event, the listener cannot change the data or abort it.
+// the Set, Add, SetData and Abort methods are no-op, the Data() returns a copy.
+// it's passed to the observer listeners. see ListenOpts.Observer
+//
+// Usage:
+// 	em.Listen("order.created", analytics, ListenOpts{Observer: true})
+type ReadOnlyEvent struct {
+	e Event
+}
+
+// NewReadOnlyEvent wrap the event as read-only
+func NewReadOnlyEvent(e Event) *ReadOnlyEvent {
+	if ro, ok := e.(*ReadOnlyEvent); ok {
+		return ro
+	}
+	return &ReadOnlyEvent{e: e}
+}
+
+// Name get event name
+func (ro *ReadOnlyEvent) Name() string {
+	return ro.e.Name()
+}
+
+// Get data by key
+func (ro *ReadOnlyEvent) Get(key string) interface{} {
+	return ro.e.Get(key)
+}
+
+// Add is no-op
+func (ro *ReadOnlyEvent) Add(string, interface{}) {}
+
+// Set is no-op
+func (ro *ReadOnlyEvent) Set(string, interface{}) {}
+
+// Data get a copy of the event data
+func (ro *ReadOnlyEvent) Data() map[string]interface{} {
+	data := make(map[string]interface{}, len(ro.e.Data()))
+	for k, v := range ro.e.Data() {
+		data[k] = v
+	}
+	return data
+}
+
+// SetData is no-op
+func (ro *ReadOnlyEvent) SetData(M) Event {
+	return ro
+}
+
+// Abort is no-op
+func (ro *ReadOnlyEvent) Abort(bool) {}
+
+// IsAborted check the wrapped event is aborted
+func (ro *ReadOnlyEvent) IsAborted() bool {
+	return ro.e.IsAborted()
+}
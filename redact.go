@@ -0,0 +1,171 @@
+package event
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactAction how to redact the field value
+type RedactAction uint8
+
+// There are some redact actions
+const (
+	// RedactMask replace the value with the RedactMaskValue
+	RedactMask RedactAction = iota
+	// RedactDrop remove the field
+	RedactDrop
+	// RedactHash replace the value with it's sha256 hash, so the values can be still correlated.
+	RedactHash
+)
+
+// RedactMaskValue the value for replace the masked fields
+const RedactMaskValue = "***"
+
+// RedactRule the redaction rule of a field.
+// the field can be a path of the nested maps, split by the '.' eg: "user.email"
+type RedactRule struct {
+	Field  string
+	Action RedactAction
+}
+
+// Mask create the RedactMask rules for the fields
+func Mask(fields ...string) []RedactRule {
+	return redactRules(RedactMask, fields)
+}
+
+// Drop create the RedactDrop rules for the fields
+func Drop(fields ...string) []RedactRule {
+	return redactRules(RedactDrop, fields)
+}
+
+// Hash create the RedactHash rules for the fields
+func Hash(fields ...string) []RedactRule {
+	return redactRules(RedactHash, fields)
+}
+
+func redactRules(action RedactAction, fields []string) []RedactRule {
+	rules := make([]RedactRule, len(fields))
+	for i, field := range fields {
+		rules[i] = RedactRule{Field: field, Action: action}
+	}
+	return rules
+}
+
+// SetRedaction setting the redaction rules of the event name or pattern. the rules are applied
+// to the event copies for the sinks: the observers by Tail(), the recorders by Record() and
+// the admin APIs. the business listeners still receive the full data.
+// the rules of the exact name and all matched patterns are applied.
+//
+// Usage:
+// 	em.SetRedaction("user.*", Mask("password", "card.number")...)
+// 	em.SetRedaction("payment.captured", append(Drop("cvv"), Hash("email")...)...)
+func (em *Manager) SetRedaction(name string, rules ...RedactRule) {
+	name = em.goodName(name)
+
+	em.mustNotSealed()
+	em.lock()
+	if len(rules) == 0 {
+		delete(em.redactions, name)
+	} else {
+		em.redactions[name] = rules
+	}
+	atomic.StoreInt32(&em.redactNum, int32(len(em.redactions)))
+	em.unlock()
+}
+
+// RemoveRedaction remove the redaction rules of the event name or pattern
+func (em *Manager) RemoveRedaction(name string) {
+	em.SetRedaction(name)
+}
+
+// redactRulesFor get the matched redaction rules of the event name
+func (em *Manager) redactRulesFor(name string) []RedactRule {
+	if atomic.LoadInt32(&em.redactNum) == 0 {
+		return nil
+	}
+
+	name = em.normalize(name)
+	em.rLock()
+	defer em.rUnlock()
+
+	var rules []RedactRule
+	for pattern, rs := range em.redactions {
+		if pattern == name || MatchName(pattern, name) {
+			rules = append(rules, rs...)
+		}
+	}
+	return rules
+}
+
+// RedactData returns the redacted copy of the event data by the rules of the event name.
+// if no rules matched, will return the given data.
+func (em *Manager) RedactData(name string, data M) M {
+	rules := em.redactRulesFor(name)
+	if len(rules) == 0 {
+		return data
+	}
+
+	data = copyMap(data)
+	for _, rule := range rules {
+		redactPath(data, strings.Split(rule.Field, "."), rule.Action)
+	}
+	return data
+}
+
+// Redact returns the redacted copy of the event for the sinks.
+// if no rules matched, will return the given event.
+func (em *Manager) Redact(e Event) Event {
+	if len(em.redactRulesFor(e.Name())) == 0 {
+		return e
+	}
+
+	be := NewBasic(e.Name(), em.RedactData(e.Name(), e.Data()))
+	be.Abort(e.IsAborted())
+	return be
+}
+
+// redactPath redact the value of the path in the data. the nested maps on the path are copied.
+func redactPath(data M, path []string, action RedactAction) {
+	key := path[0]
+	val, ok := data[key]
+	if !ok {
+		return
+	}
+
+	if len(path) > 1 {
+		var sub M
+		switch mp := val.(type) {
+		case M:
+			sub = copyMap(mp)
+		case map[string]interface{}:
+			sub = copyMap(mp)
+		default:
+			return
+		}
+
+		redactPath(sub, path[1:], action)
+		data[key] = sub
+		return
+	}
+
+	switch action {
+	case RedactDrop:
+		delete(data, key)
+	case RedactHash:
+		sum := sha256.Sum256([]byte(fmt.Sprint(val)))
+		data[key] = hex.EncodeToString(sum[:])
+	default:
+		data[key] = RedactMaskValue
+	}
+}
+
+func copyMap(data M) M {
+	cp := make(M, len(data))
+	for k, v := range data {
+		cp[k] = v
+	}
+	return cp
+}
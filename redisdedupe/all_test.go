@@ -0,0 +1,42 @@
+package redisdedupe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type testClient struct {
+	keys map[string]time.Duration
+}
+
+func (c *testClient) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if _, ok := c.keys[key]; ok {
+		return false, nil
+	}
+	c.keys[key] = ttl
+	return true, nil
+}
+
+func (c *testClient) Del(ctx context.Context, key string) error {
+	delete(c.keys, key)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	c := &testClient{keys: make(map[string]time.Duration)}
+	var s event.DedupeStore = New(c, "app:")
+
+	ok, err := s.Claim("k1", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, c.keys["app:k1"])
+	ok, _ = s.Claim("k1", time.Minute)
+	assert.False(t, ok)
+
+	assert.NoError(t, s.Release("k1"))
+	assert.Len(t, c.keys, 0)
+}
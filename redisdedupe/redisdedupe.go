@@ -0,0 +1,69 @@
+// Package redisdedupe an event.DedupeStore backed by Redis, the claimed keys are
+// shared by the processes, so the events can be processed effectively-once.
+//
+// the package has no client dependency, the Client can be wrapped from the go-redis client.
+package redisdedupe
+
+import (
+	"context"
+	"time"
+)
+
+// Client the redis client interface.
+//
+// Usage by go-redis:
+// 	type client struct {
+// 		*redis.Client
+// 	}
+// 	func (c *client) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+// 		return c.Client.SetNX(ctx, key, 1, ttl).Result()
+// 	}
+// 	func (c *client) Del(ctx context.Context, key string) error {
+// 		return c.Client.Del(ctx, key).Err()
+// 	}
+type Client interface {
+	// SetNX set the key with the ttl if the key not exists, returns false if the key exists.
+	SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Del delete the key
+	Del(ctx context.Context, key string) error
+}
+
+// Store the redis DedupeStore
+//
+// Usage:
+// 	em := event.NewManager("app", event.WithIdempotency(event.IdempotencyConfig{
+// 		Store: redisdedupe.New(&client{rdb}, "billing:"),
+// 	}))
+type Store struct {
+	client Client
+	// Prefix for the redis keys. eg: "app:dedupe:"
+	Prefix string
+	// Timeout for the redis commands. default is 3s
+	Timeout time.Duration
+}
+
+// New create a redis dedupe store
+func New(client Client, prefix string) *Store {
+	return &Store{client: client, Prefix: prefix, Timeout: 3 * time.Second}
+}
+
+// Claim the key for the window. implements the event.DedupeStore interface
+func (s *Store) Claim(key string, window time.Duration) (bool, error) {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.SetNX(ctx, s.Prefix+key, window)
+}
+
+// Release the claimed key. implements the event.DedupeStore interface
+func (s *Store) Release(key string) error {
+	ctx, cancel := s.context()
+	defer cancel()
+	return s.client.Del(ctx, s.Prefix+key)
+}
+
+func (s *Store) context() (context.Context, context.CancelFunc) {
+	if s.Timeout > 0 {
+		return context.WithTimeout(context.Background(), s.Timeout)
+	}
+	return context.WithCancel(context.Background())
+}
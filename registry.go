@@ -0,0 +1,82 @@
+package event
+
+import "sync"
+
+// registry storage the named global managers
+var registry = struct {
+	sync.RWMutex
+	managers map[string]*Manager
+}{managers: make(map[string]*Manager)}
+
+// RegisterManager register a named manager to the global registry.
+// will replace the exists manager that has same name.
+//
+// Usage:
+// 	event.RegisterManager(event.NewManager("payments"))
+// 	// in other components
+// 	em := event.MustGetManager("payments")
+func RegisterManager(em *Manager) {
+	if em == nil || em.Name() == "" {
+		panic("event: cannot register empty or unnamed manager")
+	}
+
+	registry.Lock()
+	registry.managers[em.Name()] = em
+	registry.Unlock()
+}
+
+// GetManager get a registered manager by name
+func GetManager(name string) (em *Manager, ok bool) {
+	registry.RLock()
+	em, ok = registry.managers[name]
+	registry.RUnlock()
+	return
+}
+
+// MustGetManager get a registered manager by name, will panic on not found.
+func MustGetManager(name string) *Manager {
+	em, ok := GetManager(name)
+	if !ok {
+		panic("event: the manager '" + name + "' is not registered")
+	}
+	return em
+}
+
+// UnregisterManager remove a manager from the registry. it will not close the manager.
+func UnregisterManager(name string) {
+	registry.Lock()
+	delete(registry.managers, name)
+	registry.Unlock()
+}
+
+// RegisteredManagers get all registered manager names
+func RegisteredManagers() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.managers))
+	for name := range registry.managers {
+		names = append(names, name)
+	}
+	return names
+}
+
+// CloseAll close all registered managers and clear the registry.
+func CloseAll() error {
+	registry.Lock()
+	ms := registry.managers
+	registry.managers = make(map[string]*Manager)
+	registry.Unlock()
+
+	var ers Errors
+	for _, em := range ms {
+		if err := em.Close(); err != nil {
+			ers = append(ers, err)
+		}
+	}
+
+	if len(ers) > 0 {
+		return ers
+	}
+	return nil
+}
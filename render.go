@@ -0,0 +1,173 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Renderer interface for format the event to text. eg: notifier message, log line
+type Renderer interface {
+	Render(e Event) (string, error)
+}
+
+// RenderFunc func definition.
+type RenderFunc func(e Event) (string, error)
+
+// Render the event
+func (fn RenderFunc) Render(e Event) (string, error) {
+	return fn(e)
+}
+
+// TemplateFuncs the funcs for the render templates
+// Usage:
+// 	{{path .Data "user.name"}}
+// 	{{json .Data}}
+var TemplateFuncs = template.FuncMap{
+	"path": func(data interface{}, path string) interface{} {
+		val, _ := GetPath(data, path)
+		return val
+	},
+	"json": func(v interface{}) (string, error) {
+		bs, err := json.Marshal(v)
+		return string(bs), err
+	},
+}
+
+// TemplateRenderer render the event by text/template, the template data is the Event.
+// Usage:
+// 	r := event.MustTemplateRenderer(`{{.Name}}: order {{.Get "id"}} by {{path .Data "user.name"}}`)
+// 	text, err := r.Render(e)
+type TemplateRenderer struct {
+	tpl *template.Template
+}
+
+// NewTemplateRenderer create a template renderer
+func NewTemplateRenderer(text string) (*TemplateRenderer, error) {
+	tpl, err := template.New("event").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateRenderer{tpl: tpl}, nil
+}
+
+// MustTemplateRenderer create a template renderer, will panic on parse error
+func MustTemplateRenderer(text string) *TemplateRenderer {
+	r, err := NewTemplateRenderer(text)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Render the event
+func (r *TemplateRenderer) Render(e Event) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := r.tpl.Execute(buf, e); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// pathVarReg match the path var. eg: "{data.user.name}"
+var pathVarReg = regexp.MustCompile(`{([\w.\-]+)}`)
+
+// PathRenderer render the event by replace the path vars in the format.
+//
+// the var "{name}" is the event name, "{data}" is the JSON of event data,
+// and "{data.user.name}" is the value of the path in event data. the
+// missing values will be rendered as empty string.
+//
+// Usage:
+// 	r := event.NewPathRenderer("{name}: order {data.id} by {data.user.name}")
+type PathRenderer struct {
+	format string
+}
+
+// NewPathRenderer create a path renderer
+func NewPathRenderer(format string) *PathRenderer {
+	return &PathRenderer{format: format}
+}
+
+// Render the event
+func (r *PathRenderer) Render(e Event) (string, error) {
+	var err error
+	text := pathVarReg.ReplaceAllStringFunc(r.format, func(s string) string {
+		path := s[1 : len(s)-1]
+		switch {
+		case path == "name":
+			return e.Name()
+		case path == "data":
+			bs, jErr := json.Marshal(e.Data())
+			if jErr != nil {
+				err = jErr
+			}
+			return string(bs)
+		case strings.HasPrefix(path, "data."):
+			if val, ok := GetPath(e.Data(), path[5:]); ok {
+				return fmt.Sprint(val)
+			}
+		}
+		return ""
+	})
+	return text, err
+}
+
+// GetPath get the value by the path from the nested maps, slices and structs.
+// Usage:
+// 	GetPath(data, "user.name")
+// 	GetPath(data, "items.0.sku")
+func GetPath(data interface{}, path string) (interface{}, bool) {
+	val := data
+	for _, key := range strings.Split(path, ".") {
+		switch typ := val.(type) {
+		case M:
+			val = map[string]interface{}(typ)
+		}
+
+		if mp, ok := val.(map[string]interface{}); ok {
+			if val, ok = mp[key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+
+		rv := reflect.ValueOf(val)
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			rv = rv.Elem()
+		}
+
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+
+			mv := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+			if !mv.IsValid() {
+				return nil, false
+			}
+			val = mv.Interface()
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= rv.Len() {
+				return nil, false
+			}
+			val = rv.Index(i).Interface()
+		case reflect.Struct:
+			fv := rv.FieldByName(key)
+			if !fv.IsValid() || !fv.CanInterface() {
+				return nil, false
+			}
+			val = fv.Interface()
+		default:
+			return nil, false
+		}
+	}
+	return val, true
+}
@@ -0,0 +1,176 @@
+package event
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RecordedEvent the recorded fire of the event
+type RecordedEvent struct {
+	Name string    `json:"name"`
+	Data M         `json:"data"`
+	Time time.Time `json:"time"`
+}
+
+// Recorder record the fire sequence of the manager. see Manager.Record()
+type Recorder struct {
+	mu      sync.Mutex
+	em      *Manager
+	pattern string
+	events  []*RecordedEvent
+}
+
+// recorders storage the active recorders
+type recorders struct {
+	sync.Mutex
+	list []*Recorder
+	// the number of the recorders, for fast check
+	num int32
+}
+
+// Record start record the fired events matched the pattern. the events are
+// recorded before call listeners, so the data is not changed by listeners.
+// the data is redacted by the rules of SetRedaction().
+//
+// Usage:
+// 	rec := em.Record("*")
+// 	// ... run the app
+// 	rec.Stop()
+// 	err := rec.WriteJSONLines(file)
+//
+// 	// replay later
+// 	events, err := event.LoadRecording(file)
+// 	err = event.Replay(em, events, event.ReplayOptions{Speed: 1})
+func (em *Manager) Record(pattern string) *Recorder {
+	rec := &Recorder{em: em, pattern: em.normalize(pattern)}
+
+	em.recorders.Lock()
+	em.recorders.list = append(em.recorders.list, rec)
+	atomic.StoreInt32(&em.recorders.num, int32(len(em.recorders.list)))
+	em.recorders.Unlock()
+	return rec
+}
+
+// record the event to the matched recorders
+func (em *Manager) record(e Event) {
+	if atomic.LoadInt32(&em.recorders.num) == 0 {
+		return
+	}
+
+	em.recorders.Lock()
+	defer em.recorders.Unlock()
+
+	now := time.Now()
+	for _, rec := range em.recorders.list {
+		if !MatchName(rec.pattern, e.Name()) {
+			continue
+		}
+
+		data := em.RedactData(e.Name(), copyMap(e.Data()))
+
+		rec.mu.Lock()
+		rec.events = append(rec.events, &RecordedEvent{Name: e.Name(), Data: data, Time: now})
+		rec.mu.Unlock()
+	}
+}
+
+// Stop the recording
+func (r *Recorder) Stop() {
+	em := r.em
+	em.recorders.Lock()
+	for i, rec := range em.recorders.list {
+		if rec == r {
+			em.recorders.list = append(em.recorders.list[:i:i], em.recorders.list[i+1:]...)
+			break
+		}
+	}
+	atomic.StoreInt32(&em.recorders.num, int32(len(em.recorders.list)))
+	em.recorders.Unlock()
+}
+
+// Events get the recorded events
+func (r *Recorder) Events() []*RecordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*RecordedEvent(nil), r.events...)
+}
+
+// WriteJSONLines write the recorded events as JSON lines
+func (r *Recorder) WriteJSONLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, re := range r.Events() {
+		if err := enc.Encode(re); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadRecording load the recorded events from the JSON lines
+func LoadRecording(r io.Reader) ([]*RecordedEvent, error) {
+	var events []*RecordedEvent
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		re := &RecordedEvent{}
+		if err := json.Unmarshal(sc.Bytes(), re); err != nil {
+			return nil, err
+		}
+		events = append(events, re)
+	}
+	return events, sc.Err()
+}
+
+// ReplayOptions the options for replay
+type ReplayOptions struct {
+	// Speed the replay speed. 1 is real-time, 2 is double speed, 0 is as fast as possible.
+	Speed float64
+	// Step called before fire each event, return false to stop replay.
+	// it can be used for stepwise replay. eg: wait the user input
+	Step func(i int, re *RecordedEvent) bool
+	// StopOnError stop replay on the fire returns error
+	StopOnError bool
+}
+
+// Replay fire the recorded events in order to the manager. the fire errors
+// will be returned as Errors, the first error is returned if StopOnError.
+func Replay(em *Manager, events []*RecordedEvent, opts ReplayOptions) error {
+	var ers Errors
+	for i, re := range events {
+		if opts.Speed > 0 && i > 0 {
+			if wait := re.Time.Sub(events[i-1].Time); wait > 0 {
+				time.Sleep(time.Duration(float64(wait) / opts.Speed))
+			}
+		}
+
+		if opts.Step != nil && !opts.Step(i, re) {
+			break
+		}
+
+		data := make(M, len(re.Data))
+		for k, v := range re.Data {
+			data[k] = v
+		}
+
+		if err, _ := em.TryFire(re.Name, data); err != nil {
+			if opts.StopOnError {
+				return err
+			}
+			ers = append(ers, err)
+		}
+	}
+
+	if len(ers) > 0 {
+		return ers
+	}
+	return nil
+}
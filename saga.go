@@ -0,0 +1,137 @@
+package event
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SagaStep a saga step definition.
+type SagaStep struct {
+	// Event the event name of the step action
+	Event string
+	// Compensate the compensating event name. fired when a later step failed.
+	// allow empty, will skip compensate the step.
+	Compensate string
+}
+
+// Saga a simple workflow coordinator built on events.
+//
+// Steps are fired in order through the Manager, when a step returns error
+// or is aborted, the compensating events of all completed steps will be
+// fired in reverse order.
+//
+// Usage:
+// 	saga := NewSaga("order", em)
+// 	saga.Step("order.reserve", "order.release").
+// 		Step("order.charge", "order.refund").
+// 		Step("order.ship", "")
+// 	err := saga.Run(M{"id": 23})
+type Saga struct {
+	name  string
+	em    *Manager
+	steps []*SagaStep
+}
+
+// SagaError the error returned by Saga.Run
+type SagaError struct {
+	// Saga name
+	Saga string
+	// Step the failed step event name
+	Step string
+	// Err the step error
+	Err error
+	// Compensations errors on fire compensating events. key is event name.
+	Compensations map[string]error
+	// the compensating event names of the Compensations, in the fired order
+	compensated []string
+}
+
+// Error string
+func (e *SagaError) Error() string {
+	msg := fmt.Sprintf("event: saga '%s' failed on step '%s': %v", e.Saga, e.Step, e.Err)
+	if len(e.Compensations) == 0 {
+		return msg
+	}
+
+	names := e.compensated
+	if len(names) != len(e.Compensations) {
+		names = make([]string, 0, len(e.Compensations))
+		for name := range e.Compensations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	ss := make([]string, 0, len(names))
+	for _, name := range names {
+		ss = append(ss, name+": "+e.Compensations[name].Error())
+	}
+	return msg + "; compensate errors: " + strings.Join(ss, ", ")
+}
+
+// NewSaga create new saga instance. if em is nil, will use the DefaultEM
+func NewSaga(name string, em *Manager) *Saga {
+	if em == nil {
+		em = DefaultEM
+	}
+
+	return &Saga{name: name, em: em}
+}
+
+// Name get saga name
+func (s *Saga) Name() string {
+	return s.name
+}
+
+// Step add a step with action event and compensating event
+func (s *Saga) Step(event, compensate string) *Saga {
+	s.steps = append(s.steps, &SagaStep{Event: event, Compensate: compensate})
+	return s
+}
+
+// Steps get all steps
+func (s *Saga) Steps() []*SagaStep {
+	return s.steps
+}
+
+// Run all steps by the params. params will be shared by all step events,
+// so listeners can pass data to later steps.
+func (s *Saga) Run(params M) error {
+	if params == nil {
+		params = make(M)
+	}
+
+	for i, step := range s.steps {
+		err, e := s.em.Fire(step.Event, params)
+		if err == nil && e != nil && e.IsAborted() {
+			err = ErrAborted
+		}
+
+		if err != nil {
+			return s.compensate(i, step, err, params)
+		}
+	}
+	return nil
+}
+
+// compensate fire compensating events for steps before the failed index.
+func (s *Saga) compensate(failed int, step *SagaStep, err error, params M) error {
+	se := &SagaError{Saga: s.name, Step: step.Event, Err: err}
+
+	for i := failed - 1; i >= 0; i-- {
+		name := s.steps[i].Compensate
+		if name == "" {
+			continue
+		}
+
+		if cErr, _ := s.em.Fire(name, params); cErr != nil {
+			if se.Compensations == nil {
+				se.Compensations = make(map[string]error)
+			}
+			se.Compensations[name] = cErr
+			se.compensated = append(se.compensated, name)
+		}
+	}
+	return se
+}
@@ -0,0 +1,384 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Schema interface for validate the event data
+type Schema interface {
+	// Validate the event data
+	Validate(data M) error
+	// Definition get the JSON Schema definition
+	Definition() M
+}
+
+// SchemaError the event data is not matched the schema
+type SchemaError struct {
+	Event  string
+	Field  string
+	Reason string
+}
+
+// Error string
+func (e *SchemaError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("event: the data of '%s' is invalid: %s", e.Event, e.Reason)
+	}
+	return fmt.Sprintf("event: the data field '%s' of '%s' is invalid: %s", e.Field, e.Event, e.Reason)
+}
+
+// SchemaRegistry storage the schemas by the event names.
+//
+// Usage:
+// 	reg := event.NewSchemaRegistry()
+// 	reg.Register("order.created", event.MustStructSchema(OrderCreated{}))
+// 	reg.Register("user.created", event.MustJSONSchema(`{"required": ["id"]}`))
+//
+// 	// validate on fire, the invalid events will be vetoed
+// 	em.AddInterceptor(reg.Interceptor())
+// 	// validate on consume, the invalid events will not be handled by the listener
+// 	em.On("order.created", reg.Consumer(listener))
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]Schema
+}
+
+// NewSchemaRegistry create a schema registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string]Schema)}
+}
+
+// Register the schema for the event name
+func (r *SchemaRegistry) Register(name string, schema Schema) *SchemaRegistry {
+	if name == "" || schema == nil {
+		panic("event: the schema name and schema cannot be empty")
+	}
+
+	r.mu.Lock()
+	r.schemas[name] = schema
+	r.mu.Unlock()
+	return r
+}
+
+// Get the schema by event name
+func (r *SchemaRegistry) Get(name string) (schema Schema, ok bool) {
+	r.mu.RLock()
+	schema, ok = r.schemas[name]
+	r.mu.RUnlock()
+	return
+}
+
+// Names get the registered event names, is sorted.
+func (r *SchemaRegistry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.schemas))
+	for name := range r.schemas {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Validate the event data by the registered schema.
+// the events without schema are always valid.
+func (r *SchemaRegistry) Validate(e Event) error {
+	schema, ok := r.Get(e.Name())
+	if !ok {
+		return nil
+	}
+
+	if err := schema.Validate(e.Data()); err != nil {
+		if se, ok := err.(*SchemaError); ok {
+			se.Event = e.Name()
+		}
+		return err
+	}
+	return nil
+}
+
+// Interceptor create an interceptor for validate the events on fire
+func (r *SchemaRegistry) Interceptor() Interceptor {
+	return r.Validate
+}
+
+// Consumer wrap the listener, validate the events before handle
+func (r *SchemaRegistry) Consumer(listener Listener) Listener {
+	return ListenerFunc(func(e Event) error {
+		if err := r.Validate(e); err != nil {
+			return err
+		}
+		return listener.Handle(e)
+	})
+}
+
+// Export the JSON Schema definitions by the event names
+func (r *SchemaRegistry) Export() map[string]M {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make(map[string]M, len(r.schemas))
+	for name, schema := range r.schemas {
+		defs[name] = schema.Definition()
+	}
+	return defs
+}
+
+// ExportJSON export the JSON Schema definitions as JSON
+func (r *SchemaRegistry) ExportJSON() ([]byte, error) {
+	return json.MarshalIndent(r.Export(), "", "  ")
+}
+
+// JSONSchema the schema by the JSON Schema definition.
+//
+// NOTICE: only the keywords "type", "required", "properties", "items" and "enum" are supported.
+type JSONSchema struct {
+	def M
+}
+
+// NewJSONSchema create schema from the JSON Schema definition
+func NewJSONSchema(def string) (*JSONSchema, error) {
+	s := &JSONSchema{}
+	if err := json.Unmarshal([]byte(def), &s.def); err != nil {
+		return nil, fmt.Errorf("event: invalid JSON Schema: %v", err)
+	}
+	return s, nil
+}
+
+// MustJSONSchema create schema from the JSON Schema definition, will panic on error
+func MustJSONSchema(def string) *JSONSchema {
+	s, err := NewJSONSchema(def)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Definition get the JSON Schema definition
+func (s *JSONSchema) Definition() M {
+	return s.def
+}
+
+// Validate the event data
+func (s *JSONSchema) Validate(data M) error {
+	return validateJSONSchema(s.def, "", map[string]interface{}(data))
+}
+
+func validateJSONSchema(def map[string]interface{}, field string, val interface{}) error {
+	if typ, ok := def["type"].(string); ok && !matchJSONType(typ, val) {
+		return &SchemaError{Field: field, Reason: "must be " + typ}
+	}
+
+	if enum, ok := def["enum"].([]interface{}); ok {
+		found := false
+		for _, item := range enum {
+			if compareValues("==", val, item) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return &SchemaError{Field: field, Reason: fmt.Sprintf("must be one of %v", enum)}
+		}
+	}
+
+	switch typ := val.(type) {
+	case M:
+		return validateJSONSchema(def, field, map[string]interface{}(typ))
+	case map[string]interface{}:
+		required, _ := def["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := typ[fmt.Sprint(key)]; !ok {
+				return &SchemaError{Field: joinField(field, fmt.Sprint(key)), Reason: "is required"}
+			}
+		}
+
+		props, _ := toMap(def["properties"])
+		for key, pdef := range props {
+			pv, ok := typ[key]
+			pm, isMap := toMap(pdef)
+			if ok && isMap {
+				if err := validateJSONSchema(pm, joinField(field, key), pv); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := toMap(def["items"]); ok {
+			for i, item := range typ {
+				if err := validateJSONSchema(items, joinField(field, fmt.Sprint(i)), item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// toMap convert the M or map value to map[string]interface{}
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	switch typ := v.(type) {
+	case M:
+		return typ, true
+	case map[string]interface{}:
+		return typ, true
+	}
+	return nil, false
+}
+
+func joinField(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// matchJSONType check the value is matched the JSON type
+func matchJSONType(typ string, val interface{}) bool {
+	rv := reflect.ValueOf(val)
+	switch typ {
+	case "null":
+		return val == nil
+	case "string":
+		return rv.Kind() == reflect.String
+	case "boolean":
+		return rv.Kind() == reflect.Bool
+	case "number":
+		_, ok := toFloat(val)
+		return ok
+	case "integer":
+		f, ok := toFloat(val)
+		return ok && f == float64(int64(f))
+	case "object":
+		return rv.Kind() == reflect.Map || rv.Kind() == reflect.Struct ||
+			rv.Kind() == reflect.Ptr && rv.Elem().Kind() == reflect.Struct
+	case "array":
+		return rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array
+	}
+	return true
+}
+
+// StructSchema the schema by the Go struct type.
+//
+// the data keys are the json tag names of the fields, the fields without
+// "omitempty" are required. if the data has the struct payload (see FireStruct),
+// the payload type will be checked instead.
+type StructSchema struct {
+	typ reflect.Type
+	def M
+}
+
+// NewStructSchema create schema from the struct. eg: NewStructSchema(OrderCreated{})
+func NewStructSchema(v interface{}) (*StructSchema, error) {
+	rt := reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("event: the schema value must be a struct, but got %T", v)
+	}
+	return &StructSchema{typ: rt, def: typeDefinition(rt)}, nil
+}
+
+// MustStructSchema create schema from the struct, will panic on error
+func MustStructSchema(v interface{}) *StructSchema {
+	s, err := NewStructSchema(v)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Type get the struct type
+func (s *StructSchema) Type() reflect.Type {
+	return s.typ
+}
+
+// Definition get the JSON Schema definition of the struct
+func (s *StructSchema) Definition() M {
+	return s.def
+}
+
+// Validate the event data
+func (s *StructSchema) Validate(data M) error {
+	if payload, ok := data[PayloadKey]; ok && payload != nil {
+		rt := reflect.TypeOf(payload)
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+
+		if rt == s.typ {
+			return nil
+		}
+	}
+	return validateJSONSchema(s.def, "", map[string]interface{}(data))
+}
+
+// typeDefinition build the JSON Schema definition of the type
+func typeDefinition(rt reflect.Type) M {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	switch rt.Kind() {
+	case reflect.String:
+		return M{"type": "string"}
+	case reflect.Bool:
+		return M{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return M{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return M{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return M{"type": "array", "items": typeDefinition(rt.Elem())}
+	case reflect.Map:
+		return M{"type": "object"}
+	case reflect.Struct:
+	default:
+		return M{}
+	}
+
+	props := M{}
+	var required []interface{}
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		name, opts := sf.Name, ""
+		if tag := sf.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+
+			nodes := strings.SplitN(tag, ",", 2)
+			if nodes[0] != "" {
+				name = nodes[0]
+			}
+			if len(nodes) > 1 {
+				opts = nodes[1]
+			}
+		}
+
+		props[name] = typeDefinition(sf.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	def := M{"type": "object", "properties": props}
+	if len(required) > 0 {
+		def["required"] = required
+	}
+	return def
+}
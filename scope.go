@@ -0,0 +1,142 @@
+package event
+
+import (
+	"context"
+	"sync"
+)
+
+// scopedItem the listener item registered by the Scope
+type scopedItem struct {
+	name string
+	li   *ListenerItem
+}
+
+// Scope registers listeners to the manager, all of them can be removed by once Close().
+// it's useful for the request or session scoped listeners.
+//
+// Usage:
+// 	scope := em.NewScope()
+// 	defer scope.Close()
+// 	scope.On("order.updated", listener)
+//
+// 	// will be closed on the ctx is done
+// 	scope := em.WithScope(ctx)
+type Scope struct {
+	em    *Manager
+	mu    sync.Mutex
+	items []scopedItem
+	done  chan struct{}
+}
+
+// NewScope create a listener scope of the manager
+func (em *Manager) NewScope() *Scope {
+	return &Scope{em: em, done: make(chan struct{})}
+}
+
+// WithScope create a listener scope, it will be closed on the ctx is done.
+func (em *Manager) WithScope(ctx context.Context) *Scope {
+	s := em.NewScope()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+// OnWithContext register a event listener, it will be removed on the ctx is done.
+// Usage:
+// 	em.OnWithContext(r.Context(), "order.updated", listener)
+// 	em.OnWithContext(ctx, "order.updated", listener, High)
+func (em *Manager) OnWithContext(ctx context.Context, name string, listener Listener, priority ...int) {
+	s := em.WithScope(ctx)
+	if err := s.TryOn(name, listener, priority...); err != nil {
+		s.Close()
+		em.fail(err)
+	}
+}
+
+// On register a listener in the scope. can setting priority.
+func (s *Scope) On(name string, listener Listener, priority ...int) {
+	if err := s.TryOn(name, listener, priority...); err != nil {
+		s.em.fail(err)
+	}
+}
+
+// TryOn register a listener in the scope, will return error instead of panic.
+func (s *Scope) TryOn(name string, listener Listener, priority ...int) error {
+	pv := Normal
+	if len(priority) > 0 {
+		pv = priority[0]
+	}
+	return s.add(name, &ListenerItem{Priority: pv, Listener: listener})
+}
+
+// Listen register a listener with options in the scope.
+func (s *Scope) Listen(name string, listener Listener, opts ListenOpts) {
+	err := s.add(name, newListenerItem(listener, opts))
+	if err != nil {
+		s.em.fail(err)
+	}
+}
+
+func (s *Scope) add(name string, li *ListenerItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed() {
+		return ErrClosed
+	}
+
+	name, err := s.em.addItem(name, li)
+	if err == nil {
+		s.items = append(s.items, scopedItem{name: name, li: li})
+	}
+	return err
+}
+
+// Len get the registered listeners count of the scope
+func (s *Scope) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// Done returns a channel that's closed when the scope is closed
+func (s *Scope) Done() <-chan struct{} {
+	return s.done
+}
+
+// IsClosed check the scope is closed
+func (s *Scope) IsClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed()
+}
+
+func (s *Scope) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close remove all listeners registered by the scope. it's safe to call multi times.
+func (s *Scope) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed() {
+		return
+	}
+
+	for _, item := range s.items {
+		s.em.removeItems(item.name, []*ListenerItem{item.li})
+	}
+	s.items = nil
+	close(s.done)
+}
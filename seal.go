@@ -0,0 +1,26 @@
+package event
+
+import "sync/atomic"
+
+// Seal the manager. after sealed, the wiring of the manager cannot be changed.
+//
+// - On, Listen, AddEvent and other register methods will panic with ErrSealed
+// - TryOn, TryAddEvent and other methods has error result will return ErrSealed
+// - RemoveListener, RemoveListeners, Clear and other mutate methods will panic with ErrSealed
+//
+// NOTICE: the once listeners still will be removed after called. Close() is allowed.
+func (em *Manager) Seal() {
+	atomic.StoreInt32(&em.sealed, 1)
+}
+
+// IsSealed check the manager is sealed
+func (em *Manager) IsSealed() bool {
+	return atomic.LoadInt32(&em.sealed) == 1
+}
+
+// mustNotSealed panic with ErrSealed if the manager is sealed
+func (em *Manager) mustNotSealed() {
+	if em.IsSealed() {
+		panic(ErrSealed)
+	}
+}
@@ -0,0 +1,145 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Closer interface. the listener can implement it for release the resources on Shutdown().
+type Closer interface {
+	Close() error
+}
+
+// Drainer interface. the listener can implement it for flush the buffered works on Shutdown().
+// the Drain is called before the Close, if the listener implements both.
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// WithCloseTimeout setting the max wait time of each listener Drain and Close on Shutdown()
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.CloseTimeout = timeout
+	}
+}
+
+// Shutdown gracefully close the manager:
+//
+// 	1. stop accept new fires, the Fire methods will return ErrClosed
+// 	2. wait the pending async dispatches and listeners done
+// 	3. drain and close the listeners implemented the Drainer or Closer, by the reverse priority
+// 	   order. so the low priority listeners, which maybe depend on the high priority, are closed first.
+// 	4. close the manager, see Close()
+//
+// the ctx limit the whole shutdown, and the Options.CloseTimeout limit each listener.
+// the errors of the listeners are returned as Errors.
+//
+// Usage:
+// 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// 	defer cancel()
+// 	err := em.Shutdown(ctx)
+func (em *Manager) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&em.closed, 0, 1) {
+		return nil
+	}
+	_ = em.transition(MetaManagerStopped, StateStopped, StateCreated, StateRunning, StatePaused)
+
+	var errs Errors
+	if err := em.waitPending(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, li := range em.closingItems() {
+		if err := em.closeListener(ctx, li); err != nil {
+			errs = append(errs, fmt.Errorf("event: close the listener '%s' error: %w", li.Name(), err))
+		}
+	}
+
+	em.close()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// waitPending wait the pending async works done, or the ctx is done
+func (em *Manager) waitPending(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+
+	for em.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("event: wait %d pending works error: %w", em.Pending(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// closingItems collect the listener items implemented the Drainer or Closer, in the close order.
+// the listener registered on multi names will be closed once.
+func (em *Manager) closingItems() []*ListenerItem {
+	em.rLock()
+	var items []*ListenerItem
+	for _, lq := range em.listeners {
+		for _, li := range lq.Items() {
+			switch li.Listener.(type) {
+			case Closer, Drainer:
+				items = append(items, li)
+			}
+		}
+	}
+	em.rUnlock()
+
+	// the reverse of the call order: low priority first, the later registered first.
+	sort.Slice(items, func(i, j int) bool {
+		return lessItem(items[j], items[i])
+	})
+
+	seen := make(map[interface{}]bool, len(items))
+	list := items[:0]
+	for _, li := range items {
+		if reflect.TypeOf(li.Listener).Comparable() {
+			if seen[li.Listener] {
+				continue
+			}
+			seen[li.Listener] = true
+		}
+		list = append(list, li)
+	}
+	return list
+}
+
+// closeListener drain and close the listener, will wait it until the CloseTimeout or the ctx is done.
+func (em *Manager) closeListener(ctx context.Context, li *ListenerItem) error {
+	if em.opts.CloseTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, em.opts.CloseTimeout)
+		defer cancel()
+	}
+
+	ch := make(chan error, 1)
+	go func() {
+		var err error
+		if d, ok := li.Listener.(Drainer); ok {
+			err = d.Drain(ctx)
+		}
+		if c, ok := li.Listener.(Closer); ok && err == nil {
+			err = c.Close()
+		}
+		ch <- err
+	}()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
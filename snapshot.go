@@ -0,0 +1,126 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Snapshot the state snapshot of a stream
+type Snapshot struct {
+	// Stream name
+	Stream string `json:"stream"`
+	// Version the last event version included in the snapshot
+	Version uint64 `json:"version"`
+	// State the encoded state. it's JSON by the TakeSnapshot()
+	State []byte `json:"state"`
+	// Time the snapshot created time
+	Time time.Time `json:"time"`
+}
+
+// SnapshotStore interface, the EventStore can implement it for support snapshots.
+type SnapshotStore interface {
+	// SaveSnapshot save the snapshot, will replace the old snapshot of the stream.
+	SaveSnapshot(snap *Snapshot) error
+	// LoadSnapshot load the latest snapshot of the stream. return nil if not exists.
+	LoadSnapshot(stream string) (*Snapshot, error)
+}
+
+// Aggregate interface for the event-sourced state.
+// the state will be encoded to snapshot by JSON, so the state fields should be exported.
+type Aggregate interface {
+	// Apply the stored event to the state
+	Apply(se *StoredEvent) error
+}
+
+// LoadAggregate restore the aggregate state by the latest snapshot and the subsequent events.
+// if snapshotEvery > 0 and the replayed events >= snapshotEvery, will take a new snapshot.
+// return the current version of the stream.
+//
+// Usage:
+// 	order := &OrderAggregate{}
+// 	version, err := LoadAggregate(store, "order-23", order, 100)
+func LoadAggregate(store EventStore, stream string, agg Aggregate, snapshotEvery uint64) (version uint64, err error) {
+	if ss, ok := store.(SnapshotStore); ok {
+		snap, err := ss.LoadSnapshot(stream)
+		if err != nil {
+			return 0, err
+		}
+
+		if snap != nil {
+			if err = json.Unmarshal(snap.State, agg); err != nil {
+				return 0, fmt.Errorf("event: decode snapshot of the stream '%s' error: %v", stream, err)
+			}
+			version = snap.Version
+		}
+	}
+
+	ses, err := store.LoadFrom(stream, version+1)
+	if err != nil {
+		return version, err
+	}
+
+	for _, se := range ses {
+		if err = agg.Apply(se); err != nil {
+			return version, err
+		}
+		version = se.Version
+	}
+
+	if snapshotEvery > 0 && uint64(len(ses)) >= snapshotEvery {
+		err = TakeSnapshot(store, stream, version, agg)
+	}
+	return
+}
+
+// TakeSnapshot save the state as snapshot of the stream. the store must implements SnapshotStore.
+func TakeSnapshot(store EventStore, stream string, version uint64, state interface{}) error {
+	ss, ok := store.(SnapshotStore)
+	if !ok {
+		return fmt.Errorf("event: the event store is not support snapshot")
+	}
+
+	bs, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("event: encode snapshot of the stream '%s' error: %v", stream, err)
+	}
+
+	return ss.SaveSnapshot(&Snapshot{Stream: stream, Version: version, State: bs, Time: time.Now()})
+}
+
+/*************************************************************
+ * snapshots for the MemoryStore
+ *************************************************************/
+
+// memorySnapshots storage the snapshots in memory
+type memorySnapshots struct {
+	mu    sync.RWMutex
+	snaps map[string]*Snapshot
+}
+
+// SaveSnapshot save the snapshot
+func (s *MemoryStore) SaveSnapshot(snap *Snapshot) error {
+	s.snapshots.mu.Lock()
+	defer s.snapshots.mu.Unlock()
+
+	if s.snapshots.snaps == nil {
+		s.snapshots.snaps = make(map[string]*Snapshot)
+	}
+
+	cp := *snap
+	s.snapshots.snaps[snap.Stream] = &cp
+	return nil
+}
+
+// LoadSnapshot load the latest snapshot of the stream
+func (s *MemoryStore) LoadSnapshot(stream string) (*Snapshot, error) {
+	s.snapshots.mu.RLock()
+	defer s.snapshots.mu.RUnlock()
+
+	if snap, ok := s.snapshots.snaps[stream]; ok {
+		cp := *snap
+		return &cp, nil
+	}
+	return nil, nil
+}
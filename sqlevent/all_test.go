@@ -0,0 +1,131 @@
+package sqlevent
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+// direct exec the query with args, the others will be prepared
+func (c *fakeConn) ExecContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) == 0 {
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if s.query == "bad" {
+		return nil, errors.New("bad query")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (r *fakeRows) Columns() []string         { return []string{"id"} }
+func (r *fakeRows) Close() error              { return nil }
+func (r *fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (t *fakeTx) Commit() error   { return nil }
+func (t *fakeTx) Rollback() error { return errors.New("rollback failed") }
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t,
+		"SELECT * FROM users WHERE name = ? AND id = ? AND t2.x = ?",
+		Redact("SELECT * FROM users WHERE name = 'it''s' AND id = 12 AND t2.x = 0xFF"),
+	)
+}
+
+func TestRegister(t *testing.T) {
+	em := event.NewManager("test")
+	var events []event.Event
+	em.On("db.*", event.ListenerFunc(func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	}))
+	em.On("db.tx.*", event.ListenerFunc(func(e event.Event) error {
+		events = append(events, e)
+		return nil
+	}))
+
+	Register("fake-event", fakeDriver{}, em)
+	db, err := sql.Open("fake-event", "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("UPDATE users SET name = 'tom' WHERE id = ?", 1)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, EventQuery, events[0].Name())
+	assert.Equal(t, "exec", events[0].Get("op"))
+	assert.Equal(t, "UPDATE users SET name = ? WHERE id = ?", events[0].Get("query"))
+	assert.Equal(t, 1, events[0].Get("args"))
+
+	// fallback to prepare
+	events = nil
+	_, err = db.Exec("DELETE FROM users")
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	events = nil
+	_, err = db.Exec("bad")
+	assert.Error(t, err)
+	assert.Equal(t, "bad query", events[0].Get("error").(error).Error())
+
+	events = nil
+	rows, err := db.Query("SELECT id FROM users")
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	assert.Equal(t, "query", events[0].Get("op"))
+
+	events = nil
+	tx, err := db.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, EventCommit, events[0].Name())
+	assert.Nil(t, events[0].Get("error"))
+
+	events = nil
+	tx, _ = db.Begin()
+	assert.Error(t, tx.Rollback())
+	assert.Equal(t, EventRollback, events[0].Name())
+	assert.Error(t, events[0].Get("error").(error))
+}
@@ -0,0 +1,309 @@
+// Package sqlevent provide the database/sql driver wrapper for fire the query events.
+//
+// Events:
+// 	db.query        data: op("exec", "query"), query(redacted), args, duration, error
+// 	db.tx.commit    data: duration, error
+// 	db.tx.rollback  data: duration, error
+//
+// Usage:
+// 	sqlevent.Register("mysql-event", &mysql.MySQLDriver{}, em)
+// 	db, err := sql.Open("mysql-event", dsn)
+//
+// 	// slow query alerting
+// 	em.On(sqlevent.EventQuery, event.ListenerFunc(func(e event.Event) error {
+// 		if e.Get("duration").(time.Duration) > time.Second {
+// 			alert(e.Get("query"))
+// 		}
+// 		return nil
+// 	}))
+package sqlevent
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// There are the default event names
+const (
+	EventQuery    = "db.query"
+	EventCommit   = "db.tx.commit"
+	EventRollback = "db.tx.rollback"
+)
+
+// the literal values in the query. string, hex and number
+var literalReg = regexp.MustCompile(`'(?:[^']|'')*'|\b0x[0-9a-fA-F]+\b|\b\d+(?:\.\d+)?\b`)
+
+// Redact replace the string and number literals in the query with '?'
+func Redact(query string) string {
+	return literalReg.ReplaceAllString(query, "?")
+}
+
+// Hooks fire the events to the manager
+type Hooks struct {
+	em *event.Manager
+	// QueryEvent the event name of the query and exec. set empty to disable it.
+	QueryEvent string
+	// CommitEvent the event name of the tx commit. set empty to disable it.
+	CommitEvent string
+	// RollbackEvent the event name of the tx rollback. set empty to disable it.
+	RollbackEvent string
+	// Redact the query before fire. default is Redact, set nil to keep the raw query.
+	Redact func(query string) string
+}
+
+// NewHooks create the hooks with the default event names
+func NewHooks(em *event.Manager) *Hooks {
+	return &Hooks{
+		em:            em,
+		QueryEvent:    EventQuery,
+		CommitEvent:   EventCommit,
+		RollbackEvent: EventRollback,
+		Redact:        Redact,
+	}
+}
+
+func (h *Hooks) fire(name string, data event.M) {
+	if name != "" {
+		_, _ = h.em.Fire(name, data)
+	}
+}
+
+func (h *Hooks) onQuery(op, query string, args int, start time.Time, err error) {
+	if h.Redact != nil {
+		query = h.Redact(query)
+	}
+
+	h.fire(h.QueryEvent, event.M{
+		"op":       op,
+		"query":    query,
+		"args":     args,
+		"duration": time.Since(start),
+		"error":    err,
+	})
+}
+
+// Driver wrap the driver.Driver, fire the query events by the Hooks
+type Driver struct {
+	driver.Driver
+	Hooks *Hooks
+}
+
+// Wrap the driver
+func Wrap(d driver.Driver, em *event.Manager) *Driver {
+	return &Driver{Driver: d, Hooks: NewHooks(em)}
+}
+
+// Register the wrapped driver to database/sql by the name
+func Register(name string, d driver.Driver, em *event.Manager) {
+	sql.Register(name, Wrap(d, em))
+}
+
+// Open a connection
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, h: d.Hooks}, nil
+}
+
+// conn wrap the driver.Conn
+type conn struct {
+	driver.Conn
+	h *Hooks
+}
+
+// Prepare implements driver.Conn
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var st driver.Stmt
+	var err error
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		st, err = pc.PrepareContext(ctx, query)
+	} else {
+		st, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: st, query: query, conn: c}, nil
+}
+
+// Begin implements driver.Conn
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var t driver.Tx
+	var err error
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		t, err = bc.BeginTx(ctx, opts)
+	} else {
+		t, err = c.Conn.Begin()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return &tx{Tx: t, h: c.h, start: time.Now()}, nil
+}
+
+// ExecContext implements driver.ExecerContext
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.h.onQuery("exec", query, len(args), start, err)
+	}
+	return res, err
+}
+
+// QueryContext implements driver.QueryerContext
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := qc.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.h.onQuery("query", query, len(args), start, err)
+	}
+	return rows, err
+}
+
+// Ping implements driver.Pinger
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// stmt wrap the driver.Stmt
+type stmt struct {
+	driver.Stmt
+	query string
+	conn  *conn
+}
+
+// Exec implements driver.Stmt
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.Stmt.Exec(args)
+	s.conn.h.onQuery("exec", s.query, len(args), start, err)
+	return res, err
+}
+
+// Query implements driver.Stmt
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	s.conn.h.onQuery("query", s.query, len(args), start, err)
+	return rows, err
+}
+
+// ExecContext implements driver.StmtExecContext
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	sc, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return s.Exec(values(args))
+	}
+
+	start := time.Now()
+	res, err := sc.ExecContext(ctx, args)
+	s.conn.h.onQuery("exec", s.query, len(args), start, err)
+	return res, err
+}
+
+// QueryContext implements driver.StmtQueryContext
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	sc, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return s.Query(values(args))
+	}
+
+	start := time.Now()
+	rows, err := sc.QueryContext(ctx, args)
+	s.conn.h.onQuery("query", s.query, len(args), start, err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, fallback to the conn checker.
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+// ColumnConverter implements driver.ColumnConverter
+func (s *stmt) ColumnConverter(idx int) driver.ValueConverter {
+	if cc, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return cc.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vs := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vs[i] = arg.Value
+	}
+	return vs
+}
+
+// tx wrap the driver.Tx
+type tx struct {
+	driver.Tx
+	h     *Hooks
+	start time.Time
+}
+
+// Commit implements driver.Tx
+func (t *tx) Commit() error {
+	err := t.Tx.Commit()
+	t.h.fire(t.h.CommitEvent, event.M{"duration": time.Since(t.start), "error": err})
+	return err
+}
+
+// Rollback implements driver.Tx
+func (t *tx) Rollback() error {
+	err := t.Tx.Rollback()
+	t.h.fire(t.h.RollbackEvent, event.M{"duration": time.Since(t.start), "error": err})
+	return err
+}
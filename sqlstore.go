@@ -0,0 +1,282 @@
+package event
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLStore an EventStore by the database/sql. the table will be created by Init().
+// the snapshots are stored in the table "{Table}_snapshots".
+//
+// each event has a global sequence "seq" in the table, it's the Position of the Journal.
+// the seq is allocated in the append transaction and it's unique, so the concurrent
+// appends are serialized by it, the seq order is the commit order. the conflicted
+// append will be retried, see Retries.
+//
+// Usage:
+// 	store := NewSQLStore(db, "events")
+// 	store.Placeholder = DollarPlaceholder // for postgres
+// 	err := store.Init()
+type SQLStore struct {
+	db *sql.DB
+	// Table name. default is "events"
+	Table string
+	// Placeholder func for generate the n-th(starts from 1) bind var. default is "?"
+	Placeholder func(n int) string
+	// Compressor for compress the event data reach the CompressThreshold. nil is disabled
+	Compressor Compressor
+	// CompressThreshold the min size of the event data JSON for compress
+	CompressThreshold int
+	// Retries the max retry times on the append conflicted with the concurrent appends.
+	Retries int
+}
+
+// DollarPlaceholder the postgres style placeholder. eg: $1, $2
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// NewSQLStore create
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	if table == "" {
+		table = "events"
+	}
+
+	return &SQLStore{db: db, Table: table, Retries: 3}
+}
+
+// Init create the events table if not exists
+func (s *SQLStore) Init() error {
+	_, err := s.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	seq BIGINT NOT NULL UNIQUE,
+	stream VARCHAR(255) NOT NULL,
+	version BIGINT NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	data TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (stream, version)
+)`, s.Table))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s_snapshots (
+	stream VARCHAR(255) NOT NULL PRIMARY KEY,
+	version BIGINT NOT NULL,
+	state TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)`, s.Table))
+	return err
+}
+
+// SaveSnapshot save the snapshot, will replace the old snapshot of the stream.
+func (s *SQLStore) SaveSnapshot(snap *Snapshot) (err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	query := fmt.Sprintf("DELETE FROM %s_snapshots WHERE stream = %s", s.Table, s.bindVar(1))
+	if _, err = tx.Exec(query, snap.Stream); err != nil {
+		return err
+	}
+
+	if snap.Time.IsZero() {
+		snap.Time = time.Now()
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s_snapshots (stream, version, state, created_at) VALUES (%s)", s.Table, s.bindVars(4))
+	_, err = tx.Exec(query, snap.Stream, snap.Version, string(snap.State), snap.Time)
+	return err
+}
+
+// LoadSnapshot load the latest snapshot of the stream. return nil if not exists.
+func (s *SQLStore) LoadSnapshot(stream string) (*Snapshot, error) {
+	query := fmt.Sprintf("SELECT stream, version, state, created_at FROM %s_snapshots WHERE stream = %s", s.Table, s.bindVar(1))
+
+	var state string
+	snap := &Snapshot{}
+	err := s.db.QueryRow(query, stream).Scan(&snap.Stream, &snap.Version, &state, &snap.Time)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snap.State = []byte(state)
+	return snap, nil
+}
+
+// Append events to the stream. the append conflicted with the concurrent appends, by
+// the primary key or the unique seq, will be retried with the new versions and seq.
+// the stream, version, position and time are set to the events after committed.
+func (s *SQLStore) Append(stream string, events ...*StoredEvent) error {
+	for i := 0; ; i++ {
+		seq, err := s.append(stream, events)
+		if err == nil || i >= s.Retries || !s.seqChanged(seq) {
+			return err
+		}
+	}
+}
+
+// seqChanged check the max seq is changed by the concurrent appends
+func (s *SQLStore) seqChanged(seq int64) bool {
+	var cur sql.NullInt64
+	if err := s.db.QueryRow(fmt.Sprintf("SELECT MAX(seq) FROM %s", s.Table)).Scan(&cur); err != nil {
+		return false
+	}
+	return cur.Int64 != seq
+}
+
+// append the events in a transaction, returns the max seq read before insert.
+func (s *SQLStore) append(stream string, events []*StoredEvent) (seq int64, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var last, maxSeq sql.NullInt64
+	query := fmt.Sprintf("SELECT MAX(version) FROM %s WHERE stream = %s", s.Table, s.bindVar(1))
+	if err = tx.QueryRow(query, stream).Scan(&last); err != nil {
+		return 0, err
+	}
+
+	if err = tx.QueryRow(fmt.Sprintf("SELECT MAX(seq) FROM %s", s.Table)).Scan(&maxSeq); err != nil {
+		return 0, err
+	}
+	seq = maxSeq.Int64
+
+	now := time.Now()
+	insert := fmt.Sprintf("INSERT INTO %s (seq, stream, version, name, data, created_at) VALUES (%s)", s.Table, s.bindVars(6))
+	for i, se := range events {
+		bs, err := json.Marshal(se.Data)
+		if err != nil {
+			return seq, err
+		}
+
+		data, err := compressText(s.Compressor, s.CompressThreshold, bs)
+		if err != nil {
+			return seq, err
+		}
+
+		created := se.Time
+		if created.IsZero() {
+			created = now
+		}
+
+		version, pos := uint64(last.Int64)+uint64(i)+1, uint64(seq)+uint64(i)+1
+		if _, err = tx.Exec(insert, pos, stream, version, se.Name, data, created); err != nil {
+			return seq, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return seq, err
+	}
+
+	for i, se := range events {
+		se.Stream = stream
+		se.Version = uint64(last.Int64) + uint64(i) + 1
+		se.Position = uint64(seq) + uint64(i) + 1
+		if se.Time.IsZero() {
+			se.Time = now
+		}
+	}
+	return seq, nil
+}
+
+// Load all events of the stream
+func (s *SQLStore) Load(stream string) ([]*StoredEvent, error) {
+	return s.LoadFrom(stream, 1)
+}
+
+// LoadFrom load the events of the stream from the version(include).
+func (s *SQLStore) LoadFrom(stream string, version uint64) ([]*StoredEvent, error) {
+	query := fmt.Sprintf(
+		"SELECT seq, stream, version, name, data, created_at FROM %s WHERE stream = %s AND version >= %s ORDER BY version",
+		s.Table, s.bindVar(1), s.bindVar(2),
+	)
+
+	rows, err := s.db.Query(query, stream, version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanStoredEvents(rows)
+}
+
+// scanStoredEvents scan the rows of: seq, stream, version, name, data, created_at
+func (s *SQLStore) scanStoredEvents(rows *sql.Rows) ([]*StoredEvent, error) {
+	var ses []*StoredEvent
+	for rows.Next() {
+		var data string
+		se := &StoredEvent{}
+		if err := rows.Scan(&se.Position, &se.Stream, &se.Version, &se.Name, &data, &se.Time); err != nil {
+			return nil, err
+		}
+
+		bs, err := decompressText(s.Compressor, data)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(bs, &se.Data); err != nil {
+			return nil, err
+		}
+		ses = append(ses, se)
+	}
+	return ses, rows.Err()
+}
+
+// ReadAll read events after the position. implements the Journal interface.
+// the events are ordered by the global seq, the position is the seq of the event.
+func (s *SQLStore) ReadAll(position uint64, limit int) ([]*StoredEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := fmt.Sprintf(
+		"SELECT seq, stream, version, name, data, created_at FROM %s WHERE seq > %s ORDER BY seq LIMIT %d",
+		s.Table, s.bindVar(1), limit,
+	)
+
+	rows, err := s.db.Query(query, position)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return s.scanStoredEvents(rows)
+}
+
+func (s *SQLStore) bindVar(n int) string {
+	if s.Placeholder != nil {
+		return s.Placeholder(n)
+	}
+	return "?"
+}
+
+func (s *SQLStore) bindVars(num int) string {
+	ss := make([]string, num)
+	for i := range ss {
+		ss[i] = s.bindVar(i + 1)
+	}
+	return strings.Join(ss, ", ")
+}
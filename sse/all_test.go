@@ -0,0 +1,111 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitStreams(h *Handler, n int) {
+	for h.Streams() != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// readMessage read a SSE message, skip the comment lines
+func readMessage(rd *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return lines
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(lines) > 0 {
+				return lines
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, ":") {
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	em := event.NewManager("test")
+	h := New(em, "order.*", "stock.*")
+	h.Heartbeat = 5 * time.Millisecond
+	h.Filter = func(r *http.Request, e event.Event) bool {
+		return e.Get("secret") == nil
+	}
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "?events=order.*")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	waitStreams(h, 1)
+
+	em.MustFire("stock.changed", nil)
+	em.MustFire("order.created", event.M{"secret": 1})
+	em.MustFire("order.paid", event.M{"id": 1})
+
+	rd := bufio.NewReader(res.Body)
+	lines := readMessage(rd)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "id: 1", lines[0])
+	assert.Equal(t, "event: order.paid", lines[1])
+	assert.Equal(t, `data: {"name":"order.paid","data":{"id":1}}`, lines[2])
+
+	// heartbeat
+	time.Sleep(10 * time.Millisecond)
+	line, err := rd.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, ": ping\n", line)
+
+	assert.NoError(t, h.Close())
+	waitStreams(h, 0)
+
+	res2, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res2.StatusCode)
+	res2.Body.Close()
+}
+
+func TestHandler_Close_multi(t *testing.T) {
+	em := event.NewManager("test")
+	h1, h2 := New(em, "order.*"), New(em, "order.*")
+	assert.Equal(t, 2, em.ListenersCount("order.*"))
+
+	// close h1 will not remove the publisher of h2
+	assert.NoError(t, h1.Close())
+	if !assert.Equal(t, 1, em.ListenersCount("order.*")) {
+		return
+	}
+
+	srv := httptest.NewServer(h2)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	waitStreams(h2, 1)
+
+	em.MustFire("order.created", nil)
+	lines := readMessage(bufio.NewReader(res.Body))
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "event: order.created", lines[1])
+	assert.NoError(t, h2.Close())
+}
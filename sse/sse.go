@@ -0,0 +1,212 @@
+// Package sse provide the http.Handler for stream the events by Server-Sent Events.
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gookit/event"
+)
+
+// Filter func for filter the events sent to the connection
+type Filter func(r *http.Request, e event.Event) bool
+
+// message the encoded event message
+type message struct {
+	id   uint64
+	name string
+	data []byte
+}
+
+// stream the connected stream
+type stream struct {
+	req      *http.Request
+	patterns []string
+	send     chan *message
+}
+
+// match the event is wanted by the stream
+func (s *stream) match(e event.Event) bool {
+	if len(s.patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.patterns {
+		if event.MatchName(pattern, e.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handler stream the events matched the patterns to the connections.
+//
+// the client can filter events by the query "events". eg: "/events?events=order.*,stock.*"
+//
+// Usage:
+// 	h := sse.New(em, "order.*", "stock.*")
+// 	h.Heartbeat = 15 * time.Second
+// 	http.Handle("/events", h)
+type Handler struct {
+	mu       sync.RWMutex
+	em       *event.Manager
+	seq      uint64
+	streams map[*stream]struct{}
+	scope   *event.Scope
+	done    chan struct{}
+	closed  bool
+	// Codec for encode the event data. default is event.DefaultCodec
+	Codec event.Codec
+	// Filter the events for the connection. optional
+	Filter Filter
+	// Heartbeat the interval for send comment line to keep alive. 0 is disabled
+	Heartbeat time.Duration
+	// BufferSize the send buffer size of each connection. default is 64.
+	// the events will be dropped if the buffer is full.
+	BufferSize int
+}
+
+// New create a SSE handler for the event patterns
+func New(em *event.Manager, patterns ...string) *Handler {
+	h := &Handler{
+		em:         em,
+		streams:    make(map[*stream]struct{}),
+		scope:      em.NewScope(),
+		done:       make(chan struct{}),
+		Codec:      event.DefaultCodec,
+		Heartbeat:  30 * time.Second,
+		BufferSize: 64,
+	}
+
+	for _, pattern := range patterns {
+		h.scope.On(pattern, event.ListenerFunc(h.broadcast))
+	}
+	return h
+}
+
+// Streams get the number of connected streams
+func (h *Handler) Streams() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.streams)
+}
+
+// broadcast the event to the streams. the event is encoded on broadcast,
+// because the event instance may be reused after dispatch.
+func (h *Handler) broadcast(e event.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var msg *message
+	for s := range h.streams {
+		if !s.match(e) || (h.Filter != nil && !h.Filter(s.req, e)) {
+			continue
+		}
+
+		if msg == nil {
+			bs, err := h.Codec.Encode(e)
+			if err != nil {
+				return err
+			}
+
+			h.seq++
+			msg = &message{id: h.seq, name: e.Name(), data: bs}
+		}
+
+		select {
+		case s.send <- msg:
+		default: // drop
+		}
+	}
+	return nil
+}
+
+// ServeHTTP stream the events until the request canceled or the handler closed.
+// the event is written as SSE message:
+// 	id: 1
+// 	event: order.created
+// 	data: {"name":"order.created","data":{"id":1}}
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	s := &stream{req: r, send: make(chan *message, h.bufferSize())}
+	if str := r.URL.Query().Get("events"); str != "" {
+		s.patterns = strings.Split(str, ",")
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		http.Error(w, "the stream is closed", http.StatusServiceUnavailable)
+		return
+	}
+	h.streams[s] = struct{}{}
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.streams, s)
+		h.mu.Unlock()
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var tick <-chan time.Time
+	if h.Heartbeat > 0 {
+		ticker := time.NewTicker(h.Heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case <-tick:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case msg := <-s.send:
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.id, msg.name, msg.data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func (h *Handler) bufferSize() int {
+	if h.BufferSize > 0 {
+		return h.BufferSize
+	}
+	return 64
+}
+
+// Close stop stream events and end all connections
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	close(h.done)
+	h.mu.Unlock()
+
+	h.scope.Close()
+	return nil
+}
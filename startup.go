@@ -0,0 +1,115 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Initializer interface. the listener can implement it for open the connections or
+// pre-warm the caches before handle the events. see Manager.Start()
+type Initializer interface {
+	Init(ctx context.Context, em *Manager) error
+}
+
+// Start the manager, change the state to StateRunning from the created or stopped.
+// the closed manager cannot be started, will return ErrClosed.
+// will init the registered listeners implemented the Initializer, by the call order.
+// after started, the Initializer listeners will be inited on registration, and the
+// registration will return the error if the init failed.
+// the listener registered on multi names will be inited once.
+//
+// Usage:
+// 	em.On("order.created", mailer) // the mailer implements the Initializer
+// 	if err := em.Start(ctx); err != nil {
+// 		log.Fatal(err)
+// 	}
+func (em *Manager) Start(ctx context.Context) error {
+	if em.IsClosed() {
+		return ErrClosed
+	}
+
+	if s := em.State(); s == StateRunning || s == StatePaused {
+		return nil
+	}
+
+	if err := em.transition(MetaManagerStarted, StateRunning, StateCreated, StateStopped); err != nil {
+		return err
+	}
+
+	em.rLock()
+	var items []*ListenerItem
+	for _, lq := range em.listeners {
+		for _, li := range lq.Items() {
+			if _, ok := li.Listener.(Initializer); ok {
+				items = append(items, li)
+			}
+		}
+	}
+	em.rUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return lessItem(items[i], items[j])
+	})
+
+	var errs Errors
+	for _, li := range items {
+		if err := em.initListener(ctx, li); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// IsStarted check the manager is started, the state is running or paused.
+func (em *Manager) IsStarted() bool {
+	s := em.State()
+	return s == StateRunning || s == StatePaused
+}
+
+// initOnAdd init the listener on registration, if the manager is started.
+func (em *Manager) initOnAdd(li *ListenerItem) error {
+	if !em.IsStarted() {
+		return nil
+	}
+	return em.initListener(context.Background(), li)
+}
+
+// initListener init the listener if it's an Initializer and not inited.
+func (em *Manager) initListener(ctx context.Context, li *ListenerItem) error {
+	in, ok := li.Listener.(Initializer)
+	if !ok {
+		return nil
+	}
+
+	// the comparable listener is inited once
+	var key interface{}
+	if reflect.TypeOf(li.Listener).Comparable() {
+		key = li.Listener
+		em.initMu.Lock()
+		if em.inited[key] {
+			em.initMu.Unlock()
+			return nil
+		}
+		em.initMu.Unlock()
+	}
+
+	if err := in.Init(ctx, em); err != nil {
+		return fmt.Errorf("event: init the listener '%s' error: %w", li.Name(), err)
+	}
+
+	if key != nil {
+		em.initMu.Lock()
+		if em.inited == nil {
+			em.inited = make(map[interface{}]bool)
+		}
+		em.inited[key] = true
+		em.initMu.Unlock()
+	}
+	return nil
+}
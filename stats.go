@@ -0,0 +1,137 @@
+package event
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats the runtime counters of the manager
+type Stats struct {
+	// Fired the number of dispatched events
+	Fired uint64 `json:"fired"`
+	// Failed the number of dispatched events returns error
+	Failed uint64 `json:"failed"`
+	// Handled the number of the listener calls
+	Handled uint64 `json:"handled"`
+	// Paused the number of dropped events by paused
+	Paused uint64 `json:"paused"`
+	// Sampled the number of dropped events by the sample rate. see Sample()
+	Sampled uint64 `json:"sampled"`
+	// Duplicates the number of dropped duplicate events. see WithIdempotency()
+	Duplicates uint64 `json:"duplicates"`
+	// Pending the number of the pending async dispatches and listeners. see Pressure()
+	Pending int64 `json:"pending"`
+	// TailDropped the number of dropped events by the observers channel is full. see Tail()
+	TailDropped uint64 `json:"tail_dropped"`
+	// Events the number of dispatched events by name
+	Events map[string]uint64 `json:"events"`
+	// Unheard the number of fired events but no listener by name
+	Unheard map[string]uint64 `json:"unheard"`
+	// Unfired the listened names or patterns, but never fired since the stats is reset
+	Unfired []string `json:"unfired"`
+	// Since the time of the stats start, it's the created time or reset time of the manager
+	Since time.Time `json:"since"`
+}
+
+// stats the counters, all are updated by atomic
+type stats struct {
+	fired, failed, handled, paused, sampled, duplicates uint64
+	// the fired counter by name. value is *uint64
+	events sync.Map
+	// the fired but no listener counter by name. value is *uint64
+	unheard sync.Map
+	// the start time, unix nano
+	since int64
+}
+
+func (s *stats) onFire(name string, err error) {
+	atomic.AddUint64(&s.fired, 1)
+	if err != nil {
+		atomic.AddUint64(&s.failed, 1)
+	}
+
+	incr(&s.events, name)
+}
+
+// onUnheard record the event is fired, but has no listener
+func (s *stats) onUnheard(name string) {
+	incr(&s.unheard, name)
+}
+
+func incr(m *sync.Map, name string) {
+	n, ok := m.Load(name)
+	if !ok {
+		n, _ = m.LoadOrStore(name, new(uint64))
+	}
+	atomic.AddUint64(n.(*uint64), 1)
+}
+
+func loadCounters(m *sync.Map) map[string]uint64 {
+	mp := make(map[string]uint64)
+	m.Range(func(k, v interface{}) bool {
+		mp[k.(string)] = atomic.LoadUint64(v.(*uint64))
+		return true
+	})
+	return mp
+}
+
+// Stats get the runtime counters of the manager.
+// the Unheard and Unfired can help to find the dead wiring and typos of the event names.
+func (em *Manager) Stats() *Stats {
+	s := &Stats{
+		Fired:       atomic.LoadUint64(&em.stats.fired),
+		Failed:      atomic.LoadUint64(&em.stats.failed),
+		Handled:     atomic.LoadUint64(&em.stats.handled),
+		Paused:      atomic.LoadUint64(&em.stats.paused),
+		Sampled:     atomic.LoadUint64(&em.stats.sampled),
+		Duplicates:  atomic.LoadUint64(&em.stats.duplicates),
+		Pending:     em.Pending(),
+		TailDropped: atomic.LoadUint64(&em.tails.dropped),
+		Events:      loadCounters(&em.stats.events),
+		Unheard:     loadCounters(&em.stats.unheard),
+		Since:       time.Unix(0, atomic.LoadInt64(&em.stats.since)),
+	}
+
+	s.Unfired = em.unfiredNames(s.Events)
+	return s
+}
+
+// unfiredNames find the listened names and patterns, which are not matched any fired event name
+func (em *Manager) unfiredNames(fired map[string]uint64) []string {
+	names := make([]string, 0)
+	for name := range em.ListenedNames() {
+		var matched bool
+		for firedName := range fired {
+			if MatchName(name, em.normalize(firedName)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ResetStats reset the runtime counters, and start a new stats window
+func (em *Manager) ResetStats() {
+	atomic.StoreUint64(&em.stats.fired, 0)
+	atomic.StoreUint64(&em.stats.failed, 0)
+	atomic.StoreUint64(&em.stats.handled, 0)
+	atomic.StoreUint64(&em.stats.paused, 0)
+	atomic.StoreUint64(&em.stats.sampled, 0)
+	atomic.StoreUint64(&em.stats.duplicates, 0)
+	atomic.StoreUint64(&em.tails.dropped, 0)
+	atomic.StoreInt64(&em.stats.since, time.Now().UnixNano())
+	for _, m := range []*sync.Map{&em.stats.events, &em.stats.unheard} {
+		m.Range(func(k, v interface{}) bool {
+			m.Delete(k)
+			return true
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StreamKey the data key for setting the stream name of the stored event.
+// if not set, the event name will be used as stream name.
+const StreamKey = "__stream"
+
+// StoredEvent a persisted event in the EventStore
+type StoredEvent struct {
+	// Stream name. eg: an aggregate ID "order-23"
+	Stream string `json:"stream"`
+	// Version the event version in the stream, starts from 1. it's set by the store.
+	Version uint64 `json:"version"`
+	// Position the global position in the store, starts from 1. it's set by the Journal.
+	Position uint64 `json:"position,omitempty"`
+	// Name the event name
+	Name string `json:"name"`
+	// Data the event data
+	Data M `json:"data"`
+	// Time the event stored time. it's set by the store if empty.
+	Time time.Time `json:"time"`
+}
+
+// ToEvent convert to a BasicEvent
+func (se *StoredEvent) ToEvent() *BasicEvent {
+	data := make(M, len(se.Data))
+	for k, v := range se.Data {
+		data[k] = v
+	}
+	return NewBasic(se.Name, data)
+}
+
+// EventStore interface for persist events by streams
+type EventStore interface {
+	// Append events to the stream, the versions will be set by the store.
+	Append(stream string, events ...*StoredEvent) error
+	// Load all events of the stream
+	Load(stream string) ([]*StoredEvent, error)
+	// LoadFrom load the events of the stream from the version(include).
+	LoadFrom(stream string, version uint64) ([]*StoredEvent, error)
+}
+
+// NewStoredEvent create StoredEvent from the event. the stream is got from
+// the event data by StreamKey, or use the event name.
+func NewStoredEvent(e Event) *StoredEvent {
+	stream, _ := e.Get(StreamKey).(string)
+	if stream == "" {
+		stream = e.Name()
+	}
+
+	data := make(M, len(e.Data()))
+	for k, v := range e.Data() {
+		if k != StreamKey {
+			data[k] = v
+		}
+	}
+	return &StoredEvent{Stream: stream, Name: e.Name(), Data: data}
+}
+
+// WithEventStore persist the fired events to the store before dispatch.
+// can limit the event names by patterns, default persist all events.
+// if persist failed, the event will not be dispatched and return the error.
+//
+// Usage:
+// 	em := NewManager("app", WithEventStore(NewMemoryStore(), "order.*"))
+// 	// store the event to the stream "order-23"
+// 	em.Fire("order.created", M{StreamKey: "order-23", "amount": 100})
+func WithEventStore(store EventStore, patterns ...string) Option {
+	return func(o *Options) {
+		o.EventStore = store
+		o.StorePatterns = patterns
+	}
+}
+
+// storeMatched check the event name should be persisted to the EventStore.
+func (em *Manager) storeMatched(name string) bool {
+	if em.opts.EventStore == nil {
+		return false
+	}
+
+	if len(em.opts.StorePatterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range em.opts.StorePatterns {
+		if MatchName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// persist the event to the EventStore, if the event name is matched.
+func (em *Manager) persist(e Event) error {
+	if !em.storeMatched(e.Name()) {
+		return nil
+	}
+
+	se := NewStoredEvent(e)
+	if err := em.opts.EventStore.Append(se.Stream, se); err != nil {
+		return fmt.Errorf("event: persist event '%s' error: %w", e.Name(), err)
+	}
+	return nil
+}
+
+/*************************************************************
+ * Memory store
+ *************************************************************/
+
+// MemoryStore an in-memory EventStore. useful for testing.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	streams map[string][]*StoredEvent
+	// all events by the append order. see Journal
+	journal []*StoredEvent
+	// the stream snapshots. see SnapshotStore
+	snapshots memorySnapshots
+}
+
+// NewMemoryStore create
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: make(map[string][]*StoredEvent)}
+}
+
+// Append events to the stream
+func (s *MemoryStore) Append(stream string, events ...*StoredEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := s.streams[stream]
+	for _, se := range events {
+		se.Stream = stream
+		se.Version = uint64(len(list) + 1)
+		if se.Time.IsZero() {
+			se.Time = time.Now()
+		}
+
+		list = append(list, se)
+		s.journal = append(s.journal, se)
+		se.Position = uint64(len(s.journal))
+	}
+
+	s.streams[stream] = list
+	return nil
+}
+
+// Load all events of the stream
+func (s *MemoryStore) Load(stream string) ([]*StoredEvent, error) {
+	return s.LoadFrom(stream, 1)
+}
+
+// LoadFrom load the events of the stream from the version(include).
+func (s *MemoryStore) LoadFrom(stream string, version uint64) ([]*StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := s.streams[stream]
+	if version < 1 {
+		version = 1
+	}
+
+	if version > uint64(len(list)) {
+		return nil, nil
+	}
+
+	ses := make([]*StoredEvent, 0, uint64(len(list))-version+1)
+	for _, se := range list[version-1:] {
+		ses = append(ses, copyStoredEvent(se))
+	}
+	return ses, nil
+}
+
+// ReadAll read events after the position. implements the Journal interface
+func (s *MemoryStore) ReadAll(position uint64, limit int) ([]*StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if position >= uint64(len(s.journal)) {
+		return nil, nil
+	}
+
+	list := s.journal[position:]
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+
+	ses := make([]*StoredEvent, 0, len(list))
+	for _, se := range list {
+		ses = append(ses, copyStoredEvent(se))
+	}
+	return ses, nil
+}
+
+// Streams get all stream names
+func (s *MemoryStore) Streams() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.streams))
+	for name := range s.streams {
+		names = append(names, name)
+	}
+	return names
+}
+
+// copyStoredEvent copy the stored event, the data is deep copied by JSON,
+// so the loaded events has same value types as the SQLStore.
+func copyStoredEvent(se *StoredEvent) *StoredEvent {
+	cp := *se
+	if bs, err := json.Marshal(se.Data); err == nil {
+		cp.Data = nil
+		_ = json.Unmarshal(bs, &cp.Data)
+	}
+	return &cp
+}
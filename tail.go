@@ -0,0 +1,125 @@
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// tail the observer of the dispatched events. see Manager.Tail()
+type tail struct {
+	pattern string
+	ch      chan Event
+	once    sync.Once
+}
+
+func (t *tail) close() {
+	t.once.Do(func() {
+		close(t.ch)
+	})
+}
+
+// tails storage the observers
+type tails struct {
+	sync.Mutex
+	list []*tail
+	// the number of the observers, for fast check
+	num int32
+	// the number of the dropped events on the channel is full
+	dropped uint64
+}
+
+// Tail observe the dispatched events matched the pattern. returns a channel of
+// the event copies, and the func for stop observe, the channel will be closed on stop.
+//
+// the events are sent after the listeners called, will be dropped if the channel
+// is full (see Stats().TailDropped), so the dispatch will never be blocked.
+// it's intended for debuggers and dashboards rather than business listeners,
+// the observers are not the listeners and do not affect the dispatch.
+//
+// Usage:
+// 	ch, stop := em.Tail("order.*", 100)
+// 	defer stop()
+//
+// 	for e := range ch {
+// 		fmt.Println(e.Name(), e.Data())
+// 	}
+func (em *Manager) Tail(pattern string, size ...int) (<-chan Event, func()) {
+	n := 64
+	if len(size) > 0 && size[0] > 0 {
+		n = size[0]
+	}
+
+	t := &tail{pattern: em.normalize(pattern), ch: make(chan Event, n)}
+	if em.IsClosed() {
+		t.close()
+		return t.ch, func() {}
+	}
+
+	em.tails.Lock()
+	em.tails.list = append(em.tails.list, t)
+	atomic.StoreInt32(&em.tails.num, int32(len(em.tails.list)))
+	em.tails.Unlock()
+
+	return t.ch, func() {
+		em.untail(t)
+	}
+}
+
+// untail remove the observer and close it's channel
+func (em *Manager) untail(t *tail) {
+	em.tails.Lock()
+	for i, item := range em.tails.list {
+		if item == t {
+			em.tails.list = append(em.tails.list[:i:i], em.tails.list[i+1:]...)
+			break
+		}
+	}
+	atomic.StoreInt32(&em.tails.num, int32(len(em.tails.list)))
+
+	// close under lock, the tap will not send to the closed channel.
+	t.close()
+	em.tails.Unlock()
+}
+
+// closeTails remove all observers and close the channels
+func (em *Manager) closeTails() {
+	em.tails.Lock()
+	for _, t := range em.tails.list {
+		t.close()
+	}
+	em.tails.list = nil
+	atomic.StoreInt32(&em.tails.num, 0)
+	em.tails.Unlock()
+}
+
+// tap send the event copy to the matched observers
+func (em *Manager) tap(e Event) {
+	if atomic.LoadInt32(&em.tails.num) == 0 {
+		return
+	}
+
+	var cp Event
+	em.tails.Lock()
+	defer em.tails.Unlock()
+
+	for _, t := range em.tails.list {
+		if !MatchName(t.pattern, e.Name()) {
+			continue
+		}
+
+		if cp == nil {
+			// the observers are sinks, redact the data by the rules
+			data := em.RedactData(e.Name(), copyMap(e.Data()))
+
+			be := NewBasic(e.Name(), data)
+			be.Abort(e.IsAborted())
+			cp = be
+		}
+
+		select {
+		case t.ch <- cp:
+		default:
+			atomic.AddUint64(&em.tails.dropped, 1)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package event
+
+import "time"
+
+// TraceEntry the trace of a listener call. see Tracer
+type TraceEntry struct {
+	// Listener the listener name. see ListenerItem.Name()
+	Listener string
+	// Listened the listened name of the listener. eg: "app.*"
+	Listened string
+	Priority int
+	// Async the listener is called in a new goroutine, the Duration and Err are not recorded.
+	Async    bool
+	Duration time.Duration
+	Err      error
+}
+
+// Tracer interface. the event can implement it for record the called listeners on fire.
+// the BasicEvent has implemented it. see WithTrace()
+type Tracer interface {
+	// Trace get the called listeners of the last fire, in the call order.
+	Trace() []TraceEntry
+	// SetTrace is called by the manager after the listeners are called.
+	SetTrace(trace []TraceEntry)
+}
+
+// WithTrace enable record the called listeners to the event on fire.
+// the event should implement the Tracer interface.
+//
+// NOTICE: the trace is set to the event instance, should not fire the same instance concurrently.
+//
+// Usage:
+// 	em := NewManager("app", WithTrace())
+// 	err, e := em.Fire("order.created", nil)
+// 	for _, t := range e.(Tracer).Trace() {
+// 		fmt.Println(t.Listener, t.Duration, t.Err)
+// 	}
+func WithTrace() Option {
+	return func(o *Options) {
+		o.Trace = true
+	}
+}
+
+// Trace get the called listeners of the last fire. only recorded on the WithTrace() is enabled.
+func (e *BasicEvent) Trace() []TraceEntry {
+	return e.trace
+}
+
+// SetTrace set the called listeners
+func (e *BasicEvent) SetTrace(trace []TraceEntry) {
+	e.trace = trace
+}
+
+// Trace get the called listeners, only recorded on the WithTrace() is enabled.
+func (dc *DispatchContext) Trace() []TraceEntry {
+	return dc.trace
+}
+
+// traceStart returns the start time if tracing is enabled
+func (em *Manager) traceStart() (start time.Time) {
+	if em.opts.Trace {
+		start = time.Now()
+	}
+	return
+}
+
+// traceCall record the listener call to the dispatch context
+func (em *Manager) traceCall(dc *DispatchContext, g *listenerGroup, li *ListenerItem, start time.Time, err error) {
+	if !em.opts.Trace {
+		return
+	}
+
+	entry := TraceEntry{Listener: li.Name(), Listened: g.name, Priority: li.EffectivePriority(), Async: li.Async, Err: err}
+	if !li.Async {
+		entry.Duration = time.Since(start)
+	}
+	dc.trace = append(dc.trace, entry)
+}
+
+// setTrace set the trace to the event, if tracing is enabled and the event implements Tracer.
+func (em *Manager) setTrace(dc *DispatchContext) {
+	if !em.opts.Trace {
+		return
+	}
+
+	if t, ok := dc.event.(Tracer); ok {
+		t.SetTrace(dc.trace)
+	}
+}
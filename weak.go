@@ -0,0 +1,43 @@
+package event
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// OnWeak register a event listener, which is bound to the owner. the manager does not keep
+// the owner alive, the listener will be removed after the owner is garbage collected.
+//
+// NOTICE:
+// 	- the owner must be a pointer, and its finalizer will be replaced.
+// 	- the listener must not reference the owner, otherwise the owner will never be collected.
+//
+// Usage:
+// 	view := &View{}
+// 	ch := view.updates
+// 	em.OnWeak("data.changed", view, ListenerFunc(func(e Event) error {
+// 		ch <- e.Data()
+// 		return nil
+// 	}))
+func (em *Manager) OnWeak(name string, owner interface{}, listener Listener, priority ...int) {
+	pv := Normal
+	if len(priority) > 0 {
+		pv = priority[0]
+	}
+
+	var dead int32
+	li := &ListenerItem{Priority: pv, Listener: listener, Alive: func() bool {
+		return atomic.LoadInt32(&dead) == 0
+	}}
+
+	name, err := em.addItem(name, li)
+	if err != nil {
+		em.fail(err)
+		return
+	}
+
+	runtime.SetFinalizer(owner, func(interface{}) {
+		atomic.StoreInt32(&dead, 1)
+		em.removeItems(name, []*ListenerItem{li})
+	})
+}
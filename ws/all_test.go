@@ -0,0 +1,148 @@
+package ws
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gookit/event"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConn struct {
+	in   chan []byte
+	out  chan []byte
+	once sync.Once
+}
+
+func newTestConn() *testConn {
+	return &testConn{in: make(chan []byte, 4), out: make(chan []byte, 4)}
+}
+
+func (c *testConn) ReadMessage() ([]byte, error) {
+	bs, ok := <-c.in
+	if !ok {
+		return nil, errors.New("closed")
+	}
+	return bs, nil
+}
+
+func (c *testConn) WriteMessage(data []byte) error {
+	c.out <- data
+	return nil
+}
+
+func (c *testConn) Close() error {
+	c.once.Do(func() { close(c.in) })
+	return nil
+}
+
+func waitClients(b *Broadcaster, n int) {
+	for b.Clients() != n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	em := event.NewManager("test")
+	b := New(em, "order.*")
+	b.AllowFire("ui.*")
+	b.Validate = func(e event.Event) error {
+		if e.Get("id") == nil {
+			return errors.New("the id is required")
+		}
+		return nil
+	}
+
+	c1, c2 := newTestConn(), newTestConn()
+	errs := make(chan error, 2)
+	go func() { errs <- b.Serve(c1, "c1", nil) }()
+	go func() {
+		errs <- b.Serve(c2, "c2", func(e event.Event) bool {
+			return e.Name() != "order.created"
+		})
+	}()
+	waitClients(b, 2)
+
+	em.MustFire("order.created", event.M{"id": 1})
+	em.MustFire("order.paid", event.M{"id": 1})
+	assert.Contains(t, string(<-c1.out), "order.created")
+	assert.Contains(t, string(<-c1.out), "order.paid")
+	assert.Contains(t, string(<-c2.out), "order.paid")
+
+	// client fire events
+	var got []event.Event
+	em.On("*", event.ListenerFunc(func(e event.Event) error {
+		if strings.HasPrefix(e.Name(), "ui.") {
+			got = append(got, e)
+		}
+		return nil
+	}))
+
+	c1.in <- []byte(`{"name":"ui.click","data":{"id":2}}`)
+	c1.in <- []byte(`{"name":"ui.click","data":{}}`)
+	assert.Contains(t, string(<-c1.out), "the id is required")
+	c1.in <- []byte(`{"name":"order.created","data":{"id":3}}`)
+	assert.Contains(t, string(<-c1.out), "not allowed")
+	assert.Len(t, got, 1)
+	assert.Equal(t, "c1", got[0].Get(OriginKey))
+
+	// client disconnect
+	assert.NoError(t, c2.Close())
+	assert.Error(t, <-errs)
+	assert.Equal(t, 1, b.Clients())
+
+	assert.NoError(t, b.Close())
+	assert.Error(t, <-errs)
+	assert.Equal(t, 0, b.Clients())
+}
+
+func TestBroadcaster_Dropped(t *testing.T) {
+	em := event.NewManager("test")
+	b := New(em, "order.*")
+	b.BufferSize = 1
+	b.Filter = func(e event.Event) bool {
+		return e.Get("skip") == nil
+	}
+
+	c := newTestConn()
+	c.out = make(chan []byte)
+	go func() { _ = b.Serve(c, "c", nil) }()
+	waitClients(b, 1)
+
+	em.MustFire("order.skip", event.M{"skip": true})
+	for i := 0; i < 3; i++ {
+		em.MustFire("order.created", nil)
+	}
+
+	b.mu.RLock()
+	assert.True(t, b.Dropped > 0)
+	b.mu.RUnlock()
+	assert.Contains(t, string(<-c.out), "order.created")
+	assert.NoError(t, b.Close())
+}
+
+func TestBroadcaster_Close_multi(t *testing.T) {
+	em := event.NewManager("test")
+	b1, b2 := New(em, "order.*"), New(em, "order.*")
+
+	c := newTestConn()
+	errs := make(chan error, 1)
+	go func() { errs <- b2.Serve(c, "c", nil) }()
+	waitClients(b2, 1)
+
+	// close b1 will not remove the publisher of b2
+	assert.NoError(t, b1.Close())
+	em.MustFire("order.created", nil)
+	select {
+	case bs := <-c.out:
+		assert.Contains(t, string(bs), "order.created")
+	case <-time.After(time.Second):
+		t.Fatal("the event is not broadcast")
+	}
+
+	assert.NoError(t, b2.Close())
+	assert.Error(t, <-errs)
+}
@@ -0,0 +1,256 @@
+// Package ws broadcast the events to the connected WebSocket clients.
+//
+// the package has no client dependency, the Conn can be wrapped from the
+// gorilla/websocket or nhooyr.io/websocket connection.
+package ws
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gookit/event"
+)
+
+// OriginKey the event data key for mark the event is fired by the WebSocket client.
+// the events has the key will not be broadcast again, for avoid echo.
+const OriginKey = "__ws_client"
+
+// Conn the WebSocket connection interface.
+//
+// Usage by gorilla:
+// 	func (c *conn) ReadMessage() ([]byte, error) {
+// 		_, bs, err := c.Conn.ReadMessage()
+// 		return bs, err
+// 	}
+//
+// 	func (c *conn) WriteMessage(data []byte) error {
+// 		return c.Conn.WriteMessage(websocket.TextMessage, data)
+// 	}
+type Conn interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(data []byte) error
+	Close() error
+}
+
+// Filter func for filter the events sent to the client
+type Filter func(e event.Event) bool
+
+// client the connected client
+type client struct {
+	id     string
+	conn   Conn
+	filter Filter
+	send   chan []byte
+}
+
+// Broadcaster streams the events matched the patterns to the WebSocket clients.
+//
+// Usage:
+// 	b := ws.New(em, "order.*", "stock.*")
+// 	// allow clients fire the "ui.*" events
+// 	b.AllowFire("ui.*")
+//
+// 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+// 		c, _ := upgrader.Upgrade(w, r, nil)
+// 		_ = b.Serve(&conn{c}, r.RemoteAddr, nil)
+// 	})
+type Broadcaster struct {
+	mu      sync.RWMutex
+	em      *event.Manager
+	clients map[*client]struct{}
+	// the patterns for allow fire
+	allows []string
+	// scope of the broadcast listeners
+	scope *event.Scope
+	// Codec for encode and decode events. default is event.DefaultCodec
+	Codec event.Codec
+	// Filter the events for all clients. optional
+	Filter Filter
+	// Validate the events fired by client. optional
+	Validate func(e event.Event) error
+	// BufferSize the send buffer size of each client. default is 64.
+	// the events will be dropped if the buffer is full.
+	BufferSize int
+	// Dropped the number of dropped messages
+	Dropped uint64
+}
+
+// New create a broadcaster for the event patterns
+func New(em *event.Manager, patterns ...string) *Broadcaster {
+	b := &Broadcaster{
+		em:         em,
+		clients:    make(map[*client]struct{}),
+		scope:      em.NewScope(),
+		Codec:      event.DefaultCodec,
+		BufferSize: 64,
+	}
+
+	for _, pattern := range patterns {
+		b.scope.On(pattern, event.ListenerFunc(b.broadcast))
+	}
+	return b
+}
+
+// AllowFire allow the clients fire the events matched the patterns
+func (b *Broadcaster) AllowFire(patterns ...string) *Broadcaster {
+	b.mu.Lock()
+	b.allows = append(b.allows, patterns...)
+	b.mu.Unlock()
+	return b
+}
+
+// Clients get the number of connected clients
+func (b *Broadcaster) Clients() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
+}
+
+func (b *Broadcaster) broadcast(e event.Event) error {
+	if e.Get(OriginKey) != nil || (b.Filter != nil && !b.Filter(e)) {
+		return nil
+	}
+
+	var bs []byte
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c := range b.clients {
+		if c.filter != nil && !c.filter(e) {
+			continue
+		}
+
+		if bs == nil {
+			var err error
+			if bs, err = b.Codec.Encode(e); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case c.send <- bs:
+		default:
+			b.Dropped++
+		}
+	}
+	return nil
+}
+
+// Serve the connection until it's closed, the filter is optional for the client.
+// the messages from the client will be fired if the event name is allowed.
+func (b *Broadcaster) Serve(conn Conn, id string, filter Filter) error {
+	size := b.BufferSize
+	if size <= 0 {
+		size = 64
+	}
+
+	c := &client{id: id, conn: conn, filter: filter, send: make(chan []byte, size)}
+	b.mu.Lock()
+	b.clients[c] = struct{}{}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go b.write(c, done)
+
+	defer func() {
+		b.mu.Lock()
+		if _, ok := b.clients[c]; ok {
+			delete(b.clients, c)
+			close(c.send)
+		}
+		b.mu.Unlock()
+		<-done
+		_ = conn.Close()
+	}()
+
+	for {
+		bs, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		if err = b.Handle(id, bs); err != nil {
+			b.reply(c, err)
+		}
+	}
+}
+
+func (b *Broadcaster) write(c *client, done chan struct{}) {
+	defer close(done)
+	for bs := range c.send {
+		if err := c.conn.WriteMessage(bs); err != nil {
+			_ = c.conn.Close()
+			break
+		}
+	}
+
+	// drain the messages on write failed
+	for range c.send {
+	}
+}
+
+// reply the error to the client
+func (b *Broadcaster) reply(c *client, err error) {
+	bs, _ := b.Codec.Encode(event.NewBasic("ws.error", event.M{"error": err.Error()}))
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if _, ok := b.clients[c]; ok {
+		select {
+		case c.send <- bs:
+		default:
+		}
+	}
+}
+
+// Handle the message from the client, fire the decoded event if the name is allowed.
+func (b *Broadcaster) Handle(id string, bs []byte) error {
+	e, err := b.Codec.Decode(bs)
+	if err != nil {
+		return err
+	}
+
+	if !b.allowed(e.Name()) {
+		return fmt.Errorf("ws: the event '%s' is not allowed to fire", e.Name())
+	}
+
+	if b.Validate != nil {
+		if err = b.Validate(e); err != nil {
+			return err
+		}
+	}
+
+	e.Set(OriginKey, id)
+	return b.em.FireEvent(e)
+}
+
+func (b *Broadcaster) allowed(name string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, pattern := range b.allows {
+		if event.MatchName(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stop broadcast events and close all client connections
+func (b *Broadcaster) Close() error {
+	b.scope.Close()
+
+	b.mu.Lock()
+	conns := make([]Conn, 0, len(b.clients))
+	for c := range b.clients {
+		delete(b.clients, c)
+		close(c.send)
+		conns = append(conns, c.conn)
+	}
+	b.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+	return nil
+}